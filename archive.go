@@ -0,0 +1,164 @@
+package gositemapfetcher
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Archiver records the raw bodies of fetched sitemaps so a walk can be
+// audited or reproduced later.
+type Archiver interface {
+	// Begin starts recording one sitemap response. The returned writer
+	// receives the body exactly as it was received from the server.
+	Begin(meta ArchiveMeta) (ArchiveWriter, error)
+}
+
+// ArchiveWriter receives a sitemap body being archived.
+type ArchiveWriter interface {
+	io.Writer
+	// Commit is called once the complete body has been written.
+	Commit() error
+	// Abort discards a body that could not be read completely.
+	Abort() error
+}
+
+// ArchiveMeta describes an archived sitemap response.
+type ArchiveMeta struct {
+	URL        string      `json:"url"`
+	Method     string      `json:"method,omitempty"` // empty for GET
+	FetchedAt  time.Time   `json:"fetched_at"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Size       int64       `json:"size"`
+	SHA256     string      `json:"sha256,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// TarArchive is an Archiver writing a tar stream. Each sitemap becomes a
+// gzip-compressed body entry plus a JSON metadata entry.
+type TarArchive struct {
+	mu    sync.Mutex
+	tw    *tar.Writer
+	count int
+}
+
+// NewTarArchive returns a TarArchive writing to w. Call Close when the walk
+// has finished to flush the tar footer.
+func NewTarArchive(w io.Writer) *TarArchive {
+	return &TarArchive{tw: tar.NewWriter(w)}
+}
+
+// Begin implements Archiver.
+func (a *TarArchive) Begin(meta ArchiveMeta) (ArchiveWriter, error) {
+	w := &tarArchiveWriter{archive: a, meta: meta, hash: sha256.New()}
+	w.gz = gzip.NewWriter(&w.buf)
+	return w, nil
+}
+
+// Close writes the tar footer.
+func (a *TarArchive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.tw.Close()
+}
+
+func (a *TarArchive) add(meta ArchiveMeta, body []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.count++
+	prefix := fmt.Sprintf("%06d", a.count)
+	meta.Body = prefix + ".body.gz"
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := a.writeEntry(meta.Body, meta.FetchedAt, body); err != nil {
+		return err
+	}
+	return a.writeEntry(prefix+".meta.json", meta.FetchedAt, data)
+}
+
+func (a *TarArchive) writeEntry(name string, modTime time.Time, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.tw.Write(data)
+	return err
+}
+
+type tarArchiveWriter struct {
+	archive *TarArchive
+	meta    ArchiveMeta
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	hash    hash.Hash
+	size    int64
+}
+
+func (w *tarArchiveWriter) Write(p []byte) (int, error) {
+	w.hash.Write(p)
+	w.size += int64(len(p))
+	return w.gz.Write(p)
+}
+
+func (w *tarArchiveWriter) Commit() error {
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+	w.meta.Size = w.size
+	w.meta.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	return w.archive.add(w.meta, w.buf.Bytes())
+}
+
+func (w *tarArchiveWriter) Abort() error {
+	w.buf.Reset()
+	return nil
+}
+
+// archiveTee copies a response body into an ArchiveWriter. A body closed
+// before its end, e.g. when the walk stopped early, is not archived: reading
+// the remainder would download bytes the walk did not ask for.
+type archiveTee struct {
+	body   io.ReadCloser
+	writer ArchiveWriter
+	eof    bool
+	failed bool
+}
+
+func (t *archiveTee) Read(p []byte) (int, error) {
+	n, err := t.body.Read(p)
+	if n > 0 && !t.failed {
+		if _, werr := t.writer.Write(p[:n]); werr != nil {
+			t.failed = true
+		}
+	}
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+func (t *archiveTee) Close() error {
+	if t.eof && !t.failed {
+		_ = t.writer.Commit()
+	} else {
+		_ = t.writer.Abort()
+	}
+	return t.body.Close()
+}
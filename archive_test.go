@@ -0,0 +1,199 @@
+package gositemapfetcher
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSitemapFetcher_TarArchive(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/one</loc></url>
+  <url><loc>/two</loc></url>
+</urlset>`
+
+	large := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + strings.Repeat(`<url><loc>/page</loc></url>`, 10000) + `</urlset>`
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large.xml" {
+			_, _ = w.Write([]byte(large))
+			return
+		}
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	// A walk stopped early does not download the rest of the body to
+	// archive it.
+	var stopped bytes.Buffer
+	archive := NewTarArchive(&stopped)
+	fetcher := New(Options{IgnoreRobots: true, MaxURLs: 1, Archive: archive})
+	result, err := fetcher.WalkWithResult(context.Background(), mustParseURL(t, server.URL+"/large.xml"), func(Item) error { return nil })
+	if err == nil {
+		t.Fatalf("expected ErrMaxURLs, got nil")
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if _, err := tar.NewReader(&stopped).Next(); err != io.EOF {
+		t.Fatalf("expected no archive entry for the unfinished body, got %v", err)
+	}
+	if result.BytesDownloaded >= int64(len(large)) {
+		t.Fatalf("expected the unfinished body not to be drained, downloaded %d bytes", result.BytesDownloaded)
+	}
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	var buf bytes.Buffer
+	archive = NewTarArchive(&buf)
+	fetcher = New(Options{IgnoreRobots: true, Archive: archive})
+	if _, err := collectItems(fetcher, sitemapURL); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	reader := tar.NewReader(&buf)
+	files := map[string][]byte{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar read failed: %v", err)
+		}
+		data, _ := io.ReadAll(reader)
+		files[header.Name] = data
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 archive entries, got %d", len(files))
+	}
+
+	var meta ArchiveMeta
+	if err := json.Unmarshal(files["000001.meta.json"], &meta); err != nil {
+		t.Fatalf("invalid metadata: %v", err)
+	}
+	if !strings.HasSuffix(meta.URL, "/sitemap.xml") || meta.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(files[meta.Body]))
+	if err != nil {
+		t.Fatalf("invalid gzip body: %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	if string(body) != sitemap {
+		t.Fatalf("archived body mismatch: %q", body)
+	}
+}
+
+func TestSitemapFetcher_ReplayArchive(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/nested.xml.gz</loc></sitemap>
+</sitemapindex>`
+	const nested = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/keep</loc></url>
+  <url><loc>/skip</loc></url>
+</urlset>`
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte(nested))
+	_ = gzipWriter.Close()
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/nested.xml.gz":
+			_, _ = w.Write(gzipped.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	indexURL, err := url.Parse(server.URL + "/index.xml")
+	if err != nil {
+		t.Fatalf("failed to parse index URL: %v", err)
+	}
+
+	var buf bytes.Buffer
+	archive := NewTarArchive(&buf)
+	if _, err := collectItems(New(Options{IgnoreRobots: true, Archive: archive}), indexURL); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	server.Close()
+
+	source, err := OpenTarArchive(&buf)
+	if err != nil {
+		t.Fatalf("open archive failed: %v", err)
+	}
+	fetcher := New(Options{
+		IgnoreRobots:  true,
+		SitemapSource: source,
+		Exclude:       []*regexp.Regexp{regexp.MustCompile("skip")},
+	})
+	items, err := collectItems(fetcher, indexURL)
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if len(items) != 1 || !strings.HasSuffix(items[0].Loc.String(), "/keep") {
+		t.Fatalf("unexpected replayed items: %v", items)
+	}
+}
+
+func TestSitemapFetcher_ArchiveCachedSitemaps(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	cache := NewMemoryCache()
+	if _, err := collectItems(New(Options{IgnoreRobots: true, Cache: cache}), sitemapURL); err != nil {
+		t.Fatalf("first walk failed: %v", err)
+	}
+
+	// The second walk gets a 304 and reads the cached copy, which must still
+	// end up in its archive.
+	var buf bytes.Buffer
+	archive := NewTarArchive(&buf)
+	if _, err := collectItems(New(Options{IgnoreRobots: true, Cache: cache, Archive: archive}), sitemapURL); err != nil {
+		t.Fatalf("cached walk failed: %v", err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	server.Close()
+
+	source, err := OpenTarArchive(&buf)
+	if err != nil {
+		t.Fatalf("open archive failed: %v", err)
+	}
+	items, err := collectItems(New(Options{IgnoreRobots: true, SitemapSource: source}), sitemapURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected the cached sitemap to replay, got %v (%v)", items, err)
+	}
+}
@@ -0,0 +1,47 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// DefaultBatchSize is the batch size WalkBatches uses when size is not positive.
+const DefaultBatchSize = 1000
+
+// WalkBatches walks website and passes items to yield in batches of up to size
+// items (0 => DefaultBatchSize), e.g. for bulk database inserts or message
+// queue publishes where per-item calls dominate the cost. Every batch is a new
+// slice that yield may keep.
+//
+// The last, possibly shorter batch is also delivered when the walk stops on a
+// limit or fetch error, so no accepted item is lost; it is dropped when yield
+// itself failed or ctx was canceled. Errors from yield are wrapped in *ErrYield.
+func WalkBatches(ctx context.Context, walker SitemapWalker, website *url.URL, size int, yield func([]Item) error) error {
+	if yield == nil {
+		return &ErrNilYield{}
+	}
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	batch := make([]Item, 0, size)
+	err := walker.Walk(ctx, website, func(item Item) error {
+		batch = append(batch, item)
+		if len(batch) < size {
+			return nil
+		}
+		full := batch
+		batch = make([]Item, 0, size)
+		return yield(full)
+	})
+
+	var yieldErr *ErrYield
+	if len(batch) == 0 || errors.As(err, &yieldErr) || (ctx != nil && ctx.Err() != nil) {
+		return err
+	}
+	if flushErr := yield(batch); flushErr != nil && err == nil {
+		return &ErrYield{Err: flushErr}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWalkBatches(t *testing.T) {
+	var entries strings.Builder
+	for i := range 5 {
+		fmt.Fprintf(&entries, "<url><loc>/page-%d</loc></url>", i)
+	}
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		entries.String() + `</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+
+	batchSizes := func(opts Options, size int, yieldErr error) ([]int, error) {
+		var sizes []int
+		err := WalkBatches(context.Background(), New(opts), sitemapURL, size, func(batch []Item) error {
+			sizes = append(sizes, len(batch))
+			return yieldErr
+		})
+		return sizes, err
+	}
+
+	sizes, err := batchSizes(Options{}, 2, nil)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if fmt.Sprint(sizes) != "[2 2 1]" {
+		t.Fatalf("unexpected batches: %v", sizes)
+	}
+
+	sizes, err = batchSizes(Options{}, 0, nil)
+	if err != nil || fmt.Sprint(sizes) != "[5]" {
+		t.Fatalf("expected one default-sized batch, got %v (%v)", sizes, err)
+	}
+
+	sizes, err = batchSizes(Options{MaxURLs: 3}, 2, nil)
+	var maxErr *ErrMaxURLs
+	if !errors.As(err, &maxErr) {
+		t.Fatalf("expected ErrMaxURLs, got %v", err)
+	}
+	if fmt.Sprint(sizes) != "[2 1]" {
+		t.Fatalf("expected the partial batch to be flushed, got %v", sizes)
+	}
+
+	boom := errors.New("boom")
+	sizes, err = batchSizes(Options{}, 2, boom)
+	var yieldErr *ErrYield
+	if !errors.As(err, &yieldErr) || !errors.Is(err, boom) {
+		t.Fatalf("expected ErrYield wrapping boom, got %v", err)
+	}
+	if fmt.Sprint(sizes) != "[2]" {
+		t.Fatalf("expected no batches after a yield failure, got %v", sizes)
+	}
+
+	if err := WalkBatches(context.Background(), New(Options{}), sitemapURL, 2, nil); !errors.As(err, new(*ErrNilYield)) {
+		t.Fatalf("expected ErrNilYield, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// byteBudget counts the bytes downloaded during one walk and enforces
+// Options.MaxBytes. Probes run concurrently, so the count is atomic.
+type byteBudget struct {
+	max  int64
+	used atomic.Int64
+}
+
+type byteBudgetKey struct{}
+
+// withByteBudget returns ctx carrying a fresh budget for one walk.
+func (f *SitemapFetcher) withByteBudget(ctx context.Context) (context.Context, *byteBudget) {
+	budget := &byteBudget{max: f.opts.MaxBytes}
+	return context.WithValue(ctx, byteBudgetKey{}, budget), budget
+}
+
+func byteBudgetFrom(ctx context.Context) *byteBudget {
+	budget, _ := ctx.Value(byteBudgetKey{}).(*byteBudget)
+	return budget
+}
+
+// exhausted reports whether no byte may be downloaded any more.
+func (b *byteBudget) exhausted() bool {
+	return b.max > 0 && b.used.Load() >= b.max
+}
+
+func (b *byteBudget) err(loc *url.URL) error {
+	return &ErrMaxBytes{MaxBytes: b.max, URL: cloneURL(loc)}
+}
+
+// do sends req through the client, refusing it once the walk's byte budget
+// is spent and counting its response body against the budget.
+func (f *SitemapFetcher) do(req *http.Request) (*http.Response, error) {
+	budget := byteBudgetFrom(req.Context())
+	if budget != nil && budget.exhausted() {
+		return nil, budget.err(req.URL)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil || budget == nil {
+		return resp, err
+	}
+	resp.Body = &budgetReader{ReadCloser: resp.Body, budget: budget, loc: req.URL}
+	return resp, nil
+}
+
+// budgetReader counts bytes against a byteBudget and fails with
+// *ErrMaxBytes once a read would go past it.
+type budgetReader struct {
+	io.ReadCloser
+	budget *byteBudget
+	loc    *url.URL
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	if r.budget.max > 0 {
+		// Reading one byte past the remaining budget tells an exact fit
+		// from an overrun without downloading much more.
+		remaining := r.budget.max - r.budget.used.Load()
+		if remaining < 0 {
+			remaining = 0
+		}
+		if int64(len(p)) > remaining+1 {
+			p = p[:remaining+1]
+		}
+	}
+	n, err := r.ReadCloser.Read(p)
+	used := r.budget.used.Add(int64(n))
+	if r.budget.max > 0 && used > r.budget.max {
+		// Bytes past the budget are dropped, not counted.
+		over := min(used-r.budget.max, int64(n))
+		r.budget.used.Add(-over)
+		return n - int(over), r.budget.err(r.loc)
+	}
+	return n, err
+}
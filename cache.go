@@ -0,0 +1,139 @@
+package gositemapfetcher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// Cache stores HTTP validators (and optionally bodies) per sitemap URL so
+// repeated walks can send conditional requests.
+type Cache interface {
+	// Get returns the cached entry for key, or nil when nothing is stored.
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	// Put starts storing a fresh response for key. The returned writer
+	// receives the raw response body; a nil writer stores validators only.
+	Put(ctx context.Context, key string, entry CacheEntry) (CacheWriter, error)
+}
+
+// CacheEntry holds the validators of a previously fetched sitemap.
+type CacheEntry struct {
+	ETag         string
+	LastModified string
+	// ContentEncoding is the Content-Encoding the body was served with, so a
+	// codec recognized only by that header can decode the stored body.
+	ContentEncoding string
+	// Body opens the stored raw body. Nil means only validators are kept and
+	// an unchanged (304) sitemap is skipped instead of re-parsed.
+	Body func() (io.ReadCloser, error)
+}
+
+// CacheWriter receives a response body while it is being parsed.
+type CacheWriter interface {
+	io.Writer
+	// Commit is called once the body has been read completely.
+	Commit() error
+	// Abort discards a partially written body.
+	Abort() error
+}
+
+// MemoryCache is a concurrency-safe in-memory Cache.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	etag            string
+	lastModified    string
+	contentEncoding string
+	body            []byte
+}
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]memoryCacheEntry{}}
+}
+
+// Get implements Cache.
+func (c *MemoryCache) Get(_ context.Context, key string) (*CacheEntry, error) {
+	c.mu.Lock()
+	stored, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return &CacheEntry{
+		ETag:            stored.etag,
+		LastModified:    stored.lastModified,
+		ContentEncoding: stored.contentEncoding,
+		Body: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(stored.body)), nil
+		},
+	}, nil
+}
+
+// Put implements Cache.
+func (c *MemoryCache) Put(_ context.Context, key string, entry CacheEntry) (CacheWriter, error) {
+	return &memoryCacheWriter{cache: c, key: key, entry: entry}, nil
+}
+
+type memoryCacheWriter struct {
+	cache *MemoryCache
+	key   string
+	entry CacheEntry
+	buf   bytes.Buffer
+}
+
+func (w *memoryCacheWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memoryCacheWriter) Commit() error {
+	w.cache.mu.Lock()
+	defer w.cache.mu.Unlock()
+	w.cache.entries[w.key] = memoryCacheEntry{
+		etag:            w.entry.ETag,
+		lastModified:    w.entry.LastModified,
+		contentEncoding: w.entry.ContentEncoding,
+		body:            w.buf.Bytes(),
+	}
+	return nil
+}
+
+func (w *memoryCacheWriter) Abort() error {
+	w.buf.Reset()
+	return nil
+}
+
+// cacheTee copies a response body into a CacheWriter and commits it only when
+// the body was consumed to EOF.
+type cacheTee struct {
+	body   io.ReadCloser
+	writer CacheWriter
+	eof    bool
+	failed bool
+}
+
+func (t *cacheTee) Read(p []byte) (int, error) {
+	n, err := t.body.Read(p)
+	if n > 0 && !t.failed {
+		if _, werr := t.writer.Write(p[:n]); werr != nil {
+			t.failed = true
+		}
+	}
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+func (t *cacheTee) Close() error {
+	if t.eof && !t.failed {
+		_ = t.writer.Commit()
+	} else {
+		_ = t.writer.Abort()
+	}
+	return t.body.Close()
+}
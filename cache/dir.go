@@ -0,0 +1,200 @@
+// Package cache provides Cache implementations for gositemapfetcher.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+// DirCache is a filesystem-backed gositemapfetcher.Cache. Each sitemap URL is
+// stored as a raw body file plus a small JSON file with its validators. Body
+// files are named after their content and the JSON file after the URL, so
+// replacing the JSON file swaps validators and body together.
+type DirCache struct {
+	dir string
+}
+
+type dirCacheMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// ContentEncoding is omitted for entries written before it was stored.
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	// Body is the name of the body file in the cache directory. Entries
+	// written before it was stored use the URL-named .body file.
+	Body string `json:"body,omitempty"`
+}
+
+// NewDirCache returns a DirCache rooted at dir, creating it when missing.
+func NewDirCache(dir string) (*DirCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirCache{dir: dir}, nil
+}
+
+// Get implements gositemapfetcher.Cache.
+func (c *DirCache) Get(_ context.Context, key string) (*gositemapfetcher.CacheEntry, error) {
+	meta, err := c.readMeta(key)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	bodyPath := c.bodyPath(key, meta)
+	if bodyPath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(bodyPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &gositemapfetcher.CacheEntry{
+		ETag:            meta.ETag,
+		LastModified:    meta.LastModified,
+		ContentEncoding: meta.ContentEncoding,
+		Body: func() (io.ReadCloser, error) {
+			return os.Open(bodyPath)
+		},
+	}, nil
+}
+
+// Put implements gositemapfetcher.Cache.
+func (c *DirCache) Put(_ context.Context, key string, entry gositemapfetcher.CacheEntry) (gositemapfetcher.CacheWriter, error) {
+	tmp, err := os.CreateTemp(c.dir, "body-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &dirCacheWriter{
+		cache: c,
+		file:  tmp,
+		hash:  sha256.New(),
+		meta: dirCacheMeta{
+			URL:             key,
+			ETag:            entry.ETag,
+			LastModified:    entry.LastModified,
+			ContentEncoding: entry.ContentEncoding,
+		},
+	}, nil
+}
+
+// readMeta returns the stored metadata of key, or nil when there is none.
+func (c *DirCache) readMeta(key string) (*dirCacheMeta, error) {
+	data, err := os.ReadFile(c.metaPath(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var meta dirCacheMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	if meta.URL != key {
+		return nil, nil
+	}
+	return &meta, nil
+}
+
+// bodyPath returns the body file of meta, or "" when its name would leave
+// the cache directory.
+func (c *DirCache) bodyPath(key string, meta *dirCacheMeta) string {
+	if meta.Body == "" {
+		return filepath.Join(c.dir, c.name(key)+".body")
+	}
+	if filepath.Base(meta.Body) != meta.Body || meta.Body == "." || meta.Body == ".." {
+		return ""
+	}
+	return filepath.Join(c.dir, meta.Body)
+}
+
+func (c *DirCache) metaPath(key string) string {
+	return filepath.Join(c.dir, c.name(key)+".json")
+}
+
+func (c *DirCache) name(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type dirCacheWriter struct {
+	cache *DirCache
+	file  *os.File
+	hash  hash.Hash
+	meta  dirCacheMeta
+}
+
+func (w *dirCacheWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	return n, err
+}
+
+// Commit moves the body to a file named after the URL and the body's hash,
+// then replaces the JSON file pointing at it. A reader sees either the old
+// validators with the old body or the new ones with the new body, never a
+// mix. The previous body is removed afterwards, so a Get racing the commit
+// may fail to open it; the next fetch then starts over.
+func (w *dirCacheWriter) Commit() error {
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	key := w.meta.URL
+	w.meta.Body = w.cache.name(key) + "-" + hex.EncodeToString(w.hash.Sum(nil)) + ".body"
+	bodyPath := filepath.Join(w.cache.dir, w.meta.Body)
+	if err := os.Rename(w.file.Name(), bodyPath); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	previous, _ := w.cache.readMeta(key)
+	data, err := json.Marshal(w.meta)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(w.cache.metaPath(key), data); err != nil {
+		if previous == nil || previous.Body != w.meta.Body {
+			os.Remove(bodyPath)
+		}
+		return err
+	}
+	if previous != nil {
+		if old := w.cache.bodyPath(key, previous); old != "" && old != bodyPath {
+			os.Remove(old)
+		}
+	}
+	return nil
+}
+
+func (w *dirCacheWriter) Abort() error {
+	w.file.Close()
+	return os.Remove(w.file.Name())
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "meta-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
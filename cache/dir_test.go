@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func TestDirCache_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir, err := NewDirCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	const key = "https://example.com/sitemap.xml"
+	entry, err := dir.Get(ctx, key)
+	if err != nil || entry != nil {
+		t.Fatalf("expected miss, got %v, %v", entry, err)
+	}
+
+	aborted, err := dir.Put(ctx, key, gositemapfetcher.CacheEntry{ETag: `"v0"`})
+	if err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	_, _ = aborted.Write([]byte("partial"))
+	if err := aborted.Abort(); err != nil {
+		t.Fatalf("abort failed: %v", err)
+	}
+	if entry, _ := dir.Get(ctx, key); entry != nil {
+		t.Fatalf("expected aborted write to be discarded")
+	}
+
+	writer, err := dir.Put(ctx, key, gositemapfetcher.CacheEntry{ETag: `"v1"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"})
+	if err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	_, _ = writer.Write([]byte("<urlset/>"))
+	if err := writer.Commit(); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	entry, err = dir.Get(ctx, key)
+	if err != nil || entry == nil {
+		t.Fatalf("expected hit, got %v, %v", entry, err)
+	}
+	if entry.ETag != `"v1"` || entry.LastModified == "" {
+		t.Fatalf("unexpected validators: %+v", entry)
+	}
+	body, err := entry.Body()
+	if err != nil {
+		t.Fatalf("open body failed: %v", err)
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	if string(data) != "<urlset/>" {
+		t.Fatalf("unexpected body %q", data)
+	}
+}
+
+func TestDirCache_Replace(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	dir, err := NewDirCache(root)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	const key = "https://example.com/sitemap.xml"
+
+	// An entry written before body files were named after their content.
+	name := dir.name(key)
+	if err := os.WriteFile(filepath.Join(root, name+".json"), []byte(`{"url":"`+key+`","etag":"\"v0\""}`), 0o644); err != nil {
+		t.Fatalf("write legacy meta: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, name+".body"), []byte("<urlset>v0</urlset>"), 0o644); err != nil {
+		t.Fatalf("write legacy body: %v", err)
+	}
+
+	for _, tc := range []struct {
+		etag string
+		body string
+	}{
+		{`"v0"`, "<urlset>v0</urlset>"},
+		{`"v1"`, "<urlset>v1</urlset>"},
+		{`"v2"`, "<urlset>v2</urlset>"},
+		{`"v2b"`, "<urlset>v2</urlset>"},
+	} {
+		if tc.etag != `"v0"` {
+			writer, err := dir.Put(ctx, key, gositemapfetcher.CacheEntry{ETag: tc.etag})
+			if err != nil {
+				t.Fatalf("put failed: %v", err)
+			}
+			_, _ = writer.Write([]byte(tc.body))
+			if err := writer.Commit(); err != nil {
+				t.Fatalf("commit failed: %v", err)
+			}
+		}
+
+		entry, err := dir.Get(ctx, key)
+		if err != nil || entry == nil {
+			t.Fatalf("%s: expected hit, got %v, %v", tc.etag, entry, err)
+		}
+		body, err := entry.Body()
+		if err != nil {
+			t.Fatalf("%s: open body failed: %v", tc.etag, err)
+		}
+		data, _ := io.ReadAll(body)
+		body.Close()
+		if entry.ETag != tc.etag || string(data) != tc.body {
+			t.Fatalf("expected %s with %q, got %s with %q", tc.etag, tc.body, entry.ETag, data)
+		}
+
+		bodies, err := filepath.Glob(filepath.Join(root, "*.body"))
+		if err != nil || len(bodies) != 1 {
+			t.Fatalf("%s: expected one body file, got %v (%v)", tc.etag, bodies, err)
+		}
+	}
+}
+
+func TestDirCache_RejectsBodyOutsideDir(t *testing.T) {
+	root := t.TempDir()
+	dir, err := NewDirCache(root)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	const key = "https://example.com/sitemap.xml"
+	meta := `{"url":"` + key + `","body":"../outside.body"}`
+	if err := os.WriteFile(filepath.Join(root, dir.name(key)+".json"), []byte(meta), 0o644); err != nil {
+		t.Fatalf("write meta: %v", err)
+	}
+	if entry, err := dir.Get(context.Background(), key); err != nil || entry != nil {
+		t.Fatalf("expected miss, got %v, %v", entry, err)
+	}
+}
@@ -0,0 +1,52 @@
+package gositemapfetcher
+
+import (
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSitemapFetcher_ConditionalCache(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>/cached</loc>
+  </url>
+</urlset>`
+
+	var notModified int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	fetcher := New(Options{Cache: NewMemoryCache()})
+	for run := 0; run < 2; run++ {
+		items, err := collectItems(fetcher, sitemapURL)
+		if err != nil {
+			t.Fatalf("walk %d failed: %v", run, err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("walk %d: expected 1 item, got %d", run, len(items))
+		}
+	}
+	if got := atomic.LoadInt32(&notModified); got != 1 {
+		t.Fatalf("expected 1 not-modified response, got %d", got)
+	}
+}
@@ -0,0 +1,19 @@
+package gositemapfetcher
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// charsetReader decodes sitemaps declaring a non-UTF-8 encoding, e.g.
+// <?xml version="1.0" encoding="windows-1251"?>, to UTF-8. Labels are
+// resolved like browsers do, so ISO-8859-1 is read as windows-1252.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported XML encoding %q", label)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/cobra"
+)
+
+func newCompareCommand(opts *fetchOptions) *cobra.Command {
+	var against string
+
+	cmd := &cobra.Command{
+		Use:          "compare [flags] <site or sitemap URL>",
+		Short:        "Walk a site and compare its URLs against a reference list",
+		Long:         "Walk a site and report URLs missing from (\"missing\") or absent in (\"extra\") a reference list read from --against (one URL per line, - for stdin). Without --against only the walked URL count is reported.",
+		SilenceUsage: true,
+		Args:         inputArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parsed, err := parseTargetURL(args[0])
+			if err != nil {
+				return invalidInput(err)
+			}
+			fetcher, cleanup, err := opts.newFetcher()
+			if err != nil {
+				return invalidInput(err)
+			}
+
+			ours := make(map[string]struct{})
+			err = fetcher.Walk(context.Background(), parsed, opts.track(func(item gositemapfetcher.Item) error {
+				if loc := normalizeURLString(item.Loc.String()); loc != "" {
+					ours[loc] = struct{}{}
+				}
+				return nil
+			}))
+			if cleanupErr := cleanup(); err == nil {
+				err = cleanupErr
+			}
+			if err != nil {
+				return err
+			}
+			if against == "" {
+				fmt.Fprintf(os.Stderr, "walked=%d\n", len(ours))
+				return nil
+			}
+
+			reference, err := readURLList(against)
+			if err != nil {
+				return invalidInput(err)
+			}
+			missing := diffSet(reference, ours)
+			extra := diffSet(ours, reference)
+
+			out := bufio.NewWriter(os.Stdout)
+			for _, loc := range missing {
+				fmt.Fprintf(out, "missing\t%s\n", loc)
+			}
+			for _, loc := range extra {
+				fmt.Fprintf(out, "extra\t%s\n", loc)
+			}
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "walked=%d reference=%d missing=%d extra=%d\n", len(ours), len(reference), len(missing), len(extra))
+			if len(missing) > 0 || len(extra) > 0 {
+				return fmt.Errorf("comparison mismatch: missing=%d extra=%d", len(missing), len(extra))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&against, "against", "", "File with reference URLs, one per line (- for stdin)")
+	return cmd
+}
+
+func readURLList(path string) (map[string]struct{}, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+	out := make(map[string]struct{})
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if loc := normalizeURLString(scanner.Text()); loc != "" {
+			out[loc] = struct{}{}
+		}
+	}
+	return out, scanner.Err()
+}
+
+func normalizeURLString(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return ""
+	}
+	parsed, err := url.Parse(trimmed)
+	if err != nil {
+		return trimmed
+	}
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+// diffSet returns the sorted keys of left that are absent from right.
+func diffSet(left, right map[string]struct{}) []string {
+	out := make([]string, 0)
+	for key := range left {
+		if _, ok := right[key]; !ok {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
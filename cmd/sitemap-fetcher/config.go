@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix starts the environment variable of every flag, e.g.
+// GO_SITEMAP_FETCHER_MAX_URLS for --max-urls.
+const envPrefix = "GO_SITEMAP_FETCHER_"
+
+// envName returns the environment variable read for a flag.
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// applyConfig fills the flags of cmd that were not given on the command line
+// from GO_SITEMAP_FETCHER_* environment variables and then from the --config
+// file, so the precedence is flags > env > file. The file is YAML or JSON
+// with flag names as keys, e.g. "max-urls: 1000"; list flags take a list.
+func applyConfig(cmd *cobra.Command, path string) error {
+	if path == "" {
+		path = os.Getenv(envName("config"))
+	}
+	file, err := readConfigFile(cmd.Root(), path)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || flag.Name == "help" || flag.Name == "config" {
+			return
+		}
+		if value, ok := os.LookupEnv(envName(flag.Name)); ok {
+			if err := setFlag(flag, []string{value}, true); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", envName(flag.Name), err))
+			}
+			return
+		}
+		if values, ok := file[flag.Name]; ok {
+			if err := setFlag(flag, values, false); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %q in config file %q: %w", flag.Name, path, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// setFlag sets flag as if it were given on the command line. An environment
+// value for a list flag is split on commas like the flag itself; file
+// values are taken one list element at a time.
+func setFlag(flag *pflag.Flag, values []string, fromEnv bool) error {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok && !fromEnv {
+		if err := slice.Replace(values); err != nil {
+			return err
+		}
+		flag.Changed = true
+		return nil
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("expected a single value, got %d", len(values))
+	}
+	if err := flag.Value.Set(values[0]); err != nil {
+		return err
+	}
+	flag.Changed = true
+	return nil
+}
+
+// readConfigFile loads path as a map from flag name to values. Keys must
+// name a flag of some command, so a typo is reported instead of ignored,
+// while one file can still serve several subcommands.
+func readConfigFile(root *cobra.Command, path string) (map[string][]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %q: %w", path, err)
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid config file %q: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	var collect func(*cobra.Command)
+	collect = func(c *cobra.Command) {
+		c.Flags().VisitAll(func(flag *pflag.Flag) { known[flag.Name] = true })
+		c.PersistentFlags().VisitAll(func(flag *pflag.Flag) { known[flag.Name] = true })
+		for _, child := range c.Commands() {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	config := make(map[string][]string, len(raw))
+	for _, key := range keys {
+		if !known[key] || key == "config" {
+			return nil, fmt.Errorf("invalid config file %q: unknown flag %q", path, key)
+		}
+		values, err := configValues(raw[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %q: %q: %w", path, key, err)
+		}
+		config[key] = values
+	}
+	return config, nil
+}
+
+func configValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, element := range v {
+			scalar, err := configScalar(element)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, scalar)
+		}
+		return values, nil
+	default:
+		scalar, err := configScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		return []string{scalar}, nil
+	}
+}
+
+func configScalar(value any) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+	case map[string]any, []any:
+		return "", errors.New("expected a value or a list of values")
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigCommand returns a subcommand of a fresh root with a few flags of
+// each kind, parsed from args.
+func newConfigCommand(t *testing.T, args ...string) (*cobra.Command, *int, *string, *[]string) {
+	t.Helper()
+	root := &cobra.Command{Use: "root"}
+	cmd := &cobra.Command{Use: "walk", RunE: func(*cobra.Command, []string) error { return nil }}
+	maxURLs := cmd.Flags().Int("max-urls", 0, "")
+	format := cmd.Flags().String("format", "text", "")
+	include := cmd.Flags().StringSlice("include", nil, "")
+	cmd.Flags().String("config", "", "")
+	root.AddCommand(cmd)
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	return cmd, maxURLs, format, include
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestApplyConfig_Precedence(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "max-urls: 10\nformat: csv\ninclude:\n  - /blog/\n  - /news/\n")
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		urls    int
+		format  string
+		include []string
+	}{
+		{"file", nil, nil, 10, "csv", []string{"/blog/", "/news/"}},
+		{"env over file", nil, map[string]string{"GO_SITEMAP_FETCHER_MAX_URLS": "20", "GO_SITEMAP_FETCHER_INCLUDE": "/a/,/b/"}, 20, "csv", []string{"/a/", "/b/"}},
+		{"flags over env", []string{"--max-urls", "30", "--format", "ndjson"}, map[string]string{"GO_SITEMAP_FETCHER_MAX_URLS": "20"}, 30, "ndjson", []string{"/blog/", "/news/"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			cmd, maxURLs, format, include := newConfigCommand(t, tc.args...)
+			if err := applyConfig(cmd, path); err != nil {
+				t.Fatalf("applyConfig failed: %v", err)
+			}
+			if *maxURLs != tc.urls || *format != tc.format || !reflect.DeepEqual(*include, tc.include) {
+				t.Fatalf("expected %d %q %v, got %d %q %v", tc.urls, tc.format, tc.include, *maxURLs, *format, *include)
+			}
+		})
+	}
+}
+
+func TestApplyConfig_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{"unknown key", "max-url: 10\n", nil, `unknown flag "max-url"`},
+		{"nested value", "format:\n  name: csv\n", nil, "expected a value or a list of values"},
+		{"bad value", "max-urls: many\n", nil, `invalid "max-urls" in config file`},
+		{"bad env", "", map[string]string{"GO_SITEMAP_FETCHER_MAX_URLS": "many"}, "invalid GO_SITEMAP_FETCHER_MAX_URLS"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			cmd, _, _, _ := newConfigCommand(t)
+			err := applyConfig(cmd, writeConfig(t, "config.yaml", tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestApplyConfig_JSONFromEnv(t *testing.T) {
+	t.Setenv("GO_SITEMAP_FETCHER_CONFIG", writeConfig(t, "config.json", `{"max-urls": 5, "include": ["/x/"]}`))
+	cmd, maxURLs, _, include := newConfigCommand(t)
+	if err := applyConfig(cmd, ""); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if *maxURLs != 5 || !reflect.DeepEqual(*include, []string{"/x/"}) {
+		t.Fatalf("expected the JSON file named by the environment, got %d %v", *maxURLs, *include)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/cobra"
+)
+
+func newDiffCommand(opts *fetchOptions) *cobra.Command {
+	var (
+		currentPath string
+		savePath    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff [flags] <previous snapshot> [<site, sitemap URL, or file>]",
+		Short: "Compare a saved snapshot with the current run",
+		Long: "Walk a site (or load --current) and compare its URLs with a snapshot from a previous run, printing \"added\", \"removed\", and \"changed\" (different lastmod) URLs. " +
+			"Snapshots are --format json or ndjson output; --save writes the current run as the next snapshot.",
+		SilenceUsage: true,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if currentPath != "" && len(args) == 1 {
+				return nil
+			}
+			if currentPath == "" && len(args) == 2 {
+				return nil
+			}
+			return invalidInput(errors.New("expected a previous snapshot and either a URL or --current"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			previous, err := readSnapshot(args[0])
+			if err != nil {
+				return invalidInput(err)
+			}
+
+			var current *gositemapfetcher.ResultSet
+			if currentPath != "" {
+				current, err = readSnapshot(currentPath)
+				if err != nil {
+					return invalidInput(err)
+				}
+			} else {
+				parsed, err := parseTargetURL(args[1])
+				if err != nil {
+					return invalidInput(err)
+				}
+				fetcher, cleanup, err := opts.newFetcher()
+				if err != nil {
+					return invalidInput(err)
+				}
+				current = gositemapfetcher.NewResultSet(nil)
+				err = fetcher.Walk(context.Background(), parsed, opts.track(func(item gositemapfetcher.Item) error {
+					current.Add(item)
+					return nil
+				}))
+				if cleanupErr := cleanup(); err == nil {
+					err = cleanupErr
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if savePath != "" {
+				if err := current.WriteFile(savePath); err != nil {
+					return err
+				}
+			}
+
+			diff := gositemapfetcher.DiffResultSets(previous, current)
+			out := bufio.NewWriter(os.Stdout)
+			for _, item := range diff.Added {
+				fmt.Fprintf(out, "added\t%s\n", item.Loc)
+			}
+			for _, item := range diff.Removed {
+				fmt.Fprintf(out, "removed\t%s\n", item.Loc)
+			}
+			for _, change := range diff.Changed {
+				fmt.Fprintf(out, "changed\t%s\t%s\t%s\n", change.Current.Loc, formatLastMod(change.Previous), formatLastMod(change.Current))
+			}
+			if err := out.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "previous=%d current=%d added=%d removed=%d changed=%d\n",
+				previous.Len(), current.Len(), len(diff.Added), len(diff.Removed), len(diff.Changed))
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&currentPath, "current", "", "Compare against this snapshot instead of walking a URL (- for stdin)")
+	flags.StringVar(&savePath, "save", "", "Write the current run as a JSON snapshot to this file")
+	return cmd
+}
+
+func formatLastMod(item gositemapfetcher.Item) string {
+	if item.LastMod == nil {
+		return "-"
+	}
+	return item.LastMod.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/cobra"
+)
+
+func newDiscoverCommand(opts *fetchOptions) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "discover [flags] <site, sitemap URL, or file>",
+		Short: "List the sitemap files of a site without printing page URLs",
+		Long: "Discover sitemaps via robots.txt, probing, and index traversal, and print one line per sitemap file with its type, lastmod from the parent index, and entry count. " +
+			"Only sitemap indexes are downloaded in full; other sitemaps are read just far enough to tell their type, and their entry count is printed as \"-\" unless --count-urls downloads them to count their URLs.",
+		SilenceUsage: true,
+		Args:         inputArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parsed, err := parseTargetURL(args[0])
+			if err != nil {
+				return invalidInput(err)
+			}
+			writeSitemaps, err := sitemapListWriter(format)
+			if err != nil {
+				return invalidInput(err)
+			}
+			fetcher, cleanup, err := opts.newFetcher()
+			if err != nil {
+				return invalidInput(err)
+			}
+
+			sitemaps, err := fetcher.ListSitemaps(context.Background(), parsed)
+			if cleanupErr := cleanup(); err == nil {
+				err = cleanupErr
+			}
+			out := bufio.NewWriter(os.Stdout)
+			if writeErr := writeSitemaps(out, sitemaps); err == nil {
+				err = writeErr
+			}
+			if flushErr := out.Flush(); err == nil {
+				err = flushErr
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "text", "Output format (text, ndjson, json)")
+	cmd.Flags().BoolVar(&opts.countURLs, "count-urls", false, "Download every sitemap to count its URLs, not only the indexes")
+	return cmd
+}
+
+// jsonSitemap is the JSON form of a SitemapInfo.
+type jsonSitemap struct {
+	URL      string `json:"url"`
+	Type     string `json:"type,omitempty"`
+	Depth    int    `json:"depth"`
+	LastMod  string `json:"lastmod,omitempty"`
+	Via      string `json:"via,omitempty"`
+	Ref      string `json:"ref,omitempty"`
+	URLs     int    `json:"urls"`
+	Sitemaps int    `json:"sitemaps"`
+	Bytes    int64  `json:"bytes"`
+	Counted  bool   `json:"counted"`
+}
+
+func toJSONSitemap(info gositemapfetcher.SitemapInfo) jsonSitemap {
+	out := jsonSitemap{
+		URL:      info.URL.String(),
+		Type:     string(info.Type),
+		Depth:    info.Depth,
+		Via:      info.Via,
+		URLs:     info.URLs,
+		Sitemaps: info.Sitemaps,
+		Bytes:    info.Bytes,
+		Counted:  info.Counted,
+	}
+	if info.LastMod != nil {
+		out.LastMod = info.LastMod.Format(time.RFC3339Nano)
+	}
+	if info.Ref != nil {
+		out.Ref = info.Ref.String()
+	}
+	return out
+}
+
+// sitemapListWriter returns the function that renders discovered sitemaps
+// in format. Text prints url, type, lastmod, and entry count separated by
+// tabs, with "-" for a missing type, lastmod, or count.
+func sitemapListWriter(format string) (func(io.Writer, []gositemapfetcher.SitemapInfo) error, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "text":
+		return func(w io.Writer, sitemaps []gositemapfetcher.SitemapInfo) error {
+			for _, info := range sitemaps {
+				sitemapType, lastMod := string(info.Type), "-"
+				if sitemapType == "" {
+					sitemapType = "-"
+				}
+				if info.LastMod != nil {
+					lastMod = info.LastMod.Format(time.RFC3339Nano)
+				}
+				entries := "-"
+				if info.Counted {
+					entries = strconv.Itoa(info.URLs + info.Sitemaps)
+				}
+				if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.URL, sitemapType, lastMod, entries); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	case "ndjson":
+		return func(w io.Writer, sitemaps []gositemapfetcher.SitemapInfo) error {
+			enc := json.NewEncoder(w)
+			for _, info := range sitemaps {
+				if err := enc.Encode(toJSONSitemap(info)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, nil
+	case "json":
+		return func(w io.Writer, sitemaps []gositemapfetcher.SitemapInfo) error {
+			out := make([]jsonSitemap, 0, len(sitemaps))
+			for _, info := range sitemaps {
+				out = append(out, toJSONSitemap(info))
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q (use text, ndjson, json)", format)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes by failure class, so shell pipelines and schedulers can branch
+// on the kind of failure. When an error matches several classes, e.g.
+// several targets or --continue-on-error failures, the lowest code wins.
+const (
+	exitOK           = 0
+	exitFailure      = 1 // anything not listed below, e.g. a compare mismatch
+	exitInvalidInput = 2 // invalid flags, arguments, or URLs
+	exitNoSitemaps   = 3 // no sitemaps discovered for the site
+	exitHTTP         = 4 // network, HTTP status, or robots.txt failure
+	exitParse        = 5 // malformed, oversized, or non-sitemap document
+	exitLimit        = 6 // --max-depth, --max-sitemaps, --max-urls, --max-duration, or --max-bytes reached
+)
+
+// inputError marks an error caused by the command line rather than the walk.
+type inputError struct {
+	err error
+}
+
+func (e *inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *inputError) Unwrap() error {
+	return e.err
+}
+
+// invalidInput wraps a non-nil err as an inputError.
+func invalidInput(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &inputError{err: err}
+}
+
+// inputArgs marks the errors of an argument validator as invalid input.
+func inputArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		return invalidInput(validate(cmd, args))
+	}
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var (
+		input           *inputError
+		invalidURL      *gositemapfetcher.ErrInvalidURL
+		noSitemaps      *gositemapfetcher.ErrNoSitemaps
+		fetch           *gositemapfetcher.ErrFetch
+		status          *gositemapfetcher.ErrHTTPStatus
+		encoding        *gositemapfetcher.ErrContentEncoding
+		robots          *gositemapfetcher.ErrRobotsUnavailable
+		parse           *gositemapfetcher.ErrSitemapParse
+		notSitemap      *gositemapfetcher.ErrNotSitemap
+		tooLarge        *gositemapfetcher.ErrSitemapTooLarge
+		elementTooLarge *gositemapfetcher.ErrElementTooLarge
+		xmlLimit        *gositemapfetcher.ErrXMLLimit
+		spec            *gositemapfetcher.ErrSpecViolations
+		maxDepth        *gositemapfetcher.ErrMaxDepth
+		maxSitemaps     *gositemapfetcher.ErrMaxSitemaps
+		maxURLs         *gositemapfetcher.ErrMaxURLs
+		deadline        *gositemapfetcher.ErrDeadline
+		maxBytes        *gositemapfetcher.ErrMaxBytes
+	)
+	switch {
+	case errors.As(err, &input), errors.As(err, &invalidURL):
+		return exitInvalidInput
+	case errors.As(err, &noSitemaps):
+		return exitNoSitemaps
+	case errors.As(err, &fetch), errors.As(err, &status), errors.As(err, &encoding), errors.As(err, &robots):
+		return exitHTTP
+	case errors.As(err, &parse), errors.As(err, &notSitemap), errors.As(err, &tooLarge),
+		errors.As(err, &elementTooLarge), errors.As(err, &xmlLimit), errors.As(err, &spec):
+		return exitParse
+	case errors.As(err, &maxDepth), errors.As(err, &maxSitemaps), errors.As(err, &maxURLs), errors.As(err, &deadline),
+		errors.As(err, &maxBytes):
+		return exitLimit
+	default:
+		return exitFailure
+	}
+}
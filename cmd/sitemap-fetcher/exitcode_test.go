@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func TestExitCode(t *testing.T) {
+	status := &gositemapfetcher.ErrHTTPStatus{StatusCode: 503}
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"other", errors.New("boom"), exitFailure},
+		{"input", invalidInput(errors.New("bad flag")), exitInvalidInput},
+		{"invalid URL", &gositemapfetcher.ErrInvalidURL{}, exitInvalidInput},
+		{"no sitemaps", &gositemapfetcher.ErrNoSitemaps{}, exitNoSitemaps},
+		{"status", status, exitHTTP},
+		{"wrapped parse", fmt.Errorf("site: %w", &gositemapfetcher.ErrSitemapParse{Err: errors.New("eof")}), exitParse},
+		{"spec", &gositemapfetcher.ErrSpecViolations{Total: 1}, exitParse},
+		{"max urls", &gositemapfetcher.ErrMaxURLs{MaxURLs: 10}, exitLimit},
+		{"deadline", &gositemapfetcher.ErrDeadline{}, exitLimit},
+		{"lowest wins", errors.Join(&gositemapfetcher.ErrMaxURLs{}, status, invalidInput(errors.New("bad target"))), exitInvalidInput},
+		{"partial", &gositemapfetcher.ErrPartial{Failures: []gositemapfetcher.SitemapFailure{{Err: status}}}, exitHTTP},
+	} {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Fatalf("%s: expected exit code %d, got %d", tc.name, tc.want, got)
+		}
+	}
+}
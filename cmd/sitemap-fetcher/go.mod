@@ -0,0 +1,18 @@
+module github.com/enot-style/go-sitemap-fetcher/cmd/sitemap-fetcher
+
+go 1.25.5
+
+require (
+	github.com/enot-style/go-sitemap-fetcher v0.0.0
+	github.com/spf13/cobra v1.10.2
+	github.com/spf13/pflag v1.0.9
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/temoto/robotstxt v1.1.2 // indirect
+	golang.org/x/text v0.40.0 // indirect
+)
+
+replace github.com/enot-style/go-sitemap-fetcher => ../..
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -16,81 +19,198 @@ import (
 
 func main() {
 	var (
-		maxDepth          int
-		maxSitemaps       int
-		maxURLs           int
-		allowNon200       bool
-		ignoreRobots      bool
-		userAgent         string
-		perRequestTimeout time.Duration
-		logLevel          string
+		opts         fetchOptions
+		format       string
+		columns      []string
+		manifestPath string
+		withMetadata bool
+		inputFile    string
+		concurrency  int
+		summary      bool
+		summaryPath  string
+		configPath   string
+		count        bool
+		countPer     bool
+		outputPath   string
 	)
 
 	cmd := &cobra.Command{
-		Use:          "go-sitemap-fetcher [flags] <site or sitemap URL>",
+		Use:          "go-sitemap-fetcher [flags] <site, sitemap URL, or file>... | -",
 		Short:        "Fetch sitemaps and print URLs line by line",
+		Long:         "Fetch sitemaps and print URLs line by line. Several targets may be given, or read one per line from stdin (-) or --input-file; each output line is then tagged with the target it came from.",
 		SilenceUsage: true,
-		Args: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 1 {
-				return nil
-			}
-			return errors.New("missing URL argument")
-		},
-		PreRunE: func(cmd *cobra.Command, args []string) error {
+		Args:         cobra.ArbitraryArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			for _, arg := range os.Args[1:] {
 				if arg == "--" {
-					return nil
+					break
 				}
-				if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && arg != "-h" {
-					return fmt.Errorf("invalid flag %q (use --)", arg)
+				if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && arg != "-h" && arg != "-" {
+					return invalidInput(fmt.Errorf("invalid flag %q (use --)", arg))
 				}
 			}
-			return nil
+			return invalidInput(applyConfig(cmd, configPath))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			parsed, err := url.Parse(args[0])
+			targets, err := readTargets(args, inputFile, os.Stdin)
 			if err != nil {
-				return fmt.Errorf("invalid URL %q: %w", args[0], err)
+				return invalidInput(err)
+			}
+			tagSites := len(targets) > 1
+			if !tagSites {
+				if _, err := parseTargetURL(targets[0]); err != nil {
+					return invalidInput(err)
+				}
 			}
 
-			level, err := resolveLogLevel(logLevel)
+			started := time.Now()
+			var manifest *runManifest
+			if manifestPath != "" {
+				manifest = newRunManifest(siteTargets(targets), cmd.Flags(), started)
+			}
+
+			stdout := newHashingWriter(os.Stdout)
+			var sink io.Writer = stdout
+			var outFile *atomicFile
+			if outputPath != "" {
+				outFile, err = createAtomicFile(outputPath)
+				if err != nil {
+					return invalidInput(fmt.Errorf("invalid output file %q: %w", outputPath, err))
+				}
+				defer outFile.Abort()
+				sink = outFile
+			}
+			out := bufio.NewWriter(sink)
+			writer, err := newItemWriter(format, columns, withMetadata, tagSites, out)
 			if err != nil {
-				return err
+				return invalidInput(err)
+			}
+			if count || countPer {
+				writer = discardWriter{}
 			}
-			logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 
-			fetcher := gositemapfetcher.New(gositemapfetcher.Options{
-				MaxDepth:          maxDepth,
-				MaxSitemaps:       maxSitemaps,
-				MaxURLs:           maxURLs,
-				AllowNon200:       allowNon200,
-				IgnoreRobots:      ignoreRobots,
-				UserAgent:         userAgent,
-				PerRequestTimeout: perRequestTimeout,
-				Logger:            logger,
-			})
+			opts.captureExtensions = withMetadata
+			fetcher, cleanup, err := opts.newFetcher()
+			if err != nil {
+				return invalidInput(err)
+			}
 
-			return fetcher.Walk(context.Background(), parsed, func(item gositemapfetcher.Item) error {
-				_, err := fmt.Fprintln(os.Stdout, item.Loc.String())
-				return err
+			if opts.deterministic {
+				// Concurrent targets would interleave their output.
+				concurrency = 1
+			}
+			results, walkErr := walkTargets(context.Background(), fetcher, targets, concurrency, func(target string) func(gositemapfetcher.Item) error {
+				if !tagSites {
+					target = ""
+				}
+				return opts.track(func(item gositemapfetcher.Item) error {
+					return writer.Write(target, item)
+				})
 			})
+			merged := mergeResults(results)
+			if err := writer.Flush(); err != nil && walkErr == nil {
+				walkErr = err
+			}
+			if count || countPer {
+				if err := writeCounts(out, merged, countPer); err != nil && walkErr == nil {
+					walkErr = err
+				}
+			}
+			if err := out.Flush(); err != nil && walkErr == nil {
+				walkErr = err
+			}
+			if err := cleanup(); err != nil && walkErr == nil {
+				walkErr = err
+			}
+			if outFile != nil && walkErr == nil {
+				if err := outFile.Commit(); err != nil {
+					walkErr = fmt.Errorf("write output: %w", err)
+				}
+			}
+			if summary || summaryPath != "" {
+				report := newRunSummary(merged, walkErr, time.Since(started))
+				if summary {
+					report.print(os.Stderr)
+				}
+				if summaryPath != "" {
+					if err := report.writeFile(summaryPath); err != nil && walkErr == nil {
+						walkErr = fmt.Errorf("write summary: %w", err)
+					}
+				}
+			}
+			if manifest != nil {
+				manifest.finish(merged, walkErr)
+				if outFile != nil {
+					if walkErr == nil {
+						manifest.Outputs = append(manifest.Outputs, outFile.output())
+					}
+				} else {
+					manifest.Outputs = append(manifest.Outputs, stdout.output("stdout"))
+				}
+				if opts.archivePath != "" {
+					if err := manifest.addFile(opts.archivePath); err != nil && walkErr == nil {
+						walkErr = err
+					}
+				}
+				if err := manifest.writeFile(manifestPath); err != nil && walkErr == nil {
+					walkErr = fmt.Errorf("write manifest: %w", err)
+				}
+			}
+			return walkErr
 		},
 	}
 
+	opts.register(cmd)
+	cmd.PersistentFlags().StringVar(&configPath, "config", "", "YAML or JSON file with flag values keyed by flag name (flags and GO_SITEMAP_FETCHER_* env vars take precedence)")
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return invalidInput(err)
+	})
 	flags := cmd.Flags()
-	flags.IntVar(&maxDepth, "max-depth", 0, "Maximum sitemap index depth (0 = no limit)")
-	flags.IntVar(&maxSitemaps, "max-sitemaps", 0, "Maximum number of sitemaps to fetch (0 = no limit)")
-	flags.IntVar(&maxURLs, "max-urls", 0, "Maximum number of URLs to yield (0 = no limit)")
-	flags.BoolVar(&allowNon200, "allow-non-200", false, "Skip non-200 sitemaps instead of failing")
-	flags.BoolVar(&ignoreRobots, "ignore-robots", false, "Ignore robots.txt disallow rules")
-	flags.StringVar(&userAgent, "user-agent", "", "User-Agent for HTTP requests")
-	flags.DurationVar(&perRequestTimeout, "timeout", 0, "Per-request timeout (e.g. 5s, 500ms)")
-	flags.StringVar(&logLevel, "log-level", "", "Log level (debug, info, warn, error)")
+	flags.StringVar(&format, "format", "text", "Output format (text, ndjson, json, csv, tsv)")
+	flags.StringVar(&outputPath, "output", "", "Write the output to this file instead of stdout, gzip-compressed if it ends in .gz; it only appears once the run succeeded")
+	flags.StringVar(&manifestPath, "manifest", "", "Write a JSON run manifest (input, sitemaps, options, versions, timings, errors, output hashes) to this file")
+	flags.BoolVar(&summary, "summary", false, "Print URL, sitemap, byte, and error counts to stderr at the end of the run")
+	flags.StringVar(&summaryPath, "summary-json", "", "Write the end-of-run summary as JSON to this file")
+	flags.BoolVar(&count, "count", false, "Print only the number of URLs instead of the URLs")
+	flags.BoolVar(&countPer, "count-per-sitemap", false, "Like --count, preceded by the number of URLs from each sitemap")
+	flags.BoolVar(&withMetadata, "with-metadata", false, "Add images, videos, and raw lastmod/priority text to json and ndjson output")
+	flags.StringSliceVar(&columns, "columns", nil, "Columns for csv/tsv output (site, loc, lastmod, changefreq, priority, sitemap)")
+	flags.StringVar(&inputFile, "input-file", "", "Read more targets from this file, one per line (# starts a comment)")
+	flags.IntVar(&concurrency, "concurrency", 1, "Number of targets walked at the same time")
+
+	cmd.AddCommand(newCompareCommand(&opts))
+	cmd.AddCommand(newQueryCommand())
+	cmd.AddCommand(newDiffCommand(&opts))
+	cmd.AddCommand(newDiscoverCommand(&opts))
+	cmd.AddCommand(newValidateCommand(&opts))
 
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		var partial *gositemapfetcher.ErrPartial
+		if errors.As(err, &partial) {
+			for _, failure := range partial.Failures {
+				fmt.Fprintf(os.Stderr, "  %s: %v\n", failure.URL, failure.Err)
+			}
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+func parseTargetURL(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		if info, err := os.Stat(raw); err == nil && info.Mode().IsRegular() {
+			abs, err := filepath.Abs(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %w", raw, err)
+			}
+			return gositemapfetcher.FileURL(abs), nil
+		}
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, err)
 	}
+	return parsed, nil
 }
 
 func resolveLogLevel(flagValue string) (slog.Level, error) {
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/pflag"
+)
+
+// runManifest records how a URL list was produced, so pipelines can keep it
+// next to the output as an audit trail.
+type runManifest struct {
+	Input      string            `json:"input"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Duration   string            `json:"duration"`
+	Versions   manifestVersions  `json:"versions"`
+	Options    map[string]string `json:"options"`
+	Sitemaps   []manifestSitemap `json:"sitemaps"`
+	Summary    manifestSummary   `json:"summary"`
+	Error      string            `json:"error,omitempty"`
+	Outputs    []manifestOutput  `json:"outputs"`
+}
+
+type manifestVersions struct {
+	Tool string `json:"tool"`
+	Go   string `json:"go"`
+}
+
+type manifestSitemap struct {
+	URL      string `json:"url"`
+	Type     string `json:"type,omitempty"`
+	Entries  int    `json:"entries"`
+	URLs     int    `json:"urls"`
+	Bytes    int64  `json:"bytes"`
+	Duration string `json:"duration"`
+	AliasOf  string `json:"alias_of,omitempty"`
+	FinalURL string `json:"final_url,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type manifestSummary struct {
+	SitemapsFetched       int   `json:"sitemaps_fetched"`
+	URLsYielded           int   `json:"urls_yielded"`
+	URLsFiltered          int   `json:"urls_filtered"`
+	RobotsBlockedURLs     int   `json:"robots_blocked_urls"`
+	RobotsBlockedSitemaps int   `json:"robots_blocked_sitemaps"`
+	BytesRead             int64 `json:"bytes_read"`
+	BytesDownloaded       int64 `json:"bytes_downloaded"`
+	SitemapAliases        int   `json:"sitemap_aliases"`
+	Errors                int   `json:"errors"`
+}
+
+type manifestOutput struct {
+	Name   string `json:"name"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// hashingWriter passes writes through while hashing them.
+type hashingWriter struct {
+	w    io.Writer
+	hash hash.Hash
+	n    int64
+}
+
+func newHashingWriter(w io.Writer) *hashingWriter {
+	return &hashingWriter{w: w, hash: sha256.New()}
+}
+
+func (h *hashingWriter) Write(p []byte) (int, error) {
+	n, err := h.w.Write(p)
+	h.hash.Write(p[:n])
+	h.n += int64(n)
+	return n, err
+}
+
+func (h *hashingWriter) output(name string) manifestOutput {
+	return manifestOutput{Name: name, Bytes: h.n, SHA256: hex.EncodeToString(h.hash.Sum(nil))}
+}
+
+func newRunManifest(input string, flags *pflag.FlagSet, started time.Time) *runManifest {
+	manifest := &runManifest{
+		Input:     input,
+		StartedAt: started.UTC(),
+		Versions:  manifestVersions{Tool: "(devel)", Go: runtime.Version()},
+		Options:   map[string]string{},
+		Sitemaps:  []manifestSitemap{},
+		Outputs:   []manifestOutput{},
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		manifest.Versions.Tool = info.Main.Version
+	}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == "help" || flag.Name == "manifest" {
+			return
+		}
+		if flag.Name == "header" {
+			manifest.Options[flag.Name] = redactHeaders(flag.Value.(pflag.SliceValue).GetSlice())
+			return
+		}
+		if flag.Name == "proxy" {
+			if proxy, err := url.Parse(flag.Value.String()); err == nil {
+				manifest.Options[flag.Name] = proxy.Redacted()
+				return
+			}
+		}
+		if flag.Name == "mirror" {
+			manifest.Options[flag.Name] = redactURLs(flag.Value.(pflag.SliceValue).GetSlice())
+			return
+		}
+		manifest.Options[flag.Name] = flag.Value.String()
+	})
+	return manifest
+}
+
+// redactHeaders keeps --header names but not their values, which often
+// carry credentials.
+func redactHeaders(headers []string) string {
+	redacted := make([]string, 0, len(headers))
+	for _, header := range headers {
+		name, _, _ := strings.Cut(header, ":")
+		redacted = append(redacted, strings.TrimSpace(name)+": [redacted]")
+	}
+	return "[" + strings.Join(redacted, ",") + "]"
+}
+
+// redactURLs hides the passwords of --mirror URLs like --proxy does.
+func redactURLs(raw []string) string {
+	redacted := make([]string, 0, len(raw))
+	for _, value := range raw {
+		if parsed, err := url.Parse(value); err == nil {
+			value = parsed.Redacted()
+		}
+		redacted = append(redacted, value)
+	}
+	return "[" + strings.Join(redacted, ",") + "]"
+}
+
+// finish records the walk result and error.
+func (m *runManifest) finish(result *gositemapfetcher.WalkResult, walkErr error) {
+	finished := time.Now()
+	m.FinishedAt = finished.UTC()
+	m.Duration = finished.Sub(m.StartedAt).String()
+	if walkErr != nil {
+		m.Error = walkErr.Error()
+	}
+	m.Summary.SitemapsFetched = result.SitemapsFetched
+	m.Summary.URLsYielded = result.URLsYielded
+	m.Summary.URLsFiltered = result.URLsFiltered
+	m.Summary.RobotsBlockedURLs = result.RobotsBlockedURLs
+	m.Summary.RobotsBlockedSitemaps = result.RobotsBlockedSitemaps
+	m.Summary.BytesRead = result.BytesRead
+	m.Summary.BytesDownloaded = result.BytesDownloaded
+	m.Summary.SitemapAliases = result.SitemapAliases
+	for _, stats := range result.Sitemaps {
+		entry := manifestSitemap{
+			URL:      stats.URL.String(),
+			Type:     string(stats.Type),
+			Entries:  stats.Entries,
+			URLs:     stats.URLs,
+			Bytes:    stats.Bytes,
+			Duration: stats.Duration.String(),
+		}
+		if stats.AliasOf != nil {
+			entry.AliasOf = stats.AliasOf.String()
+		}
+		if stats.FinalURL != nil {
+			entry.FinalURL = stats.FinalURL.String()
+		}
+		if stats.Err != nil {
+			entry.Error = stats.Err.Error()
+			m.Summary.Errors++
+		}
+		m.Sitemaps = append(m.Sitemaps, entry)
+	}
+	// Errors outside any sitemap, e.g. robots.txt or yield failures.
+	if walkErr != nil && m.Summary.Errors == 0 {
+		m.Summary.Errors = 1
+	}
+}
+
+// addFile hashes a file written during the run, e.g. the --archive tar.
+func (m *runManifest) addFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	h := newHashingWriter(io.Discard)
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+	m.Outputs = append(m.Outputs, h.output(path))
+	return nil
+}
+
+func (m *runManifest) writeFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/enot-style/go-sitemap-fetcher/cache"
+	"github.com/spf13/cobra"
+)
+
+// fetchOptions holds the flags shared by every command that walks sitemaps.
+type fetchOptions struct {
+	maxDepth          int
+	maxSitemaps       int
+	maxURLs           int
+	maxSitemapBytes   int64
+	stopAtLimit       bool
+	skipDeep          bool
+	allowNon200       bool
+	continueOnError   bool
+	noCompression     bool
+	ignoreRobots      bool
+	ignoreCrawlDelay  bool
+	maxCrawlDelay     time.Duration
+	userAgent         string
+	userAgentSuffix   string
+	robotsAgent       string
+	robotsErrors      string
+	robotsScope       string
+	headers           []string
+	headerHosts       []string
+	proxy             string
+	insecure          bool
+	caCert            string
+	perRequestTimeout time.Duration
+	maxDuration       time.Duration
+	maxBytes          int64
+	logLevel          string
+	logFormat         string
+	cacheDir          string
+	maxRetries        int
+	retryStatus       []int
+	retryNetwork      bool
+	archivePath       string
+	replayPath        string
+	recordDir         string
+	sourceDir         string
+	mirrors           []string
+	strictSpec        bool
+	lenient           bool
+	requireNamespace  bool
+	crossHost         string
+	traversal         string
+	deterministic     bool
+	priority          string
+	extensions        []string
+	utc               bool
+	progress          bool
+	skipDuplicates    bool
+	noProbe           bool
+	since             string
+	until             string
+
+	// captureExtensions is set by the root command for --with-metadata.
+	captureExtensions bool
+	// countURLs is set by the discover command for --count-urls.
+	countURLs bool
+
+	// reporter is set by newFetcher when --progress is given.
+	reporter *progressReporter
+}
+
+func (o *fetchOptions) register(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.IntVar(&o.maxDepth, "max-depth", 0, "Maximum sitemap index depth (0 = no limit)")
+	flags.IntVar(&o.maxSitemaps, "max-sitemaps", 0, "Maximum number of sitemaps to fetch (0 = no limit)")
+	flags.IntVar(&o.maxURLs, "max-urls", 0, "Maximum number of URLs to yield (0 = no limit)")
+	flags.Int64Var(&o.maxSitemapBytes, "max-sitemap-bytes", 0, "Maximum uncompressed bytes per sitemap (0 = 50MB, -1 = no limit)")
+	flags.BoolVar(&o.stopAtLimit, "stop-at-limit", false, "End successfully when --max-depth, --max-sitemaps, or --max-urls is reached")
+	flags.BoolVar(&o.skipDeep, "skip-deep-sitemaps", false, "Skip sitemaps deeper than --max-depth instead of failing")
+	flags.BoolVar(&o.allowNon200, "allow-non-200", false, "Skip non-200 sitemaps instead of failing")
+	flags.BoolVar(&o.continueOnError, "continue-on-error", false, "Skip sitemaps that fail to fetch or parse and report them at the end")
+	flags.BoolVar(&o.skipDuplicates, "skip-duplicate-sitemaps", false, "Parse sitemaps served with identical content at several URLs only once")
+	flags.BoolVar(&o.noCompression, "no-compression", false, "Do not request gzip-compressed sitemaps")
+	flags.BoolVar(&o.strictSpec, "strict", false, "Enforce the sitemaps.org protocol and report violations")
+	flags.BoolVar(&o.lenient, "lenient", false, "Recover entries from malformed sitemaps instead of failing them")
+	flags.BoolVar(&o.requireNamespace, "require-namespace", false, "Reject documents that are not sitemaps.org urlset or sitemapindex")
+	flags.StringVar(&o.crossHost, "cross-host", "allow", "Policy for URLs on another host than their sitemap (allow, skip, error)")
+	flags.StringVar(&o.traversal, "traversal", "bfs", "Order in which queued sitemaps are fetched (bfs, dfs, newest: newest index lastmod first)")
+	flags.BoolVar(&o.deterministic, "deterministic", false, "Sort discovered sitemaps by URL and walk multiple targets one at a time, so runs over an unchanged site give identical output")
+	flags.StringVar(&o.priority, "priority", "keep", "Policy for priorities outside 0.0-1.0 (keep, clamp, reject)")
+	flags.StringSliceVar(&o.extensions, "require-extension", nil, "Only yield entries carrying this extension data (image, video, news)")
+	flags.StringVar(&o.since, "since", "", "Only URLs with lastmod at or after this date or this long ago (YYYY-MM-DD, RFC 3339, or a duration like 72h or 7d)")
+	flags.StringVar(&o.until, "until", "", "Only URLs with lastmod before this date or this long ago (same forms as --since)")
+	flags.BoolVar(&o.utc, "utc", false, "Normalize lastmod values to UTC")
+	flags.BoolVar(&o.noProbe, "no-probe", false, "Do not probe default sitemap paths when robots.txt lists none")
+	flags.BoolVar(&o.ignoreRobots, "ignore-robots", false, "Ignore robots.txt disallow rules")
+	flags.StringVar(&o.robotsScope, "robots-scope", "both", "What robots.txt rules are checked against (both, sitemaps, urls, none)")
+	flags.StringVar(&o.robotsErrors, "robots-errors", "allow", "Policy for robots.txt answering 5xx/429 or unreachable (allow, rfc9309, fail)")
+	flags.BoolVar(&o.ignoreCrawlDelay, "ignore-crawl-delay", false, "Ignore robots.txt Crawl-delay")
+	flags.DurationVar(&o.maxCrawlDelay, "max-crawl-delay", 0, "Maximum robots.txt Crawl-delay to honor (0 = 30s)")
+	flags.IntVar(&o.maxRetries, "max-retries", 0, "Retries per sitemap request (0 = 3, -1 = none)")
+	flags.IntSliceVar(&o.retryStatus, "retry-status", nil, "HTTP statuses to retry (default 429)")
+	flags.BoolVar(&o.retryNetwork, "retry-network-errors", false, "Retry requests that fail at the network level")
+	flags.StringVar(&o.userAgent, "user-agent", "", "User-Agent for HTTP requests")
+	flags.StringVar(&o.userAgentSuffix, "user-agent-suffix", "", "Token appended to the User-Agent (e.g. +https://example.com/bot)")
+	flags.StringVar(&o.robotsAgent, "robots-agent", "", "robots.txt user-agent group to obey (default: the User-Agent)")
+	flags.StringArrayVar(&o.headers, "header", nil, "Extra request header as \"Name: value\", e.g. \"Authorization: Bearer TOKEN\" (repeatable)")
+	flags.StringSliceVar(&o.headerHosts, "header-host", nil, "Another host receiving --header values, e.g. a CDN serving child sitemaps (repeatable)")
+	flags.StringVar(&o.proxy, "proxy", "", "Proxy URL for all requests (http://, https://, or socks5://host:port)")
+	flags.BoolVar(&o.insecure, "insecure", false, "Skip TLS certificate verification (e.g. self-signed staging hosts)")
+	flags.StringVar(&o.caCert, "ca-cert", "", "PEM file with extra CA certificates to trust")
+	flags.DurationVar(&o.perRequestTimeout, "timeout", 0, "Per-request timeout (e.g. 5s, 500ms)")
+	flags.DurationVar(&o.maxDuration, "max-duration", 0, "Stop the walk after this long (e.g. 10m, 0 = no limit)")
+	flags.Int64Var(&o.maxBytes, "max-bytes", 0, "Stop the walk after downloading this many bytes, robots.txt included (0 = no limit)")
+	flags.BoolVar(&o.progress, "progress", false, "Show a live status line on stderr (sitemaps fetched and queued, URLs, elapsed time, current sitemap)")
+	flags.StringVar(&o.logLevel, "log-level", "", "Log level (debug, info, warn, error)")
+	flags.StringVar(&o.logFormat, "log-format", "text", "Log format on stderr (text, json)")
+	flags.StringVar(&o.archivePath, "archive", "", "Write raw sitemap bodies and metadata to this tar file")
+	flags.StringVar(&o.replayPath, "replay", "", "Walk from a tar file written by --archive or a directory written by --record instead of the network")
+	flags.StringVar(&o.recordDir, "record", "", "Record every robots.txt and sitemap response (URL, headers, body) into this directory for --replay")
+	flags.StringVar(&o.sourceDir, "source-dir", "", "Read sitemaps from DIR/<host>/<path> instead of the network")
+	flags.StringSliceVar(&o.mirrors, "mirror", nil, "Fallback base URL tried when the site's host fails at the network level (repeatable)")
+	flags.StringVar(&o.cacheDir, "cache-dir", "", "Directory for caching sitemaps between runs (conditional requests)")
+}
+
+// newFetcher builds a SitemapFetcher from the parsed flags. The returned
+// cleanup function must be called once the walk has finished.
+func (o *fetchOptions) newFetcher() (*gositemapfetcher.SitemapFetcher, func() error, error) {
+	level, err := resolveLogLevel(o.logLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(o.logFormat)) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	default:
+		return nil, nil, fmt.Errorf("invalid log format %q (use text, json)", o.logFormat)
+	}
+	logger := slog.New(handler)
+
+	crossHost, err := parseCrossHostPolicy(o.crossHost)
+	if err != nil {
+		return nil, nil, err
+	}
+	priorityPolicy, err := parsePriorityPolicy(o.priority)
+	if err != nil {
+		return nil, nil, err
+	}
+	traversal, err := parseTraversalOrder(o.traversal)
+	if err != nil {
+		return nil, nil, err
+	}
+	robotsErrors, err := parseRobotsErrorPolicy(o.robotsErrors)
+	if err != nil {
+		return nil, nil, err
+	}
+	robotsScope, err := parseRobotsScope(o.robotsScope)
+	if err != nil {
+		return nil, nil, err
+	}
+	since, err := parseDateFlag("since", o.since)
+	if err != nil {
+		return nil, nil, err
+	}
+	until, err := parseDateFlag("until", o.until)
+	if err != nil {
+		return nil, nil, err
+	}
+	extensions := make([]gositemapfetcher.Extension, 0, len(o.extensions))
+	for _, raw := range o.extensions {
+		ext := gositemapfetcher.Extension(strings.ToLower(strings.TrimSpace(raw)))
+		switch ext {
+		case gositemapfetcher.ExtensionImage, gositemapfetcher.ExtensionVideo, gositemapfetcher.ExtensionNews:
+			extensions = append(extensions, ext)
+		default:
+			return nil, nil, fmt.Errorf("invalid extension %q (use image, video, news)", raw)
+		}
+	}
+	headers := http.Header{}
+	for _, raw := range o.headers {
+		name, value, ok := strings.Cut(raw, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, nil, fmt.Errorf("invalid header %q (use \"Name: value\")", raw)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	var proxyURL *url.URL
+	if o.proxy != "" {
+		proxyURL, err = url.Parse(o.proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, nil, fmt.Errorf("invalid proxy URL %q", o.proxy)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, nil, fmt.Errorf("invalid proxy URL %q (use http, https, or socks5)", o.proxy)
+		}
+	}
+	var tlsConfig *tls.Config
+	if o.insecure || o.caCert != "" {
+		tlsConfig = &tls.Config{InsecureSkipVerify: o.insecure}
+	}
+	if o.caCert != "" {
+		pem, err := os.ReadFile(o.caCert)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CA certificate file %q: %w", o.caCert, err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("invalid CA certificate file %q: no PEM certificates", o.caCert)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	mirrors := make([]*url.URL, 0, len(o.mirrors))
+	for _, raw := range o.mirrors {
+		mirror, err := url.Parse(raw)
+		if err != nil || mirror.Scheme == "" || mirror.Host == "" {
+			return nil, nil, fmt.Errorf("invalid mirror URL %q", raw)
+		}
+		mirrors = append(mirrors, mirror)
+	}
+
+	limitBehavior := gositemapfetcher.LimitError
+	if o.stopAtLimit {
+		limitBehavior = gositemapfetcher.LimitStop
+	}
+
+	var lastModLocation *time.Location
+	if o.utc {
+		lastModLocation = time.UTC
+	}
+
+	var sitemapCache gositemapfetcher.Cache
+	if o.cacheDir != "" {
+		dirCache, err := cache.NewDirCache(o.cacheDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cache dir %q: %w", o.cacheDir, err)
+		}
+		sitemapCache = dirCache
+	}
+
+	var source gositemapfetcher.SitemapSource
+	var httpClient *http.Client
+	ignoreRobots := o.ignoreRobots
+	if info, err := os.Stat(o.replayPath); o.replayPath != "" && err == nil && info.IsDir() {
+		replay, err := gositemapfetcher.NewReplayTransport(o.replayPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid replay directory %q: %w", o.replayPath, err)
+		}
+		httpClient = &http.Client{Transport: replay}
+	} else if o.replayPath != "" {
+		file, err := os.Open(o.replayPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid replay archive %q: %w", o.replayPath, err)
+		}
+		archiveSource, err := gositemapfetcher.OpenTarArchive(file)
+		file.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid replay archive %q: %w", o.replayPath, err)
+		}
+		source = archiveSource
+		// An archive holds sitemap bodies only; fetching robots.txt would
+		// take a replay back to the live site.
+		ignoreRobots = true
+	}
+	if o.sourceDir != "" {
+		if o.replayPath != "" {
+			return nil, nil, errors.New("--source-dir and --replay cannot be combined")
+		}
+		info, err := os.Stat(o.sourceDir)
+		if err != nil || !info.IsDir() {
+			return nil, nil, fmt.Errorf("invalid source dir %q", o.sourceDir)
+		}
+		source = gositemapfetcher.NewDirSource(o.sourceDir)
+	}
+
+	var wrapTransport func(http.RoundTripper) http.RoundTripper
+	if o.recordDir != "" {
+		recorder, err := gositemapfetcher.NewRecorder(o.recordDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid record directory %q: %w", o.recordDir, err)
+		}
+		wrapTransport = recorder.Wrap
+	}
+
+	cleanup := func() error { return nil }
+	var archive gositemapfetcher.Archiver
+	if o.archivePath != "" {
+		file, err := os.Create(o.archivePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid archive path %q: %w", o.archivePath, err)
+		}
+		tarArchive := gositemapfetcher.NewTarArchive(file)
+		archive = tarArchive
+		cleanup = func() error {
+			if err := tarArchive.Close(); err != nil {
+				file.Close()
+				return err
+			}
+			return file.Close()
+		}
+	}
+
+	var hooks gositemapfetcher.Hooks
+	if o.progress {
+		o.reporter = newProgressReporter(os.Stderr)
+		hooks = o.reporter.hooks()
+		archiveCleanup := cleanup
+		cleanup = func() error {
+			o.reporter.Close()
+			return archiveCleanup()
+		}
+	}
+
+	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+		MaxDepth:          o.maxDepth,
+		MaxSitemaps:       o.maxSitemaps,
+		MaxURLs:           o.maxURLs,
+		MaxSitemapBytes:   o.maxSitemapBytes,
+		AllowNon200:       o.allowNon200,
+		IgnoreRobots:      ignoreRobots,
+		IgnoreCrawlDelay:  o.ignoreCrawlDelay,
+		MaxCrawlDelay:     o.maxCrawlDelay,
+		UserAgent:         o.userAgent,
+		UserAgentSuffix:   o.userAgentSuffix,
+		RobotsAgent:       o.robotsAgent,
+		RobotsErrorPolicy: robotsErrors,
+		RobotsScope:       robotsScope,
+		Headers:           headers,
+		HeaderHosts:       o.headerHosts,
+		CountSitemapURLs:  o.countURLs,
+		HTTPClient:        httpClient,
+		ProxyURL:          proxyURL,
+		TLS:               tlsConfig,
+		WrapTransport:     wrapTransport,
+		PerRequestTimeout: o.perRequestTimeout,
+		MaxDuration:       o.maxDuration,
+		MaxBytes:          o.maxBytes,
+		LimitBehavior:     limitBehavior,
+		Logger:            logger,
+		Cache:             sitemapCache,
+		Retry: gositemapfetcher.RetryPolicy{
+			MaxRetries:    o.maxRetries,
+			StatusCodes:   o.retryStatus,
+			NetworkErrors: o.retryNetwork,
+		},
+		Archive:            archive,
+		SitemapSource:      source,
+		StrictSpec:         o.strictSpec,
+		CrossHostPolicy:    crossHost,
+		TraversalOrder:     traversal,
+		Deterministic:      o.deterministic,
+		LastModLocation:    lastModLocation,
+		DisableCompression: o.noCompression,
+		PriorityPolicy:     priorityPolicy,
+		Mirrors:            mirrors,
+		RequireExtensions:  extensions,
+		Hooks:              hooks,
+		NoProbe:            o.noProbe,
+		SkipDeepSitemaps:   o.skipDeep,
+		OnErrorContinue:    o.continueOnError,
+		LenientXML:         o.lenient,
+		RequireNamespace:   o.requireNamespace,
+		CaptureExtensions:  o.captureExtensions,
+		ModifiedAfter:      since,
+		ModifiedBefore:     until,
+
+		SkipDuplicateSitemaps: o.skipDuplicates,
+	})
+	return fetcher, cleanup, nil
+}
+
+// track wraps yield to count items for --progress.
+func (o *fetchOptions) track(yield func(gositemapfetcher.Item) error) func(gositemapfetcher.Item) error {
+	if o.reporter == nil {
+		return yield
+	}
+	return o.reporter.track(yield)
+}
+
+func parseCrossHostPolicy(value string) (gositemapfetcher.CrossHostPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "allow":
+		return gositemapfetcher.CrossHostAllow, nil
+	case "skip":
+		return gositemapfetcher.CrossHostSkip, nil
+	case "error":
+		return gositemapfetcher.CrossHostError, nil
+	default:
+		return gositemapfetcher.CrossHostAllow, fmt.Errorf("invalid cross-host policy %q (use allow, skip, error)", value)
+	}
+}
+
+func parseTraversalOrder(value string) (gositemapfetcher.TraversalOrder, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "bfs":
+		return gositemapfetcher.TraversalBFS, nil
+	case "dfs":
+		return gositemapfetcher.TraversalDFS, nil
+	case "newest":
+		return gositemapfetcher.TraversalNewestFirst, nil
+	default:
+		return gositemapfetcher.TraversalBFS, fmt.Errorf("invalid traversal order %q (use bfs, dfs, newest)", value)
+	}
+}
+
+func parsePriorityPolicy(value string) (gositemapfetcher.PriorityPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "keep":
+		return gositemapfetcher.PriorityKeep, nil
+	case "clamp":
+		return gositemapfetcher.PriorityClamp, nil
+	case "reject":
+		return gositemapfetcher.PriorityReject, nil
+	default:
+		return gositemapfetcher.PriorityKeep, fmt.Errorf("invalid priority policy %q (use keep, clamp, reject)", value)
+	}
+}
+
+func parseRobotsErrorPolicy(value string) (gositemapfetcher.RobotsErrorPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "allow":
+		return gositemapfetcher.RobotsErrorAllow, nil
+	case "rfc9309":
+		return gositemapfetcher.RobotsErrorRFC9309, nil
+	case "fail":
+		return gositemapfetcher.RobotsErrorFail, nil
+	default:
+		return gositemapfetcher.RobotsErrorAllow, fmt.Errorf("invalid robots error policy %q (use allow, rfc9309, fail)", value)
+	}
+}
+
+func parseRobotsScope(value string) (gositemapfetcher.RobotsScope, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "both":
+		return gositemapfetcher.RobotsScopeBoth, nil
+	case "sitemaps":
+		return gositemapfetcher.RobotsScopeSitemaps, nil
+	case "urls":
+		return gositemapfetcher.RobotsScopeURLs, nil
+	case "none":
+		return gositemapfetcher.RobotsScopeNone, nil
+	default:
+		return gositemapfetcher.RobotsScopeBoth, fmt.Errorf("invalid robots scope %q (use both, sitemaps, urls, none)", value)
+	}
+}
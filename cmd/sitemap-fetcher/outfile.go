@@ -0,0 +1,86 @@
+package main
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// atomicFile writes an export to a temporary file next to path and renames
+// it into place on Commit, so a failed run never leaves a truncated file
+// that downstream jobs could mistake for a complete one. Paths ending in
+// .gz are gzip-compressed.
+type atomicFile struct {
+	path string
+	tmp  *os.File
+	hash *hashingWriter // hashes the bytes on disk for the manifest
+	gz   *gzip.Writer
+	done bool
+}
+
+func createAtomicFile(path string) (*atomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	a := &atomicFile{path: path, tmp: tmp, hash: newHashingWriter(tmp)}
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		a.gz = gzip.NewWriter(a.hash)
+	}
+	return a, nil
+}
+
+func (a *atomicFile) Write(p []byte) (int, error) {
+	if a.gz != nil {
+		return a.gz.Write(p)
+	}
+	return a.hash.Write(p)
+}
+
+// Commit finishes the file and renames it to its final path.
+func (a *atomicFile) Commit() error {
+	a.done = true
+	if a.gz != nil {
+		if err := a.gz.Close(); err != nil {
+			a.remove()
+			return err
+		}
+	}
+	if err := a.tmp.Chmod(0o644); err != nil {
+		a.remove()
+		return err
+	}
+	if err := a.tmp.Sync(); err != nil {
+		a.remove()
+		return err
+	}
+	if err := a.tmp.Close(); err != nil {
+		os.Remove(a.tmp.Name())
+		return err
+	}
+	if err := os.Rename(a.tmp.Name(), a.path); err != nil {
+		os.Remove(a.tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort discards the temporary file unless Commit was called.
+func (a *atomicFile) Abort() {
+	if a.done {
+		return
+	}
+	a.done = true
+	a.remove()
+}
+
+func (a *atomicFile) remove() {
+	a.tmp.Close()
+	os.Remove(a.tmp.Name())
+}
+
+// output reports the committed file for the manifest.
+func (a *atomicFile) output() manifestOutput {
+	return a.hash.output(a.path)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicFile(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		file   string
+		commit bool
+		gzip   bool
+	}{
+		{"plain", "urls.txt", true, false},
+		{"gzip", "urls.txt.gz", true, true},
+		{"gzip uppercase", "URLS.TXT.GZ", true, true},
+		{"abort", "urls.txt", false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tc.file)
+			if err := os.WriteFile(path, []byte("previous\n"), 0o644); err != nil {
+				t.Fatalf("write previous file: %v", err)
+			}
+			a, err := createAtomicFile(path)
+			if err != nil {
+				t.Fatalf("createAtomicFile failed: %v", err)
+			}
+			if _, err := io.WriteString(a, "https://example.com/\n"); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if got := readFile(t, path, false); got != "previous\n" {
+				t.Fatalf("expected the previous file until Commit, got %q", got)
+			}
+			if tc.commit {
+				if err := a.Commit(); err != nil {
+					t.Fatalf("Commit failed: %v", err)
+				}
+			}
+			a.Abort()
+
+			want := "previous\n"
+			if tc.commit {
+				want = "https://example.com/\n"
+			}
+			if got := readFile(t, path, tc.gzip && tc.commit); got != want {
+				t.Fatalf("expected %q, got %q", want, got)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("read dir: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected only %s in the directory, got %d entries", tc.file, len(entries))
+			}
+			if tc.commit {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatalf("stat: %v", err)
+				}
+				if info.Mode().Perm() != 0o644 {
+					t.Fatalf("expected mode 0644, got %v", info.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+func readFile(t *testing.T, path string, gzipped bool) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("expected a gzip file: %v", err)
+		}
+		r = gz
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
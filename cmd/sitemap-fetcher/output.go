@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+// itemWriter renders walked items in a specific output format.
+type itemWriter interface {
+	// Write renders item. site is the target it was walked from when
+	// several targets are walked, and empty otherwise.
+	Write(site string, item gositemapfetcher.Item) error
+	// Flush writes anything buffered once all items have been written.
+	Flush() error
+}
+
+var defaultColumns = []string{"loc", "lastmod", "changefreq", "priority", "sitemap"}
+
+// newItemWriter returns the writer for format. withMetadata adds images,
+// videos, and the raw lastmod and priority text to json and ndjson output;
+// tagSites means items will come with a site to tag them with.
+func newItemWriter(format string, columns []string, withMetadata, tagSites bool, w io.Writer) (itemWriter, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if withMetadata && format != "json" && format != "ndjson" {
+		return nil, errors.New("--with-metadata requires --format json or ndjson")
+	}
+	switch format {
+	case "", "text":
+		return &textWriter{w: w}, nil
+	case "ndjson":
+		return &ndjsonWriter{enc: json.NewEncoder(w), withMetadata: withMetadata}, nil
+	case "json":
+		if withMetadata || tagSites {
+			return &jsonArrayWriter{w: w, withMetadata: withMetadata}, nil
+		}
+		return &snapshotWriter{w: w, set: gositemapfetcher.NewResultSet(nil)}, nil
+	case "csv", "tsv":
+		if len(columns) == 0 && tagSites {
+			columns = append([]string{"site"}, defaultColumns...)
+		}
+		return newCSVWriter(w, format, columns)
+	default:
+		return nil, fmt.Errorf("invalid format %q (use text, ndjson, json, csv, tsv)", format)
+	}
+}
+
+// discardWriter drops items, e.g. for --count.
+type discardWriter struct{}
+
+func (discardWriter) Write(string, gositemapfetcher.Item) error {
+	return nil
+}
+
+func (discardWriter) Flush() error {
+	return nil
+}
+
+// writeCounts prints the URL total for --count like wc -l. With perSitemap,
+// one "count<TAB>sitemap" line per sitemap with URL entries comes first, in
+// fetch order, and the total is labeled "total".
+func writeCounts(w io.Writer, result *gositemapfetcher.WalkResult, perSitemap bool) error {
+	if !perSitemap {
+		_, err := fmt.Fprintln(w, result.URLsYielded)
+		return err
+	}
+	for _, stats := range result.Sitemaps {
+		if stats.Type == gositemapfetcher.SitemapTypeIndex || stats.AliasOf != nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%d\t%s\n", stats.URLs, stats.URL); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d\ttotal\n", result.URLsYielded)
+	return err
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Write(site string, item gositemapfetcher.Item) error {
+	if site != "" {
+		_, err := fmt.Fprintf(t.w, "%s\t%s\n", site, item.Loc)
+		return err
+	}
+	_, err := fmt.Fprintln(t.w, item.Loc.String())
+	return err
+}
+
+func (t *textWriter) Flush() error {
+	return nil
+}
+
+type jsonItem struct {
+	Site       string   `json:"site,omitempty"`
+	Loc        string   `json:"loc"`
+	LastMod    string   `json:"lastmod,omitempty"`
+	ChangeFreq string   `json:"changefreq,omitempty"`
+	Priority   *float64 `json:"priority,omitempty"`
+	Sitemap    string   `json:"sitemap,omitempty"`
+	// SitemapLastMod is the lastmod the parent index gave Sitemap.
+	SitemapLastMod string `json:"sitemap_lastmod,omitempty"`
+
+	// Set with --with-metadata only.
+	RawLastMod  string      `json:"raw_lastmod,omitempty"`
+	RawPriority string      `json:"raw_priority,omitempty"`
+	Images      []jsonImage `json:"images,omitempty"`
+	Videos      []jsonVideo `json:"videos,omitempty"`
+}
+
+type jsonImage struct {
+	Loc     string `json:"loc"`
+	Title   string `json:"title,omitempty"`
+	Caption string `json:"caption,omitempty"`
+}
+
+type jsonVideo struct {
+	ThumbnailLoc    string `json:"thumbnail_loc,omitempty"`
+	Title           string `json:"title,omitempty"`
+	Description     string `json:"description,omitempty"`
+	ContentLoc      string `json:"content_loc,omitempty"`
+	PlayerLoc       string `json:"player_loc,omitempty"`
+	Duration        string `json:"duration,omitempty"`
+	PublicationDate string `json:"publication_date,omitempty"`
+}
+
+func toJSONItem(site string, item gositemapfetcher.Item, withMetadata bool) jsonItem {
+	out := jsonItem{
+		Site:       site,
+		Loc:        item.Loc.String(),
+		ChangeFreq: string(item.ChangeFreq),
+		Priority:   item.Priority,
+	}
+	if item.LastMod != nil {
+		out.LastMod = item.LastMod.Format(time.RFC3339Nano)
+	}
+	if item.Sitemap != nil {
+		out.Sitemap = item.Sitemap.String()
+	}
+	if item.SitemapLastMod != nil {
+		out.SitemapLastMod = item.SitemapLastMod.Format(time.RFC3339Nano)
+	}
+	if !withMetadata {
+		return out
+	}
+	out.RawLastMod = item.RawLastMod
+	out.RawPriority = item.RawPriority
+	for _, el := range item.Extensions {
+		switch {
+		case isExtension(el, gositemapfetcher.ExtensionImage, gositemapfetcher.ImageNamespace):
+			out.Images = append(out.Images, jsonImage{
+				Loc:     childText(el, "loc"),
+				Title:   childText(el, "title"),
+				Caption: childText(el, "caption"),
+			})
+		case isExtension(el, gositemapfetcher.ExtensionVideo, gositemapfetcher.VideoNamespace):
+			out.Videos = append(out.Videos, jsonVideo{
+				ThumbnailLoc:    childText(el, "thumbnail_loc"),
+				Title:           childText(el, "title"),
+				Description:     childText(el, "description"),
+				ContentLoc:      childText(el, "content_loc"),
+				PlayerLoc:       childText(el, "player_loc"),
+				Duration:        childText(el, "duration"),
+				PublicationDate: childText(el, "publication_date"),
+			})
+		}
+	}
+	return out
+}
+
+// isExtension reports whether el is the <image:image> or <video:video>
+// element of ext, accepting an undeclared prefix like the library does.
+func isExtension(el gositemapfetcher.ExtensionElement, ext gositemapfetcher.Extension, namespace string) bool {
+	return el.XMLName.Local == string(ext) && (el.XMLName.Space == namespace || el.XMLName.Space == string(ext))
+}
+
+func childText(el gositemapfetcher.ExtensionElement, local string) string {
+	for _, child := range el.Children {
+		if child.XMLName.Local == local {
+			return child.Text
+		}
+	}
+	return ""
+}
+
+type ndjsonWriter struct {
+	enc          *json.Encoder
+	withMetadata bool
+}
+
+func (n *ndjsonWriter) Write(site string, item gositemapfetcher.Item) error {
+	return n.enc.Encode(toJSONItem(site, item, n.withMetadata))
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
+
+// jsonArrayWriter collects items with their site tag or full metadata and
+// writes them as one JSON array. ReadResultSet ignores the extra fields, so
+// query can still load it.
+type jsonArrayWriter struct {
+	w            io.Writer
+	withMetadata bool
+	items        []jsonItem
+}
+
+func (j *jsonArrayWriter) Write(site string, item gositemapfetcher.Item) error {
+	j.items = append(j.items, toJSONItem(site, item, j.withMetadata))
+	return nil
+}
+
+func (j *jsonArrayWriter) Flush() error {
+	if j.items == nil {
+		j.items = []jsonItem{}
+	}
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.items)
+}
+
+// snapshotWriter collects items and writes them as one JSON array snapshot,
+// which the query command can load later.
+type snapshotWriter struct {
+	w   io.Writer
+	set *gositemapfetcher.ResultSet
+}
+
+func (s *snapshotWriter) Write(_ string, item gositemapfetcher.Item) error {
+	s.set.Add(item)
+	return nil
+}
+
+func (s *snapshotWriter) Flush() error {
+	return s.set.WriteJSON(s.w)
+}
+
+type csvWriter struct {
+	w           *csv.Writer
+	columns     []string
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer, format string, columns []string) (*csvWriter, error) {
+	if len(columns) == 0 {
+		columns = defaultColumns
+	}
+	normalized := make([]string, 0, len(columns))
+	for _, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "site", "loc", "lastmod", "changefreq", "priority", "sitemap":
+			normalized = append(normalized, column)
+		default:
+			return nil, fmt.Errorf("invalid column %q (use site, %s)", column, strings.Join(defaultColumns, ", "))
+		}
+	}
+	writer := csv.NewWriter(w)
+	if strings.EqualFold(format, "tsv") {
+		writer.Comma = '\t'
+	}
+	return &csvWriter{w: writer, columns: normalized}, nil
+}
+
+func (c *csvWriter) Write(site string, item gositemapfetcher.Item) error {
+	if !c.wroteHeader {
+		if err := c.w.Write(c.columns); err != nil {
+			return err
+		}
+		c.wroteHeader = true
+	}
+	record := make([]string, len(c.columns))
+	for i, column := range c.columns {
+		record[i] = columnValue(site, item, column)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Flush() error {
+	return nil
+}
+
+func columnValue(site string, item gositemapfetcher.Item, column string) string {
+	switch column {
+	case "site":
+		return site
+	case "loc":
+		return item.Loc.String()
+	case "lastmod":
+		if item.LastMod != nil {
+			return item.LastMod.Format(time.RFC3339Nano)
+		}
+	case "changefreq":
+		return string(item.ChangeFreq)
+	case "priority":
+		if item.Priority != nil {
+			return strconv.FormatFloat(*item.Priority, 'f', -1, 64)
+		}
+	case "sitemap":
+		if item.Sitemap != nil {
+			return item.Sitemap.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	return parsed
+}
+
+func TestCSVWriter(t *testing.T) {
+	lastMod := time.Date(2024, 1, 2, 3, 4, 5, 500_000_000, time.UTC)
+	priority := 0.8
+	item := gositemapfetcher.Item{
+		Loc:        mustParseURL(t, "https://example.com/a,b?q=\"x\""),
+		LastMod:    &lastMod,
+		ChangeFreq: gositemapfetcher.ChangeFreqDaily,
+		Priority:   &priority,
+		Sitemap:    mustParseURL(t, "https://example.com/sitemap.xml"),
+	}
+	bare := gositemapfetcher.Item{Loc: mustParseURL(t, "https://example.com/plain")}
+
+	for _, tc := range []struct {
+		name     string
+		format   string
+		columns  []string
+		tagSites bool
+		want     string
+		wantErr  string
+	}{
+		{
+			name:   "csv quoting",
+			format: "csv",
+			want: "loc,lastmod,changefreq,priority,sitemap\n" +
+				"\"https://example.com/a,b?q=\"\"x\"\"\",2024-01-02T03:04:05.5Z,daily,0.8,https://example.com/sitemap.xml\n" +
+				"https://example.com/plain,,,,\n",
+		},
+		{
+			name:   "tsv",
+			format: "TSV",
+			want: "loc\tlastmod\tchangefreq\tpriority\tsitemap\n" +
+				"\"https://example.com/a,b?q=\"\"x\"\"\"\t2024-01-02T03:04:05.5Z\tdaily\t0.8\thttps://example.com/sitemap.xml\n" +
+				"https://example.com/plain\t\t\t\t\n",
+		},
+		{
+			name:    "columns",
+			format:  "csv",
+			columns: []string{" Priority ", "loc"},
+			want:    "priority,loc\n0.8,\"https://example.com/a,b?q=\"\"x\"\"\"\n,https://example.com/plain\n",
+		},
+		{
+			name:     "site column",
+			format:   "csv",
+			tagSites: true,
+			want: "site,loc,lastmod,changefreq,priority,sitemap\n" +
+				"example.com,\"https://example.com/a,b?q=\"\"x\"\"\",2024-01-02T03:04:05.5Z,daily,0.8,https://example.com/sitemap.xml\n" +
+				"example.com,https://example.com/plain,,,,\n",
+		},
+		{name: "invalid column", format: "csv", columns: []string{"loc", "title"}, wantErr: `invalid column "title"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			w, err := newItemWriter(tc.format, tc.columns, false, tc.tagSites, &out)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newItemWriter failed: %v", err)
+			}
+			site := ""
+			if tc.tagSites {
+				site = "example.com"
+			}
+			for _, it := range []gositemapfetcher.Item{item, bare} {
+				if err := w.Write(site, it); err != nil {
+					t.Fatalf("Write failed: %v", err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Fatalf("expected\n%q\ngot\n%q", tc.want, out.String())
+			}
+		})
+	}
+}
+
+func TestNewItemWriter_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		format       string
+		withMetadata bool
+		want         string
+	}{
+		{"xml", false, `invalid format "xml"`},
+		{"csv", true, "--with-metadata requires --format json or ndjson"},
+		{"text", true, "--with-metadata requires --format json or ndjson"},
+	} {
+		if _, err := newItemWriter(tc.format, nil, tc.withMetadata, false, &strings.Builder{}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected an error containing %q, got %v", tc.format, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+const progressInterval = 200 * time.Millisecond
+
+// progressReporter redraws a single status line on stderr while a walk runs.
+type progressReporter struct {
+	w       io.Writer
+	started time.Time
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	fetched int
+	pending int
+	urls    int
+	current string
+}
+
+func newProgressReporter(w io.Writer) *progressReporter {
+	p := &progressReporter{
+		w:       w,
+		started: time.Now(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *progressReporter) hooks() gositemapfetcher.Hooks {
+	return gositemapfetcher.Hooks{
+		OnSitemapStart: func(loc *url.URL) {
+			p.mu.Lock()
+			p.current = loc.String()
+			p.mu.Unlock()
+		},
+		OnSitemapDone: func(*url.URL, int, int64, time.Duration, error) {
+			p.mu.Lock()
+			p.fetched++
+			p.mu.Unlock()
+		},
+		OnQueueChange: func(pending int) {
+			p.mu.Lock()
+			p.pending = pending
+			p.mu.Unlock()
+		},
+	}
+}
+
+// track counts items passed to yield.
+func (p *progressReporter) track(yield func(gositemapfetcher.Item) error) func(gositemapfetcher.Item) error {
+	return func(item gositemapfetcher.Item) error {
+		p.mu.Lock()
+		p.urls++
+		p.mu.Unlock()
+		return yield(item)
+	}
+}
+
+func (p *progressReporter) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.render()
+		case <-p.stop:
+			p.render()
+			fmt.Fprintln(p.w)
+			return
+		}
+	}
+}
+
+func (p *progressReporter) render() {
+	p.mu.Lock()
+	line := fmt.Sprintf("sitemaps %d fetched, %d queued | %d URLs | %s",
+		p.fetched, p.pending, p.urls, time.Since(p.started).Truncate(time.Second))
+	if p.current != "" {
+		line += " | " + p.current
+	}
+	p.mu.Unlock()
+	// \r returns to the start of the line and \x1b[K clears what is left of
+	// a longer previous line.
+	fmt.Fprintf(p.w, "\r%s\x1b[K", line)
+}
+
+// Close draws the final status and ends the line.
+func (p *progressReporter) Close() {
+	close(p.stop)
+	<-p.done
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/cobra"
+)
+
+func newQueryCommand() *cobra.Command {
+	var (
+		prefix      string
+		host        string
+		since       string
+		until       string
+		minPriority float64
+		maxPriority float64
+		count       bool
+		format      string
+		columns     []string
+	)
+
+	cmd := &cobra.Command{
+		Use:          "query [flags] <snapshot file or ->",
+		Short:        "Query a saved snapshot without re-walking",
+		Long:         "Filter a snapshot written with --format json (or --format ndjson output) and print the matching URLs. Filters are combined with AND.",
+		SilenceUsage: true,
+		Args:         inputArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			set, err := readSnapshot(args[0])
+			if err != nil {
+				return invalidInput(err)
+			}
+
+			if prefix != "" {
+				set = set.WithPrefix(prefix)
+			}
+			if host != "" {
+				set = set.OnHost(host)
+			}
+			if since != "" || until != "" {
+				sinceTime, err := parseDateFlag("modified-since", since)
+				if err != nil {
+					return invalidInput(err)
+				}
+				untilTime, err := parseDateFlag("modified-until", until)
+				if err != nil {
+					return invalidInput(err)
+				}
+				set = set.ModifiedBetween(sinceTime, untilTime)
+			}
+			flags := cmd.Flags()
+			if flags.Changed("min-priority") || flags.Changed("max-priority") {
+				set = set.PriorityBetween(minPriority, maxPriority)
+			}
+
+			out := bufio.NewWriter(os.Stdout)
+			if count {
+				fmt.Fprintln(out, set.Len())
+				return out.Flush()
+			}
+			writer, err := newItemWriter(format, columns, false, false, out)
+			if err != nil {
+				return invalidInput(err)
+			}
+			for item := range set.All() {
+				if err := writer.Write("", item); err != nil {
+					return err
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			return out.Flush()
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&prefix, "prefix", "", "Only URLs whose path (if it starts with /) or full URL starts with this prefix")
+	flags.StringVar(&host, "host", "", "Only URLs on this host")
+	flags.StringVar(&since, "modified-since", "", "Only URLs with lastmod at or after this date (YYYY-MM-DD, RFC 3339, or a duration ago like 72h or 7d)")
+	flags.StringVar(&until, "modified-until", "", "Only URLs with lastmod before this date (same forms as --modified-since)")
+	flags.Float64Var(&minPriority, "min-priority", math.Inf(-1), "Only URLs with at least this priority")
+	flags.Float64Var(&maxPriority, "max-priority", math.Inf(1), "Only URLs with at most this priority")
+	flags.BoolVar(&count, "count", false, "Print the number of matching URLs only")
+	flags.StringVar(&format, "format", "text", "Output format (text, ndjson, json, csv, tsv)")
+	flags.StringSliceVar(&columns, "columns", nil, "Columns for csv/tsv output (loc, lastmod, changefreq, priority, sitemap)")
+	return cmd
+}
+
+func readSnapshot(path string) (*gositemapfetcher.ResultSet, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	set, err := gositemapfetcher.ReadResultSet(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot %q: %w", path, err)
+	}
+	return set, nil
+}
+
+// parseDateFlag parses a date flag given as YYYY-MM-DD, RFC 3339, or a
+// duration before now such as 72h or 7d, so cron jobs can pass a fixed window.
+func parseDateFlag(name, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	if ago, ok := parseAgo(value); ok {
+		return time.Now().Add(-ago), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q (use YYYY-MM-DD, RFC 3339, or a duration like 72h or 7d)", name, value)
+}
+
+// parseAgo parses a non-negative Go duration, or a whole number of days
+// with a "d" suffix, which time.ParseDuration lacks.
+func parseAgo(value string) (time.Duration, bool) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
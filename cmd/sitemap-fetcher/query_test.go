@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDateFlag(t *testing.T) {
+	for _, tc := range []struct {
+		value   string
+		want    time.Time
+		ago     time.Duration
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "  "},
+		{value: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-01-02T15:04:05+02:00", want: time.Date(2024, 1, 2, 13, 4, 5, 0, time.UTC)},
+		{value: "72h", ago: 72 * time.Hour},
+		{value: "7d", ago: 7 * 24 * time.Hour},
+		{value: "0d", ago: 0},
+		{value: "-1d", wantErr: true},
+		{value: "-5h", wantErr: true},
+		{value: "1.5d", wantErr: true},
+		{value: "02/01/2024", wantErr: true},
+		{value: "yesterday", wantErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			before := time.Now()
+			got, err := parseDateFlag("since", tc.value)
+			after := time.Now()
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid --since") {
+					t.Fatalf("expected an invalid --since error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDateFlag failed: %v", err)
+			}
+			if strings.HasSuffix(tc.value, "h") || strings.HasSuffix(tc.value, "d") {
+				if got.Before(before.Add(-tc.ago)) || got.After(after.Add(-tc.ago)) {
+					t.Fatalf("expected %s before now, got %v", tc.ago, got)
+				}
+				return
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
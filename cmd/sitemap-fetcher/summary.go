@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+// runSummary is the end-of-run report printed by --summary and written by
+// --summary-json, e.g. for crawl dashboards.
+type runSummary struct {
+	URLsYielded           int              `json:"urls_yielded"`
+	URLsFiltered          int              `json:"urls_filtered"`
+	URLsDuplicate         int              `json:"urls_duplicate"`
+	RobotsBlockedURLs     int              `json:"robots_blocked_urls"`
+	SitemapsFetched       int              `json:"sitemaps_fetched"`
+	SitemapsSkipped       int              `json:"sitemaps_skipped"`
+	SitemapAliases        int              `json:"sitemap_aliases"`
+	RobotsBlockedSitemaps int              `json:"robots_blocked_sitemaps"`
+	BytesRead             int64            `json:"bytes_read"`
+	BytesDownloaded       int64            `json:"bytes_downloaded"`
+	Duration              string           `json:"duration"`
+	Errors                []summarySitemap `json:"errors"`
+	// Error is the error that ended the run, if any.
+	Error string `json:"error,omitempty"`
+}
+
+type summarySitemap struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+func newRunSummary(result *gositemapfetcher.WalkResult, walkErr error, duration time.Duration) *runSummary {
+	summary := &runSummary{
+		URLsYielded:           result.URLsYielded,
+		URLsFiltered:          result.URLsFiltered,
+		URLsDuplicate:         result.URLsDuplicate,
+		RobotsBlockedURLs:     result.RobotsBlockedURLs,
+		SitemapsFetched:       result.SitemapsFetched,
+		SitemapsSkipped:       result.SitemapsSkipped,
+		SitemapAliases:        result.SitemapAliases,
+		RobotsBlockedSitemaps: result.RobotsBlockedSitemaps,
+		BytesRead:             result.BytesRead,
+		BytesDownloaded:       result.BytesDownloaded,
+		Duration:              duration.Round(time.Millisecond).String(),
+		Errors:                []summarySitemap{},
+	}
+	for _, stats := range result.Sitemaps {
+		if stats.Err != nil {
+			summary.Errors = append(summary.Errors, summarySitemap{URL: stats.URL.String(), Error: stats.Err.Error()})
+		}
+	}
+	if walkErr != nil {
+		summary.Error = walkErr.Error()
+	}
+	return summary
+}
+
+// print writes the summary for humans, e.g. to stderr after the URL list.
+func (s *runSummary) print(w io.Writer) error {
+	_, err := fmt.Fprintf(w,
+		"URLs:     %d yielded, %d filtered, %d duplicate, %d blocked by robots.txt\n"+
+			"Sitemaps: %d fetched, %d skipped, %d aliases, %d blocked by robots.txt\n"+
+			"Bytes:    %d parsed, %d downloaded\n"+
+			"Duration: %s\n"+
+			"Errors:   %d\n",
+		s.URLsYielded, s.URLsFiltered, s.URLsDuplicate, s.RobotsBlockedURLs,
+		s.SitemapsFetched, s.SitemapsSkipped, s.SitemapAliases, s.RobotsBlockedSitemaps,
+		s.BytesRead, s.BytesDownloaded, s.Duration, len(s.Errors))
+	if err != nil {
+		return err
+	}
+	for _, failure := range s.Errors {
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", failure.URL, failure.Error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *runSummary) writeFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+// readTargets expands the command arguments into the list of sites to walk.
+// "-" reads targets from stdin and inputFile, if set, from a file, one per
+// line; blank lines and lines starting with # are skipped.
+func readTargets(args []string, inputFile string, stdin io.Reader) ([]string, error) {
+	var targets []string
+	for _, arg := range args {
+		if arg != "-" {
+			targets = append(targets, arg)
+			continue
+		}
+		lines, err := readTargetLines(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read targets from stdin: %w", err)
+		}
+		targets = append(targets, lines...)
+	}
+	if inputFile != "" {
+		file, err := os.Open(inputFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input file %q: %w", inputFile, err)
+		}
+		defer file.Close()
+		lines, err := readTargetLines(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input file %q: %w", inputFile, err)
+		}
+		targets = append(targets, lines...)
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("missing URL argument")
+	}
+	return targets, nil
+}
+
+func readTargetLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// targetResult is the outcome of walking one target.
+type targetResult struct {
+	target string
+	result *gositemapfetcher.WalkResult
+	err    error
+}
+
+// walkTargets walks each target with up to concurrency walks at a time and
+// returns the results in target order. newYield builds the callback for
+// each target; the callbacks are never called concurrently.
+// A target that fails does not stop the others; the errors are joined,
+// each prefixed with its target.
+func walkTargets(ctx context.Context, fetcher *gositemapfetcher.SitemapFetcher, targets []string, concurrency int, newYield func(target string) func(gositemapfetcher.Item) error) ([]targetResult, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]targetResult, len(targets))
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for i, target := range targets {
+		results[i].target = target
+		parsed, err := parseTargetURL(target)
+		if err != nil {
+			results[i].err = invalidInput(err)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			yield := newYield(target)
+			results[i].result, results[i].err = fetcher.WalkWithResult(ctx, parsed, func(item gositemapfetcher.Item) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return yield(item)
+			})
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.err == nil {
+			continue
+		}
+		if len(targets) == 1 {
+			errs = append(errs, r.err)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", r.target, r.err))
+	}
+	return results, errors.Join(errs...)
+}
+
+// mergeResults adds up the walk results of several targets for the manifest.
+func mergeResults(results []targetResult) *gositemapfetcher.WalkResult {
+	merged := &gositemapfetcher.WalkResult{}
+	for _, r := range results {
+		if r.result == nil {
+			continue
+		}
+		merged.SitemapsFetched += r.result.SitemapsFetched
+		merged.URLsYielded += r.result.URLsYielded
+		merged.URLsFiltered += r.result.URLsFiltered
+		merged.URLsDuplicate += r.result.URLsDuplicate
+		merged.RobotsBlockedURLs += r.result.RobotsBlockedURLs
+		merged.RobotsBlockedSitemaps += r.result.RobotsBlockedSitemaps
+		merged.BytesRead += r.result.BytesRead
+		merged.BytesDownloaded += r.result.BytesDownloaded
+		merged.SitemapAliases += r.result.SitemapAliases
+		merged.SitemapsSkipped += r.result.SitemapsSkipped
+		merged.Duration += r.result.Duration
+		merged.Sitemaps = append(merged.Sitemaps, r.result.Sitemaps...)
+		if merged.LimitReached == nil {
+			merged.LimitReached = r.result.LimitReached
+		}
+	}
+	return merged
+}
+
+// siteTargets reports the parsed form of each target for the manifest.
+func siteTargets(targets []string) string {
+	normalized := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if parsed, err := parseTargetURL(target); err == nil {
+			target = parsed.String()
+		}
+		normalized = append(normalized, target)
+	}
+	return strings.Join(normalized, " ")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadTargets(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(inputFile, []byte("# sites\nhttps://c.example\n\n  https://d.example  \n"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		inputFile string
+		stdin     string
+		want      []string
+		wantErr   string
+	}{
+		{"args", []string{"a.example", "b.example"}, "", "", []string{"a.example", "b.example"}, ""},
+		{"stdin", []string{"-"}, "", "https://a.example\n# skip\n\r\nhttps://b.example\r\n", []string{"https://a.example", "https://b.example"}, ""},
+		{"stdin between args", []string{"x.example", "-", "y.example"}, "", "z.example", []string{"x.example", "z.example", "y.example"}, ""},
+		{"input file after args", []string{"a.example"}, inputFile, "", []string{"a.example", "https://c.example", "https://d.example"}, ""},
+		{"missing input file", nil, filepath.Join(t.TempDir(), "missing.txt"), "", nil, "invalid input file"},
+		{"empty stdin", []string{"-"}, "", "# nothing\n\n", nil, "missing URL argument"},
+		{"no targets", nil, "", "", nil, "missing URL argument"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := readTargets(tc.args, tc.inputFile, strings.NewReader(tc.stdin))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readTargets failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"github.com/spf13/cobra"
+)
+
+func newValidateCommand(opts *fetchOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [flags] <site, sitemap URL, or file>",
+		Short: "Check sitemaps against the sitemaps.org protocol",
+		Long: "Walk every sitemap with --strict and --continue-on-error and print each protocol violation as \"sitemap:line: rule: detail (loc)\", " +
+			"then each warning, e.g. CDATA use, prefixed with \"warning: \", and the sitemaps that could not be checked at all, e.g. oversized or malformed files, on stderr. " +
+			"Exits non-zero when a violation or unchecked sitemap is found, for CI use; warnings alone do not fail.",
+		SilenceUsage: true,
+		Args:         inputArgs(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parsed, err := parseTargetURL(args[0])
+			if err != nil {
+				return invalidInput(err)
+			}
+			opts.strictSpec = true
+			opts.continueOnError = true
+			fetcher, cleanup, err := opts.newFetcher()
+			if err != nil {
+				return invalidInput(err)
+			}
+
+			result, walkErr := fetcher.WalkWithResult(context.Background(), parsed, func(gositemapfetcher.Item) error {
+				return nil
+			})
+			if err := cleanup(); err != nil && walkErr == nil {
+				walkErr = err
+			}
+
+			out := bufio.NewWriter(os.Stdout)
+			var violations *gositemapfetcher.ErrSpecViolations
+			if errors.As(walkErr, &violations) {
+				for _, violation := range violations.Violations {
+					fmt.Fprintln(out, violation)
+				}
+				if more := violations.Total - len(violations.Violations); more > 0 {
+					fmt.Fprintf(out, "... and %d more violations\n", more)
+				}
+			}
+			for _, warning := range result.SpecWarnings {
+				fmt.Fprintln(out, "warning:", warning)
+			}
+			if err := out.Flush(); err != nil && walkErr == nil {
+				walkErr = err
+			}
+
+			// Sitemaps that could not be checked, e.g. oversized or
+			// malformed files, are listed from the ErrPartial by main.
+			var partial *gositemapfetcher.ErrPartial
+			errors.As(walkErr, &partial)
+
+			fmt.Fprintf(os.Stderr, "sitemaps=%d urls=%d", result.SitemapsFetched, result.URLsYielded+result.URLsFiltered)
+			if violations != nil {
+				fmt.Fprintf(os.Stderr, " violations=%d", violations.Total)
+			}
+			if len(result.SpecWarnings) > 0 {
+				fmt.Fprintf(os.Stderr, " warnings=%d", len(result.SpecWarnings))
+			}
+			if partial != nil {
+				fmt.Fprintf(os.Stderr, " unchecked=%d", len(partial.Failures))
+			}
+			fmt.Fprintln(os.Stderr)
+			return walkErr
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,139 @@
+package gositemapfetcher
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Codec decompresses sitemap bodies in a format identified by leading magic
+// bytes or by the sitemap URL's file extension.
+type Codec struct {
+	// Name identifies the codec, e.g. "xz". It is also accepted as a
+	// Content-Encoding response header value.
+	Name string
+	// Magic is the leading byte sequence of the format. Bodies starting with
+	// it are decoded whatever their URL.
+	Magic []byte
+	// Extensions are URL path suffixes, e.g. ".xz", for formats without magic
+	// bytes. They are matched case-insensitively, at most once per body. A
+	// codec with neither Magic nor Extensions is only applied by its
+	// Content-Encoding.
+	Extensions []string
+	// NewReader returns a reader decompressing r.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = []Codec{{
+		Name:  "gzip",
+		Magic: []byte{0x1f, 0x8b},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	}}
+)
+
+// RegisterCodec adds a decompressor used for every sitemap body, including
+// cached, archived, and ParseSitemap input, e.g. an xz or lz4 reader for
+// internal tooling that publishes sitemaps in such formats. A codec with the
+// same name (case-insensitive) is replaced, so the built-in "gzip" codec can
+// be swapped for a faster implementation. RegisterCodec is safe for
+// concurrent use but is meant to be called during program initialization.
+func RegisterCodec(codec Codec) error {
+	if strings.TrimSpace(codec.Name) == "" {
+		return errors.New("codec name is empty")
+	}
+	if codec.NewReader == nil {
+		return errors.New("codec NewReader is nil")
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	for i, existing := range codecs {
+		if strings.EqualFold(existing.Name, codec.Name) {
+			codecs[i] = codec
+			return nil
+		}
+	}
+	codecs = append(codecs, codec)
+	return nil
+}
+
+func registeredCodecs() []Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	return append([]Codec(nil), codecs...)
+}
+
+// sniffCodec returns the codec whose magic bytes start r, or nil.
+func sniffCodec(r *bufio.Reader, registered []Codec) *Codec {
+	var longest int
+	for _, codec := range registered {
+		longest = max(longest, len(codec.Magic))
+	}
+	if longest == 0 {
+		return nil
+	}
+	// Peek returns what is available even when the body is shorter.
+	peek, _ := r.Peek(longest)
+	for i, codec := range registered {
+		if len(codec.Magic) > 0 && bytes.HasPrefix(peek, codec.Magic) {
+			return &registered[i]
+		}
+	}
+	return nil
+}
+
+// extensionCodec returns the codec registered for the extension of loc's
+// path, or nil.
+func extensionCodec(loc *url.URL, registered []Codec) *Codec {
+	if loc == nil {
+		return nil
+	}
+	codec, _ := matchExtension(strings.ToLower(loc.Path), registered)
+	return codec
+}
+
+// matchExtension returns the codec whose extension ends path and path
+// without that extension.
+func matchExtension(path string, registered []Codec) (*Codec, string) {
+	for i, codec := range registered {
+		for _, ext := range codec.Extensions {
+			ext = strings.ToLower(ext)
+			if ext != "" && strings.HasSuffix(path, ext) {
+				return &registered[i], strings.TrimSuffix(path, ext)
+			}
+		}
+	}
+	return nil, path
+}
+
+// headerCodec returns the codec named by a Content-Encoding value when it has
+// no magic bytes, so the header is the only way to recognize its bodies.
+func headerCodec(encoding string) *Codec {
+	codec := codecByName(encoding)
+	if codec == nil || len(codec.Magic) > 0 {
+		return nil
+	}
+	return codec
+}
+
+func codecByName(name string) *Codec {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	for _, codec := range registeredCodecs() {
+		if strings.EqualFold(codec.Name, name) {
+			return &codec
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package gositemapfetcher
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// base64Codec stands in for a real decompressor such as xz or lz4.
+func base64Codec(name string, magic []byte, extensions ...string) Codec {
+	return Codec{
+		Name:       name,
+		Magic:      magic,
+		Extensions: extensions,
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			if len(magic) > 0 {
+				if _, err := io.ReadFull(r, make([]byte, len(magic))); err != nil {
+					return nil, err
+				}
+			}
+			return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+		},
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/page</loc></url>
+</urlset>`
+	encoded := base64.StdEncoding.EncodeToString([]byte(sitemap))
+
+	registered := registeredCodecs()
+	t.Cleanup(func() {
+		codecsMu.Lock()
+		defer codecsMu.Unlock()
+		codecs = registered
+	})
+
+	if err := RegisterCodec(Codec{Name: "broken"}); err == nil {
+		t.Fatalf("expected a codec without NewReader to be rejected")
+	}
+	for _, codec := range []Codec{
+		base64Codec("test-magic", []byte("B64:")),
+		base64Codec("test-ext", nil, ".b64"),
+		base64Codec("test-header", nil),
+	} {
+		if err := RegisterCodec(codec); err != nil {
+			t.Fatalf("register %s: %v", codec.Name, err)
+		}
+	}
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/magic.xml":
+			_, _ = w.Write([]byte("B64:" + encoded))
+		case "/sitemap.xml.b64":
+			_, _ = w.Write([]byte(encoded))
+		case "/header.xml":
+			w.Header().Set("Content-Encoding", "test-header")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			_, _ = w.Write([]byte(encoded))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/magic.xml", "/sitemap.xml.b64", "/header.xml"} {
+		sitemapURL, _ := url.Parse(server.URL + path)
+		items, err := collectItems(New(Options{}), sitemapURL)
+		if err != nil {
+			t.Fatalf("%s: walk failed: %v", path, err)
+		}
+		if len(items) != 1 || !strings.HasSuffix(items[0].Loc.String(), "/page") {
+			t.Fatalf("%s: unexpected items: %+v", path, items)
+		}
+	}
+
+	// A header-only codec still applies when the body is replayed from the
+	// cache after a 304 or from an archive.
+	headerURL, _ := url.Parse(server.URL + "/header.xml")
+	var buf bytes.Buffer
+	archive := NewTarArchive(&buf)
+	fetcher := New(Options{IgnoreRobots: true, Cache: NewMemoryCache(), Archive: archive})
+	for i := range 2 {
+		items, err := collectItems(fetcher, headerURL)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("cached walk %d: got %+v (%v)", i, items, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+	source, err := OpenTarArchive(&buf)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	items, err := collectItems(New(Options{IgnoreRobots: true, SitemapSource: source}), headerURL)
+	if err != nil || len(items) != 1 || !strings.HasSuffix(items[0].Loc.String(), "/page") {
+		t.Fatalf("archive replay: got %+v (%v)", items, err)
+	}
+}
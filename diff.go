@@ -0,0 +1,68 @@
+package gositemapfetcher
+
+// ResultDiff lists how the URL set changed between two runs.
+type ResultDiff struct {
+	Added   []Item          // in current only, in current's order
+	Removed []Item          // in previous only, in previous's order
+	Changed []LastModChange // in both with a different lastmod, in current's order
+}
+
+// LastModChange is a URL present in both runs whose lastmod differs.
+type LastModChange struct {
+	Previous Item
+	Current  Item
+}
+
+// Empty reports whether the two runs had the same URLs and lastmods.
+func (d *ResultDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffResultSets compares a previous run, e.g. a snapshot loaded with
+// ReadResultSet, with the current one. URLs are matched without their
+// fragment; when a URL appears several times in a set, its first item counts.
+// A lastmod that appears or disappears counts as a change.
+func DiffResultSets(previous, current *ResultSet) *ResultDiff {
+	before := make(map[string]Item, previous.Len())
+	for _, item := range previous.items {
+		key := canonicalURLKey(item.Loc)
+		if _, ok := before[key]; !ok {
+			before[key] = item
+		}
+	}
+
+	diff := &ResultDiff{}
+	after := make(map[string]struct{}, current.Len())
+	for _, item := range current.items {
+		key := canonicalURLKey(item.Loc)
+		if _, ok := after[key]; ok {
+			continue
+		}
+		after[key] = struct{}{}
+		old, ok := before[key]
+		if !ok {
+			diff.Added = append(diff.Added, item)
+			continue
+		}
+		if !sameLastMod(old, item) {
+			diff.Changed = append(diff.Changed, LastModChange{Previous: old, Current: item})
+		}
+	}
+	for _, item := range previous.items {
+		key := canonicalURLKey(item.Loc)
+		if _, ok := after[key]; ok {
+			continue
+		}
+		// Mark it so duplicates in previous are reported once.
+		after[key] = struct{}{}
+		diff.Removed = append(diff.Removed, item)
+	}
+	return diff
+}
+
+func sameLastMod(a, b Item) bool {
+	if a.LastMod == nil || b.LastMod == nil {
+		return a.LastMod == nil && b.LastMod == nil
+	}
+	return a.LastMod.Equal(*b.LastMod)
+}
@@ -0,0 +1,52 @@
+package gositemapfetcher
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDiffResultSets(t *testing.T) {
+	item := func(loc string, lastMod string) Item {
+		parsed, _ := url.Parse(loc)
+		out := Item{Loc: parsed}
+		if lastMod != "" {
+			value, _ := time.Parse(time.RFC3339, lastMod)
+			out.LastMod = &value
+		}
+		return out
+	}
+
+	previous := NewResultSet([]Item{
+		item("https://example.com/same", "2024-01-01T00:00:00Z"),
+		item("https://example.com/updated", "2024-01-01T00:00:00Z"),
+		item("https://example.com/gone", ""),
+		item("https://example.com/gone", ""),
+		item("https://example.com/dated", ""),
+		item("https://example.com/offset", "2024-01-01T02:00:00+02:00"),
+	})
+	current := NewResultSet([]Item{
+		item("https://example.com/new", ""),
+		item("https://example.com/same#top", "2024-01-01T00:00:00Z"),
+		item("https://example.com/updated", "2024-02-01T00:00:00Z"),
+		item("https://example.com/dated", "2024-03-01T00:00:00Z"),
+		item("https://example.com/offset", "2024-01-01T00:00:00Z"),
+	})
+
+	diff := DiffResultSets(previous, current)
+	if len(diff.Added) != 1 || diff.Added[0].Loc.Path != "/new" {
+		t.Fatalf("unexpected added: %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Loc.Path != "/gone" {
+		t.Fatalf("unexpected removed: %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 2 || diff.Changed[0].Current.Loc.Path != "/updated" || diff.Changed[1].Current.Loc.Path != "/dated" {
+		t.Fatalf("unexpected changed: %+v", diff.Changed)
+	}
+	if diff.Empty() {
+		t.Fatalf("expected a non-empty diff")
+	}
+	if !DiffResultSets(current, current).Empty() {
+		t.Fatalf("expected a set to equal itself")
+	}
+}
@@ -1,8 +1,11 @@
 package gositemapfetcher
 
 import (
+	"context"
+	"encoding/xml"
 	"fmt"
 	"net/url"
+	"time"
 )
 
 // ErrNilYield indicates a nil yield callback was provided.
@@ -55,6 +58,44 @@ func (e *ErrHTTPStatus) Error() string {
 	return fmt.Sprintf("unexpected HTTP status %d for %s", e.StatusCode, e.URL)
 }
 
+// ErrFetch indicates a sitemap request failed in the transport, e.g. a
+// refused connection or TLS error, after Attempt tries (1 without retries).
+type ErrFetch struct {
+	URL     *url.URL
+	Attempt int
+	Err     error
+}
+
+func (e *ErrFetch) Error() string {
+	// *url.Error repeats the method and URL.
+	cause := e.Err
+	if urlErr, ok := cause.(*url.Error); ok {
+		cause = urlErr.Err
+	}
+	if e.Attempt > 1 {
+		return fmt.Sprintf("fetching %s failed after %d attempts: %v", e.URL, e.Attempt, cause)
+	}
+	return fmt.Sprintf("fetching %s failed: %v", e.URL, cause)
+}
+
+func (e *ErrFetch) Unwrap() error {
+	return e.Err
+}
+
+// ErrContentEncoding indicates a sitemap response used a Content-Encoding
+// the fetcher cannot decode.
+type ErrContentEncoding struct {
+	URL      *url.URL
+	Encoding string
+}
+
+func (e *ErrContentEncoding) Error() string {
+	if e.URL == nil {
+		return fmt.Sprintf("unsupported content encoding %q", e.Encoding)
+	}
+	return fmt.Sprintf("unsupported content encoding %q for %s", e.Encoding, e.URL)
+}
+
 // ErrSitemapParse indicates a failure while parsing sitemap XML.
 type ErrSitemapParse struct {
 	URL *url.URL
@@ -72,6 +113,162 @@ func (e *ErrSitemapParse) Unwrap() error {
 	return e.Err
 }
 
+// ErrNotSitemap indicates a document whose root element is not a
+// sitemaps.org <urlset> or <sitemapindex> while Options.RequireNamespace is
+// set, e.g. an RSS feed or an unrelated XML file.
+type ErrNotSitemap struct {
+	URL  *url.URL
+	Root xml.Name
+}
+
+func (e *ErrNotSitemap) Error() string {
+	root := e.Root.Local
+	if e.Root.Space != "" {
+		root = fmt.Sprintf("%s (namespace %q)", e.Root.Local, e.Root.Space)
+	}
+	if e.URL == nil {
+		return fmt.Sprintf("not a sitemap: root element %s", root)
+	}
+	return fmt.Sprintf("%s is not a sitemap: root element %s", e.URL, root)
+}
+
+// ErrSitemapTooLarge indicates a sitemap exceeded the uncompressed size limit.
+type ErrSitemapTooLarge struct {
+	URL   *url.URL
+	Limit int64
+}
+
+func (e *ErrSitemapTooLarge) Error() string {
+	if e.URL == nil {
+		return fmt.Sprintf("sitemap exceeds %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("sitemap %s exceeds %d bytes", e.URL, e.Limit)
+}
+
+// ErrSpecViolations lists sitemaps.org protocol violations found in
+// StrictSpec mode. Violations holds at most the first 1000; Total counts all.
+type ErrSpecViolations struct {
+	Violations []SpecViolation
+	Total      int
+}
+
+func (e *ErrSpecViolations) Error() string {
+	if len(e.Violations) == 0 {
+		return fmt.Sprintf("%d sitemap spec violations", e.Total)
+	}
+	return fmt.Sprintf("%d sitemap spec violations, first: %s", e.Total, e.Violations[0])
+}
+
+// ErrPartial lists the sitemaps that failed during a walk with
+// Options.OnErrorContinue; the others were walked to the end. It unwraps to
+// each failure, so errors.As finds e.g. an *ErrHTTPStatus among them.
+type ErrPartial struct {
+	Failures []SitemapFailure
+}
+
+// SitemapFailure is a sitemap skipped by Options.OnErrorContinue.
+type SitemapFailure struct {
+	URL *url.URL
+	Err error
+}
+
+func (e *ErrPartial) Error() string {
+	if len(e.Failures) == 0 {
+		return "sitemaps failed"
+	}
+	if len(e.Failures) == 1 {
+		return fmt.Sprintf("1 sitemap failed: %v", e.Failures[0].Err)
+	}
+	return fmt.Sprintf("%d sitemaps failed, first: %v", len(e.Failures), e.Failures[0].Err)
+}
+
+func (e *ErrPartial) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// ErrCrossHost indicates a sitemap listed a URL on another host while
+// Options.CrossHostPolicy is CrossHostError.
+type ErrCrossHost struct {
+	Sitemap *url.URL
+	URL     *url.URL
+}
+
+func (e *ErrCrossHost) Error() string {
+	return fmt.Sprintf("cross-host URL %s in sitemap %s", e.URL, e.Sitemap)
+}
+
+// ErrLocalSitemap indicates a file:// sitemap listed by a remote document,
+// e.g. a robots.txt Sitemap line or an http(s) sitemap index. Only walks that
+// start from a local file may read local sitemaps.
+type ErrLocalSitemap struct {
+	URL *url.URL
+	Ref *url.URL // document that listed URL
+}
+
+func (e *ErrLocalSitemap) Error() string {
+	if e.Ref == nil {
+		return fmt.Sprintf("local sitemap %s not allowed in a remote walk", e.URL)
+	}
+	return fmt.Sprintf("local sitemap %s listed by remote document %s", e.URL, e.Ref)
+}
+
+// ErrRobotsUnavailable indicates robots.txt was unreachable while
+// Options.RobotsErrorPolicy is RobotsErrorFail.
+type ErrRobotsUnavailable struct {
+	URL        *url.URL
+	StatusCode int   // 0 when the request failed
+	Err        error // transport error, if any
+}
+
+func (e *ErrRobotsUnavailable) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("robots.txt %s unreachable: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("robots.txt %s unreachable: status %d", e.URL, e.StatusCode)
+}
+
+func (e *ErrRobotsUnavailable) Unwrap() error {
+	return e.Err
+}
+
+// ErrElementTooLarge indicates a single <url> or <sitemap> element exceeded
+// Options.MaxElementBytes.
+type ErrElementTooLarge struct {
+	URL   *url.URL
+	Limit int64
+}
+
+func (e *ErrElementTooLarge) Error() string {
+	if e.URL == nil {
+		return fmt.Sprintf("sitemap element exceeds %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("sitemap element in %s exceeds %d bytes", e.URL, e.Limit)
+}
+
+// Decoder limits reported in ErrXMLLimit.Limit.
+const (
+	XMLLimitTokenBytes = "token size"
+	XMLLimitNesting    = "nesting depth"
+)
+
+// ErrXMLLimit indicates a sitemap exceeded one of the DecoderOptions limits.
+type ErrXMLLimit struct {
+	URL   *url.URL
+	Limit string
+	Value int64
+}
+
+func (e *ErrXMLLimit) Error() string {
+	if e.URL == nil {
+		return fmt.Sprintf("XML %s limit %d exceeded", e.Limit, e.Value)
+	}
+	return fmt.Sprintf("XML %s limit %d exceeded in %s", e.Limit, e.Value, e.URL)
+}
+
 // ErrMaxDepth indicates the sitemap index depth limit was exceeded.
 type ErrMaxDepth struct {
 	MaxDepth int
@@ -103,6 +300,33 @@ func (e *ErrMaxURLs) Error() string {
 	return fmt.Sprintf("max URLs %d exceeded", e.MaxURLs)
 }
 
+// ErrMaxBytes indicates the walk downloaded more than Options.MaxBytes.
+type ErrMaxBytes struct {
+	MaxBytes int64
+	URL      *url.URL // response being read, or request refused, when the budget ran out
+}
+
+func (e *ErrMaxBytes) Error() string {
+	if e.URL == nil {
+		return fmt.Sprintf("max bytes %d exceeded", e.MaxBytes)
+	}
+	return fmt.Sprintf("max bytes %d exceeded at %s", e.MaxBytes, e.URL)
+}
+
+// ErrDeadline indicates the walk ran longer than Options.MaxDuration. It
+// matches context.DeadlineExceeded with errors.Is.
+type ErrDeadline struct {
+	MaxDuration time.Duration
+}
+
+func (e *ErrDeadline) Error() string {
+	return fmt.Sprintf("max duration %s exceeded", e.MaxDuration)
+}
+
+func (e *ErrDeadline) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
 // ErrYield wraps a failure returned by the yield callback.
 type ErrYield struct {
 	Err error
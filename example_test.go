@@ -0,0 +1,71 @@
+package gositemapfetcher_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+// objectStore is the part of an object storage client a sitemap source needs.
+// With the AWS SDK it wraps s3.Client.GetObject; with the GCS client it wraps
+// storage.BucketHandle.Object(key).NewReader.
+type objectStore interface {
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+var errNoSuchKey = errors.New("no such key")
+
+// bucketSource walks sitemaps mirrored into a bucket under "<host><path>"
+// keys, e.g. https://example.com/sitemap.xml => "example.com/sitemap.xml".
+func bucketSource(store objectStore, bucket string) gositemapfetcher.SitemapSource {
+	return gositemapfetcher.SourceFunc(func(ctx context.Context, loc *url.URL) (io.ReadCloser, error) {
+		body, err := store.Get(ctx, bucket, loc.Host+loc.Path)
+		if errors.Is(err, errNoSuchKey) {
+			return nil, &gositemapfetcher.ErrHTTPStatus{URL: loc, StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+		}
+		return body, err
+	})
+}
+
+// memoryStore stands in for an S3 or GCS client.
+type memoryStore map[string]string
+
+func (m memoryStore) Get(_ context.Context, bucket, key string) (io.ReadCloser, error) {
+	body, ok := m[bucket+"/"+key]
+	if !ok {
+		return nil, errNoSuchKey
+	}
+	return io.NopCloser(strings.NewReader(body)), nil
+}
+
+func ExampleSourceFunc_objectStorage() {
+	store := memoryStore{
+		"sitemaps/example.com/sitemap.xml": `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/sitemap-blog.xml</loc></sitemap>
+</sitemapindex>`,
+		"sitemaps/example.com/sitemap-blog.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/blog/hello</loc></url>
+</urlset>`,
+	}
+
+	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+		SitemapSource: bucketSource(store, "sitemaps"),
+		IgnoreRobots:  true, // robots.txt is not mirrored
+	})
+	website, _ := url.Parse("https://example.com/sitemap.xml")
+	err := fetcher.Walk(context.Background(), website, func(item gositemapfetcher.Item) error {
+		fmt.Println(item.Loc)
+		return nil
+	})
+	if err != nil {
+		fmt.Println("walk failed:", err)
+	}
+	// Output:
+	// https://example.com/blog/hello
+}
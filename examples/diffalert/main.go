@@ -0,0 +1,86 @@
+// Command diffalert compares a site's sitemaps with the snapshot left by its
+// previous run and posts a JSON summary to a webhook when URLs changed. Run it
+// from cron:
+//
+//	go run ./examples/diffalert -snapshot site.json -webhook https://hooks.example.com/... https://example.com
+//
+// Without -webhook the summary is printed to stdout.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+type summary struct {
+	Site    string   `json:"site"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Updated []string `json:"updated"`
+}
+
+func main() {
+	snapshot := flag.String("snapshot", "snapshot.json", "snapshot file kept between runs")
+	webhook := flag.String("webhook", "", "URL receiving a JSON POST when something changed")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: diffalert [-snapshot FILE] [-webhook URL] <site or sitemap URL>")
+	}
+	website, err := url.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("invalid URL: %v", err)
+	}
+
+	ctx := context.Background()
+	pipeline := gositemapfetcher.NewDiffPipeline(gositemapfetcher.New(gositemapfetcher.Options{}), website, *snapshot)
+	diff, err := pipeline.Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if diff.Empty() {
+		fmt.Fprintln(os.Stderr, "no changes")
+		return
+	}
+
+	out := summary{Site: website.String(), Added: []string{}, Removed: []string{}, Updated: []string{}}
+	for _, item := range diff.Added {
+		out.Added = append(out.Added, item.Loc.String())
+	}
+	for _, item := range diff.Removed {
+		out.Removed = append(out.Removed, item.Loc.String())
+	}
+	for _, change := range diff.Changed {
+		out.Updated = append(out.Updated, change.Current.Loc.String())
+	}
+	body, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *webhook == "" {
+		fmt.Println(string(body))
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *webhook, bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		log.Fatalf("webhook failed: %s", resp.Status)
+	}
+}
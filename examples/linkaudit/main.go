@@ -0,0 +1,106 @@
+// Command linkaudit walks a site's sitemaps and requests every listed URL,
+// printing those that do not answer with a 2xx status:
+//
+//	go run ./examples/linkaudit -workers 8 https://example.com
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func main() {
+	workers := flag.Int("workers", 4, "concurrent URL checks")
+	timeout := flag.Duration("timeout", 10*time.Second, "per-URL request timeout")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: linkaudit [-workers 4] [-timeout 10s] <site or sitemap URL>")
+	}
+	website, err := url.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("invalid URL: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := &http.Client{Timeout: *timeout}
+	urls := make(chan *url.URL)
+	var broken atomic.Int64
+	var wg sync.WaitGroup
+	for range max(*workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for loc := range urls {
+				if problem := check(ctx, client, loc); problem != "" {
+					broken.Add(1)
+					fmt.Printf("%s\t%s\n", problem, loc)
+				}
+			}
+		}()
+	}
+
+	var checked int
+	err = gositemapfetcher.New(gositemapfetcher.Options{}).Walk(ctx, website, func(item gositemapfetcher.Item) error {
+		checked++
+		select {
+		case urls <- item.Loc:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(urls)
+	wg.Wait()
+
+	fmt.Fprintf(os.Stderr, "checked=%d broken=%d\n", checked, broken.Load())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if broken.Load() > 0 {
+		os.Exit(1)
+	}
+}
+
+// check returns a short description of what is wrong with loc, or "" when it
+// answers with a 2xx status. Servers rejecting HEAD are retried with GET.
+func check(ctx context.Context, client *http.Client, loc *url.URL) string {
+	status, err := request(ctx, client, http.MethodHead, loc)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = request(ctx, client, http.MethodGet, loc)
+	}
+	switch {
+	case err != nil:
+		return "error"
+	case status < 200 || status > 299:
+		return fmt.Sprint(status)
+	default:
+		return ""
+	}
+}
+
+func request(ctx context.Context, client *http.Client, method string, loc *url.URL) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, loc.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", gositemapfetcher.DefaultUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
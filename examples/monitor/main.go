@@ -0,0 +1,44 @@
+// Command monitor watches a site's sitemaps and prints every new, removed, or
+// updated URL as it is detected:
+//
+//	go run ./examples/monitor -interval 10m https://example.com
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func main() {
+	interval := flag.Duration("interval", 15*time.Minute, "time between walks")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: monitor [-interval 15m] <site or sitemap URL>")
+	}
+	website, err := url.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("invalid URL: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	watcher := gositemapfetcher.NewMonitorPipeline(website, gositemapfetcher.Options{}, gositemapfetcher.WatchOptions{
+		Interval: *interval,
+		OnError:  func(err error) { log.Printf("walk failed, keeping previous snapshot: %v", err) },
+	})
+	err = watcher.Run(ctx, func(change gositemapfetcher.Change) {
+		fmt.Printf("%s\t%s\t%s\n", time.Now().Format(time.RFC3339), change.Kind, change.Item.Loc)
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,120 @@
+// Command s3export walks a site's sitemaps and uploads the URLs as gzipped
+// newline-delimited JSON to object storage through a presigned PUT URL, so it
+// needs no cloud SDK or credentials:
+//
+//	url=$(aws s3 presign s3://bucket/urls.ndjson.gz --expires-in 600 ...)
+//	go run ./examples/s3export -put-url "$url" https://example.com
+//
+// Without -put-url the export is written to stdout.
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+type record struct {
+	Loc        string   `json:"loc"`
+	LastMod    string   `json:"lastmod,omitempty"`
+	ChangeFreq string   `json:"changefreq,omitempty"`
+	Priority   *float64 `json:"priority,omitempty"`
+	Sitemap    string   `json:"sitemap,omitempty"`
+}
+
+func main() {
+	putURL := flag.String("put-url", "", "presigned PUT URL receiving the gzipped NDJSON export")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: s3export [-put-url URL] <site or sitemap URL>")
+	}
+	website, err := url.Parse(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("invalid URL: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Object stores want a Content-Length on PUT, so the export is staged in
+	// a temporary file rather than streamed.
+	staging, err := os.CreateTemp("", "s3export-*.ndjson.gz")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(staging.Name())
+	defer staging.Close()
+
+	count, err := export(ctx, website, staging)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := staging.Seek(0, io.SeekStart); err != nil {
+		log.Fatal(err)
+	}
+
+	if *putURL == "" {
+		if _, err := io.Copy(os.Stdout, staging); err != nil {
+			log.Fatal(err)
+		}
+	} else if err := upload(ctx, *putURL, staging); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "exported=%d\n", count)
+}
+
+func export(ctx context.Context, website *url.URL, w io.Writer) (int, error) {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	var count int
+	err := gositemapfetcher.New(gositemapfetcher.Options{}).Walk(ctx, website, func(item gositemapfetcher.Item) error {
+		out := record{Loc: item.Loc.String(), ChangeFreq: string(item.ChangeFreq), Priority: item.Priority}
+		if item.LastMod != nil {
+			out.LastMod = item.LastMod.Format(time.RFC3339)
+		}
+		if item.Sitemap != nil {
+			out.Sitemap = item.Sitemap.String()
+		}
+		count++
+		return enc.Encode(out)
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, gz.Close()
+}
+
+func upload(ctx context.Context, putURL string, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, putURL, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("upload failed: %s: %s", resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package gositemapfetcher
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Extension names a sitemap extension whose data a <url> entry may carry.
+type Extension string
+
+// Supported sitemap extensions.
+const (
+	ExtensionImage Extension = "image"
+	ExtensionVideo Extension = "video"
+	ExtensionNews  Extension = "news"
+)
+
+// Namespaces of the supported sitemap extensions.
+const (
+	ImageNamespace = "http://www.google.com/schemas/sitemap-image/1.1"
+	VideoNamespace = "http://www.google.com/schemas/sitemap-video/1.1"
+	NewsNamespace  = "http://www.google.com/schemas/sitemap-news/0.9"
+)
+
+var extensionNamespaces = map[Extension]string{
+	ExtensionImage: ImageNamespace,
+	ExtensionVideo: VideoNamespace,
+	ExtensionNews:  NewsNamespace,
+}
+
+// xmlExtension is an unrecognized child of a <url> element. Only its name is
+// decoded; the content is skipped.
+type xmlExtension struct {
+	XMLName xml.Name
+}
+
+// ExtensionElement is a child element of <url> the parser does not model,
+// e.g. a PageMap or an <image:image>, captured whole with
+// Options.CaptureExtensions.
+type ExtensionElement struct {
+	// XMLName.Space is the namespace URL, or the prefix when it is undeclared.
+	XMLName  xml.Name
+	Attrs    []xml.Attr         `xml:",any,attr"`
+	Text     string             `xml:",chardata"` // character data directly inside, trimmed
+	Children []ExtensionElement `xml:",any"`
+}
+
+func (e *ExtensionElement) trim() {
+	e.Text = strings.TrimSpace(e.Text)
+	for i := range e.Children {
+		e.Children[i].trim()
+	}
+}
+
+// xmlNamedURLEntry decodes a <url> element like xmlURLEntry plus the names
+// of its extension elements.
+type xmlNamedURLEntry struct {
+	Loc        string         `xml:"loc"`
+	LastMod    string         `xml:"lastmod"`
+	ChangeFreq string         `xml:"changefreq"`
+	Priority   string         `xml:"priority"`
+	Extensions []xmlExtension `xml:",any"`
+}
+
+func (n xmlNamedURLEntry) entry() xmlURLEntry {
+	return xmlURLEntry{
+		Loc:        n.Loc,
+		LastMod:    n.LastMod,
+		ChangeFreq: n.ChangeFreq,
+		Priority:   n.Priority,
+		Extensions: n.Extensions,
+	}
+}
+
+// xmlCapturedURLEntry decodes a <url> element like xmlURLEntry but keeps
+// its extension elements whole.
+type xmlCapturedURLEntry struct {
+	Loc        string             `xml:"loc"`
+	LastMod    string             `xml:"lastmod"`
+	ChangeFreq string             `xml:"changefreq"`
+	Priority   string             `xml:"priority"`
+	Extensions []ExtensionElement `xml:",any"`
+}
+
+func (c xmlCapturedURLEntry) entry() xmlURLEntry {
+	entry := xmlURLEntry{
+		Loc:        c.Loc,
+		LastMod:    c.LastMod,
+		ChangeFreq: c.ChangeFreq,
+		Priority:   c.Priority,
+		Extensions: make([]xmlExtension, 0, len(c.Extensions)),
+		captured:   c.Extensions,
+	}
+	for i := range c.Extensions {
+		c.Extensions[i].trim()
+		entry.Extensions = append(entry.Extensions, xmlExtension{XMLName: c.Extensions[i].XMLName})
+	}
+	return entry
+}
+
+// hasExtension reports whether the entry carries an element of ext, e.g.
+// <image:image>. An undeclared prefix is accepted as well, since the decoder
+// is not strict about namespaces.
+func (e xmlURLEntry) hasExtension(ext Extension) bool {
+	namespace := extensionNamespaces[ext]
+	for _, el := range e.Extensions {
+		if el.XMLName.Local == string(ext) && (el.XMLName.Space == namespace || el.XMLName.Space == string(ext)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyExtension reports whether the entry carries at least one of exts.
+func (e xmlURLEntry) hasAnyExtension(exts []Extension) bool {
+	for _, ext := range exts {
+		if e.hasExtension(ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,11 +3,8 @@ module github.com/enot-style/go-sitemap-fetcher
 go 1.25.5
 
 require (
-	github.com/spf13/cobra v1.10.2
 	github.com/temoto/robotstxt v1.1.2
+	golang.org/x/text v0.40.0
 )
 
-require (
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.9 // indirect
-)
+require github.com/stretchr/testify v1.12.1 // indirect
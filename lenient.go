@@ -0,0 +1,40 @@
+package gositemapfetcher
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// controlStripper drops C0 control characters other than tab, newline, and
+// carriage return, which XML forbids even as character references. The
+// bytes are the same in every ASCII-compatible encoding, so stripping them
+// before charset decoding is safe.
+type controlStripper struct {
+	r io.Reader
+}
+
+func (c *controlStripper) Read(p []byte) (int, error) {
+	for {
+		n, err := c.r.Read(p)
+		kept := 0
+		for _, b := range p[:n] {
+			if b < 0x20 && b != '\t' && b != '\n' && b != '\r' {
+				continue
+			}
+			p[kept] = b
+			kept++
+		}
+		// A chunk of nothing but control characters must not look like EOF.
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// isMalformedXML reports whether err is a syntax error in the document
+// itself, e.g. a truncated tail, rather than a limit or a read failure.
+func isMalformedXML(err error) bool {
+	var syntaxErr *xml.SyntaxError
+	return errors.As(err, &syntaxErr)
+}
@@ -0,0 +1,40 @@
+// Package attrs flattens slog attributes for the logger adapters.
+package attrs
+
+import "log/slog"
+
+// Walk calls fn for every leaf of attrs following the slog.Handler rules:
+// values are resolved, empty attributes and empty groups are dropped, groups
+// without a key are inlined, and group names are joined to keys with dots.
+// prefix is the dot-terminated group path opened with WithGroup, if any.
+func Walk(prefix string, attrs []slog.Attr, fn func(key string, value any)) {
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		if attr.Equal(slog.Attr{}) {
+			continue
+		}
+		if attr.Value.Kind() == slog.KindGroup {
+			group := attr.Value.Group()
+			if len(group) == 0 {
+				continue
+			}
+			if attr.Key == "" {
+				Walk(prefix, group, fn)
+				continue
+			}
+			Walk(prefix+attr.Key+".", group, fn)
+			continue
+		}
+		fn(prefix+attr.Key, attr.Value.Any())
+	}
+}
+
+// Record returns the attributes attached to r.
+func Record(r slog.Record) []slog.Attr {
+	out := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		out = append(out, attr)
+		return true
+	})
+	return out
+}
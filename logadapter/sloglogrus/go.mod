@@ -0,0 +1,12 @@
+module github.com/enot-style/go-sitemap-fetcher/logadapter/sloglogrus
+
+go 1.25.5
+
+require (
+	github.com/enot-style/go-sitemap-fetcher v0.0.0
+	github.com/sirupsen/logrus v1.10.2
+)
+
+require golang.org/x/sys v0.29.0 // indirect
+
+replace github.com/enot-style/go-sitemap-fetcher => ../..
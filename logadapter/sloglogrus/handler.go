@@ -0,0 +1,82 @@
+// Package sloglogrus routes gositemapfetcher logs to a logrus logger:
+//
+//	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+//		Logger: sloglogrus.NewLogger(logrus.StandardLogger()),
+//	})
+package sloglogrus
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/enot-style/go-sitemap-fetcher/logadapter/internal/attrs"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler is a slog.Handler writing to a logrus logger. Attributes become
+// logrus fields and groups are flattened into dotted keys. It is safe for
+// concurrent use.
+type Handler struct {
+	entry  *logrus.Entry
+	prefix string
+}
+
+// NewHandler returns a Handler writing to logger. Levels are taken from the
+// logger.
+func NewHandler(logger *logrus.Logger) *Handler {
+	return &Handler{entry: logrus.NewEntry(logger)}
+}
+
+// NewLogger returns a slog.Logger writing to logger, ready for Options.Logger.
+func NewLogger(logger *logrus.Logger) *slog.Logger {
+	return slog.New(NewHandler(logger))
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.entry.Logger.IsLevelEnabled(logrusLevel(level))
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	entry := h.entry.WithFields(fields(h.prefix, attrs.Record(record)))
+	if !record.Time.IsZero() {
+		entry = entry.WithTime(record.Time)
+	}
+	entry.Log(logrusLevel(record.Level), record.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(list []slog.Attr) slog.Handler {
+	return &Handler{entry: h.entry.WithFields(fields(h.prefix, list)), prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{entry: h.entry, prefix: h.prefix + name + "."}
+}
+
+func fields(prefix string, list []slog.Attr) logrus.Fields {
+	out := make(logrus.Fields, len(list))
+	attrs.Walk(prefix, list, func(key string, value any) {
+		out[key] = value
+	})
+	return out
+}
+
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	default:
+		return logrus.DebugLevel
+	}
+}
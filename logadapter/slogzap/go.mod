@@ -0,0 +1,12 @@
+module github.com/enot-style/go-sitemap-fetcher/logadapter/slogzap
+
+go 1.25.5
+
+require (
+	github.com/enot-style/go-sitemap-fetcher v0.0.0
+	go.uber.org/zap v1.28.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace github.com/enot-style/go-sitemap-fetcher => ../..
@@ -0,0 +1,86 @@
+// Package slogzap routes gositemapfetcher logs to a zap.Logger:
+//
+//	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+//		Logger: slogzap.NewLogger(zapLogger),
+//	})
+package slogzap
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/enot-style/go-sitemap-fetcher/logadapter/internal/attrs"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Handler is a slog.Handler writing to a zap.Logger. Attributes become zap
+// fields and groups are flattened into dotted keys. It is safe for
+// concurrent use.
+type Handler struct {
+	logger *zap.Logger
+	prefix string
+}
+
+// NewHandler returns a Handler writing to logger. Levels are taken from the
+// logger's core.
+func NewHandler(logger *zap.Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+// NewLogger returns a slog.Logger writing to logger, ready for Options.Logger.
+func NewLogger(logger *zap.Logger) *slog.Logger {
+	return slog.New(NewHandler(logger))
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(zapLevel(level))
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	entry := h.logger.Check(zapLevel(record.Level), record.Message)
+	if entry == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		entry.Time = record.Time
+	}
+	entry.Write(fields(h.prefix, attrs.Record(record))...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(list []slog.Attr) slog.Handler {
+	return &Handler{logger: h.logger.With(fields(h.prefix, list)...), prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+func fields(prefix string, list []slog.Attr) []zap.Field {
+	out := make([]zap.Field, 0, len(list))
+	attrs.Walk(prefix, list, func(key string, value any) {
+		out = append(out, zap.Any(key, value))
+	})
+	return out
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
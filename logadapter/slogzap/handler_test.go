@@ -0,0 +1,31 @@
+package slogzap
+
+import (
+	"log/slog"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewLogger(zap.New(core)).With("walk", "w1").WithGroup("sitemap")
+
+	logger.Debug("dropped")
+	logger.Info("fetched", "url", "https://example.com/sitemap.xml", slog.Group("stats", "urls", 3))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Message != "fetched" || entry.Level != zapcore.InfoLevel {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	fields := entry.ContextMap()
+	if fields["walk"] != "w1" || fields["sitemap.url"] != "https://example.com/sitemap.xml" || fields["sitemap.stats.urls"] != int64(3) {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
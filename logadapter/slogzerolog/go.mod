@@ -0,0 +1,16 @@
+module github.com/enot-style/go-sitemap-fetcher/logadapter/slogzerolog
+
+go 1.25.5
+
+require (
+	github.com/enot-style/go-sitemap-fetcher v0.0.0
+	github.com/rs/zerolog v1.35.1
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+)
+
+replace github.com/enot-style/go-sitemap-fetcher => ../..
@@ -0,0 +1,85 @@
+// Package slogzerolog routes gositemapfetcher logs to a zerolog.Logger:
+//
+//	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+//		Logger: slogzerolog.NewLogger(zerologLogger),
+//	})
+package slogzerolog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/enot-style/go-sitemap-fetcher/logadapter/internal/attrs"
+	"github.com/rs/zerolog"
+)
+
+// Handler is a slog.Handler writing to a zerolog.Logger. Attributes become
+// zerolog fields and groups are flattened into dotted keys. It is safe for
+// concurrent use as long as the logger's writer is.
+type Handler struct {
+	logger zerolog.Logger
+	prefix string
+}
+
+// NewHandler returns a Handler writing to logger. Levels are taken from the
+// logger and zerolog's global level; timestamps follow the logger's own
+// configuration, e.g. With().Timestamp().
+func NewHandler(logger zerolog.Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+// NewLogger returns a slog.Logger writing to logger, ready for Options.Logger.
+func NewLogger(logger zerolog.Logger) *slog.Logger {
+	return slog.New(NewHandler(logger))
+}
+
+// Enabled implements slog.Handler.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	lvl := zerologLevel(level)
+	return lvl >= h.logger.GetLevel() && lvl >= zerolog.GlobalLevel()
+}
+
+// Handle implements slog.Handler.
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	event := h.logger.WithLevel(zerologLevel(record.Level))
+	if event == nil {
+		return nil
+	}
+	event.Fields(fields(h.prefix, attrs.Record(record))).Msg(record.Message)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *Handler) WithAttrs(list []slog.Attr) slog.Handler {
+	return &Handler{logger: h.logger.With().Fields(fields(h.prefix, list)).Logger(), prefix: h.prefix}
+}
+
+// WithGroup implements slog.Handler.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger, prefix: h.prefix + name + "."}
+}
+
+// fields returns key/value pairs, which keep attribute order unlike a map.
+func fields(prefix string, list []slog.Attr) []any {
+	out := make([]any, 0, 2*len(list))
+	attrs.Walk(prefix, list, func(key string, value any) {
+		out = append(out, key, value)
+	})
+	return out
+}
+
+func zerologLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zerolog.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zerolog.WarnLevel
+	case level >= slog.LevelInfo:
+		return zerolog.InfoLevel
+	default:
+		return zerolog.DebugLevel
+	}
+}
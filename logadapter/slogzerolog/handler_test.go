@@ -0,0 +1,29 @@
+package slogzerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(zerolog.New(&buf).Level(zerolog.InfoLevel)).With("walk", "w1").WithGroup("sitemap")
+
+	logger.Debug("dropped")
+	logger.Info("fetched", "url", "https://example.com/sitemap.xml", slog.Group("stats", "urls", 3))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON entry, got %q: %v", buf.String(), err)
+	}
+	if entry["message"] != "fetched" || entry["level"] != "info" {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+	if entry["walk"] != "w1" || entry["sitemap.url"] != "https://example.com/sitemap.xml" || entry["sitemap.stats.urls"] != float64(3) {
+		t.Fatalf("unexpected fields: %v", entry)
+	}
+}
@@ -0,0 +1,68 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/url"
+)
+
+// logURL logs a URL as its string form. The conversion is deferred until a
+// handler actually emits the record, and keeps JSON handlers from encoding
+// the url.URL struct field by field.
+type logURL struct {
+	u *url.URL
+}
+
+func (l logURL) LogValue() slog.Value {
+	if l.u == nil {
+		return slog.StringValue("")
+	}
+	return slog.StringValue(l.u.String())
+}
+
+// urlAttr returns a log attribute for u.
+func urlAttr(key string, u *url.URL) slog.Attr {
+	return slog.Any(key, logURL{u: u})
+}
+
+type walkLoggerKey struct{}
+
+// newWalkID returns a random ID that tells the log records of concurrent
+// walks apart.
+func newWalkID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// withWalkLogger returns ctx carrying the logger for one walk: Options.Logger
+// with a walk_id attribute.
+func (f *SitemapFetcher) withWalkLogger(ctx context.Context) context.Context {
+	return context.WithValue(ctx, walkLoggerKey{}, f.logger.With(slog.String("walk_id", newWalkID())))
+}
+
+// log returns the walk's logger from ctx, or Options.Logger outside a walk,
+// e.g. in ParseSitemap.
+func (f *SitemapFetcher) log(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(walkLoggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return f.logger
+}
+
+// debugEnabled reports whether debug records are logged at all. Per-entry
+// call sites check it first so their attributes are never built otherwise.
+func (f *SitemapFetcher) debugEnabled(ctx context.Context) bool {
+	return f.log(ctx).Enabled(ctx, slog.LevelDebug)
+}
+
+// debug logs msg with attrs at debug level on the walk's logger.
+func (f *SitemapFetcher) debug(ctx context.Context, msg string, attrs ...slog.Attr) {
+	logger := f.log(ctx)
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, msg, attrs...)
+}
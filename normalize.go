@@ -0,0 +1,85 @@
+package gositemapfetcher
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeOptions canonicalizes yielded URLs, e.g. for crawl frontiers that
+// key on the URL string. The zero value leaves URLs unchanged.
+type NormalizeOptions struct {
+	LowercaseHost      bool     // lowercase the scheme and host
+	StripDefaultPort   bool     // drop :80 for http and :443 for https
+	StripTrailingSlash bool     // "/a/" => "/a"; the root path "/" is kept
+	DropQueryParams    []string // parameter names to remove; a trailing "*" matches a prefix, e.g. "utm_*"
+}
+
+func (n NormalizeOptions) enabled() bool {
+	return n.LowercaseHost || n.StripDefaultPort || n.StripTrailingSlash || len(n.DropQueryParams) > 0
+}
+
+// Apply returns a normalized copy of u. The remaining query parameters keep
+// their order and encoding.
+func (n NormalizeOptions) Apply(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	out := cloneURL(u)
+	if n.LowercaseHost {
+		out.Scheme = strings.ToLower(out.Scheme)
+		out.Host = strings.ToLower(out.Host)
+	}
+	if n.StripDefaultPort {
+		port := out.Port()
+		if (port == "80" && strings.EqualFold(out.Scheme, "http")) || (port == "443" && strings.EqualFold(out.Scheme, "https")) {
+			out.Host = strings.TrimSuffix(out.Host, ":"+port)
+		}
+	}
+	if n.StripTrailingSlash && len(out.Path) > 1 && strings.HasSuffix(out.Path, "/") {
+		out.Path = strings.TrimRight(out.Path, "/")
+		if out.Path == "" {
+			out.Path = "/"
+		}
+		out.RawPath = ""
+	}
+	if len(n.DropQueryParams) > 0 && out.RawQuery != "" {
+		out.RawQuery = n.dropQueryParams(out.RawQuery)
+		if out.RawQuery == "" {
+			out.ForceQuery = false
+		}
+	}
+	return out
+}
+
+func (n NormalizeOptions) dropQueryParams(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !n.dropsParam(name) {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+func (n NormalizeOptions) dropsParam(name string) bool {
+	for _, pattern := range n.DropQueryParams {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+			continue
+		}
+		if name == pattern {
+			return true
+		}
+	}
+	return false
+}
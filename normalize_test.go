@@ -0,0 +1,73 @@
+package gositemapfetcher
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNormalizeOptions_Apply(t *testing.T) {
+	opts := NormalizeOptions{
+		LowercaseHost:      true,
+		StripDefaultPort:   true,
+		StripTrailingSlash: true,
+		DropQueryParams:    []string{"utm_*", "ref"},
+	}
+	cases := map[string]string{
+		"HTTPS://Example.COM:443/Blog/?utm_source=x&b=2&ref=home&a=1": "https://example.com/Blog?b=2&a=1",
+		"http://example.com:80/":                    "http://example.com/",
+		"http://example.com:8080/a//":               "http://example.com:8080/a",
+		"https://example.com:80/a?utm_medium=y":     "https://example.com:80/a",
+		"https://example.com/a?reference=1&q=a%20b": "https://example.com/a?reference=1&q=a%20b",
+	}
+	for raw, want := range cases {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("parse %q: %v", raw, err)
+		}
+		before := u.String()
+		if got := opts.Apply(u).String(); got != want {
+			t.Fatalf("Apply(%q) = %q, want %q", raw, got, want)
+		}
+		if u.String() != before {
+			t.Fatalf("Apply modified its input %q", raw)
+		}
+	}
+	if got := (NormalizeOptions{}).Apply(mustParseURL(t, "HTTP://Example.com:80/a/")).String(); got != "http://Example.com:80/a/" {
+		t.Fatalf("zero value changed the URL: %q", got)
+	}
+}
+
+func TestSitemapFetcher_Normalize(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/page/?utm_campaign=spring&amp;id=7</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{Normalize: NormalizeOptions{StripTrailingSlash: true, DropQueryParams: []string{"utm_*"}}})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL+"/sitemap.xml"))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Loc.Path != "/page" || items[0].Loc.RawQuery != "id=7" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	return u
+}
@@ -0,0 +1,17 @@
+module github.com/enot-style/go-sitemap-fetcher/oteltracer
+
+go 1.25.5
+
+require (
+	github.com/enot-style/go-sitemap-fetcher v0.0.0
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/temoto/robotstxt v1.1.2 // indirect
+	golang.org/x/text v0.40.0 // indirect
+)
+
+replace github.com/enot-style/go-sitemap-fetcher => ..
@@ -0,0 +1,77 @@
+// Package oteltracer records gositemapfetcher spans with OpenTelemetry:
+//
+//	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+//		Tracer: oteltracer.NewTracer(otel.GetTracerProvider()),
+//	})
+package oteltracer
+
+import (
+	"context"
+	"log/slog"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created through this package.
+const tracerName = "github.com/enot-style/go-sitemap-fetcher"
+
+var _ gositemapfetcher.Tracer = (*Tracer)(nil)
+
+// Tracer is a gositemapfetcher.Tracer starting OpenTelemetry client spans.
+// Spans are stored in the returned context the OpenTelemetry way, so an
+// instrumented HTTP transport nests beneath them.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns a Tracer creating spans with provider.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(tracerName)}
+}
+
+// Start implements gositemapfetcher.Tracer.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, gositemapfetcher.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(keyValues(attrs)...))
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttributes(attrs ...slog.Attr) {
+	s.span.SetAttributes(keyValues(attrs)...)
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// keyValues converts slog attributes to OpenTelemetry ones; kinds without
+// an OpenTelemetry counterpart are recorded as strings.
+func keyValues(attrs []slog.Attr) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		switch value.Kind() {
+		case slog.KindString:
+			kvs = append(kvs, attribute.String(attr.Key, value.String()))
+		case slog.KindInt64:
+			kvs = append(kvs, attribute.Int64(attr.Key, value.Int64()))
+		case slog.KindBool:
+			kvs = append(kvs, attribute.Bool(attr.Key, value.Bool()))
+		case slog.KindFloat64:
+			kvs = append(kvs, attribute.Float64(attr.Key, value.Float64()))
+		default:
+			kvs = append(kvs, attribute.String(attr.Key, value.String()))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,115 @@
+package oteltracer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordingSpan struct {
+	noop.Span
+	name   string
+	parent trace.Span
+	attrs  map[attribute.Key]attribute.Value
+	err    error
+	ended  bool
+}
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error, _ ...trace.EventOption) {
+	s.err = err
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) {
+	s.ended = true
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	spans *[]*recordingSpan
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{name: name, parent: trace.SpanFromContext(ctx), attrs: map[attribute.Key]attribute.Value{}}
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+	*t.spans = append(*t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+type recordingTracerProvider struct {
+	noop.TracerProvider
+	spans *[]*recordingSpan
+}
+
+func (p recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{spans: p.spans}
+}
+
+func TestTracer(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <url><loc>/b</loc></url>
+</urlset>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\n"))
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var spans []*recordingSpan
+	parent := &recordingSpan{name: "crawl", attrs: map[attribute.Key]attribute.Value{}}
+	ctx := trace.ContextWithSpan(context.Background(), parent)
+	fetcher := gositemapfetcher.New(gositemapfetcher.Options{Tracer: NewTracer(recordingTracerProvider{spans: &spans})})
+
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+	if err := fetcher.Walk(ctx, sitemapURL, func(gositemapfetcher.Item) error { return nil }); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	byName := map[string]*recordingSpan{}
+	for _, span := range spans {
+		byName[span.name] = span
+	}
+	sitemapSpan, robotsSpan := byName[gositemapfetcher.SpanSitemapFetch], byName[gositemapfetcher.SpanRobotsFetch]
+	if sitemapSpan == nil || robotsSpan == nil {
+		t.Fatalf("expected sitemap and robots spans, got %d spans", len(spans))
+	}
+	if sitemapSpan.parent != parent || !sitemapSpan.ended || sitemapSpan.err != nil {
+		t.Fatalf("unexpected sitemap span: %+v", sitemapSpan)
+	}
+	if got := sitemapSpan.attrs["url.full"].AsString(); got != sitemapURL.String() {
+		t.Fatalf("expected url.full %s, got %s", sitemapURL, got)
+	}
+	if sitemapSpan.attrs["http.response.status_code"].AsInt64() != http.StatusOK ||
+		sitemapSpan.attrs[gositemapfetcher.AttrSitemapURLs].AsInt64() != 2 ||
+		sitemapSpan.attrs[gositemapfetcher.AttrSitemapBytes].AsInt64() != int64(len(sitemap)) {
+		t.Fatalf("unexpected sitemap span attributes: %v", sitemapSpan.attrs)
+	}
+	if !robotsSpan.ended || !robotsSpan.attrs[gositemapfetcher.AttrRobotsFound].AsBool() {
+		t.Fatalf("unexpected robots span: %+v", robotsSpan)
+	}
+	if len(parent.attrs) != 0 {
+		t.Fatalf("expected the caller's span to be left untouched, got %v", parent.attrs)
+	}
+}
@@ -0,0 +1,93 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+)
+
+// IndexEntry is a child sitemap listed in a sitemap index.
+type IndexEntry struct {
+	Loc     *url.URL
+	LastMod *time.Time
+}
+
+// ParseSitemap parses an already fetched <urlset> document, e.g. bytes from a
+// message queue or a test fixture, and yields its URLs. The reader may be
+// gzip-compressed. Relative locations resolve against baseURL, which may be
+// nil when every loc is absolute; baseURL is also reported as Item.Sitemap.
+// <sitemap> index entries are ignored; use ParseIndex for those.
+//
+// The default size limits of Options apply. Invalid locations are skipped;
+// other failures are returned as the same typed errors Walk uses.
+func ParseSitemap(ctx context.Context, r io.Reader, baseURL *url.URL, yield func(Item) error) error {
+	if yield == nil {
+		return &ErrNilYield{}
+	}
+	f := New(Options{})
+	parser := sitemapParser{maxElementBytes: f.opts.MaxElementBytes, decoder: f.opts.Decoder}
+	parser.onURL = func(entry xmlURLEntry) error {
+		loc, err := resolveLocation(baseURL, entry.Loc)
+		if err != nil {
+			return nil
+		}
+		lastMod, lastModOffset := f.lastMod(entry.LastMod)
+		item := Item{
+			Loc:           loc,
+			LastMod:       lastMod,
+			LastModOffset: lastModOffset,
+			ChangeFreq:    ParseChangeFreq(entry.ChangeFreq),
+			Priority:      f.priority(ctx, entry.Priority, loc),
+			Sitemap:       cloneURL(baseURL),
+		}
+		item.RawLastMod, item.RawPriority = rawMetadata(entry, item.LastMod, item.Priority)
+		if err := yield(item); err != nil {
+			return &ErrYield{Err: err}
+		}
+		return nil
+	}
+	return f.parseDocument(ctx, r, baseURL, parser)
+}
+
+// ParseIndex parses an already fetched <sitemapindex> document and yields its
+// child sitemaps without fetching them. It follows the same rules as
+// ParseSitemap; <url> entries are ignored.
+func ParseIndex(ctx context.Context, r io.Reader, baseURL *url.URL, yield func(IndexEntry) error) error {
+	if yield == nil {
+		return &ErrNilYield{}
+	}
+	f := New(Options{})
+	parser := sitemapParser{maxElementBytes: f.opts.MaxElementBytes, decoder: f.opts.Decoder}
+	parser.onSitemap = func(entry xmlSitemapEntry) error {
+		loc, err := resolveLocation(baseURL, entry.Loc)
+		if err != nil {
+			return nil
+		}
+		lastMod, _ := f.lastMod(entry.LastMod)
+		if err := yield(IndexEntry{Loc: loc, LastMod: lastMod}); err != nil {
+			return &ErrYield{Err: err}
+		}
+		return nil
+	}
+	return f.parseDocument(ctx, r, baseURL, parser)
+}
+
+func (f *SitemapFetcher) parseDocument(ctx context.Context, r io.Reader, baseURL *url.URL, parser sitemapParser) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if r == nil {
+		return &ErrSitemapParse{URL: baseURL, Err: errors.New("nil reader")}
+	}
+	reader, err := wrapReader(io.NopCloser(r), baseURL, "", nil)
+	if err != nil {
+		return &ErrSitemapParse{URL: baseURL, Err: err}
+	}
+	defer reader.Close()
+	if err := parser.parse(ctx, f.limitSitemap(reader, baseURL)); err != nil {
+		return parseFailure(baseURL, err)
+	}
+	return nil
+}
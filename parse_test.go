@@ -0,0 +1,92 @@
+package gositemapfetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseSitemap(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc><lastmod>2024-01-02</lastmod><priority>0.8</priority></url>
+  <url><loc>https://other.example/b</loc></url>
+</urlset>`
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte(sitemap))
+	_ = gzipWriter.Close()
+
+	base, _ := url.Parse("https://example.com/sitemap.xml")
+	var items []Item
+	err := ParseSitemap(context.Background(), &gzipped, base, func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].Loc.String() != "https://example.com/a" || items[0].LastMod == nil || items[0].Priority == nil {
+		t.Fatalf("unexpected first item: %+v", items[0])
+	}
+	if items[0].Sitemap.String() != base.String() {
+		t.Fatalf("expected sitemap %s, got %v", base, items[0].Sitemap)
+	}
+
+	items = nil
+	err = ParseSitemap(context.Background(), strings.NewReader(sitemap), nil, func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil || len(items) != 1 || items[0].Loc.Host != "other.example" {
+		t.Fatalf("expected only the absolute loc without a base URL, got %d (%v)", len(items), err)
+	}
+
+	stop := errors.New("stop")
+	err = ParseSitemap(context.Background(), strings.NewReader(sitemap), base, func(Item) error { return stop })
+	var yieldErr *ErrYield
+	if !errors.As(err, &yieldErr) || !errors.Is(err, stop) {
+		t.Fatalf("expected ErrYield, got %v", err)
+	}
+
+	err = ParseSitemap(context.Background(), strings.NewReader("<urlset><url>"), base, func(Item) error { return nil })
+	var parseErr *ErrSitemapParse
+	if !errors.As(err, &parseErr) || parseErr.URL != base {
+		t.Fatalf("expected ErrSitemapParse, got %v", err)
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-1.xml</loc><lastmod>2024-01-02T03:04:05Z</lastmod></sitemap>
+  <sitemap><loc>/sitemap-2.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+	base, _ := url.Parse("https://example.com/sitemap_index.xml")
+	var entries []IndexEntry
+	err := ParseIndex(context.Background(), strings.NewReader(index), base, func(entry IndexEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Loc.String() != "https://example.com/sitemap-1.xml" || entries[0].LastMod == nil {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1].Loc.String() != "https://example.com/sitemap-2.xml.gz" || entries[1].LastMod != nil {
+		t.Fatalf("unexpected second entry: %+v", entries[1])
+	}
+}
@@ -0,0 +1,61 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+)
+
+// NewMonitorPipeline returns a Watcher over website with a fetcher tuned for
+// repeated walks: a MemoryCache for conditional requests unless opts.Cache is
+// set. The other options are used as given; set SkipDuplicateSitemaps as well
+// for sites serving one sitemap under several URLs. Start it with Run or
+// Changes.
+func NewMonitorPipeline(website *url.URL, opts Options, watch WatchOptions) *Watcher {
+	if opts.Cache == nil {
+		opts.Cache = NewMemoryCache()
+	}
+	return NewWatcher(New(opts), website, watch)
+}
+
+// DiffPipeline compares each walk with a snapshot file left by the previous
+// run and replaces it, e.g. for a cron job that alerts on sitemap changes.
+type DiffPipeline struct {
+	walker       SitemapWalker
+	website      *url.URL
+	snapshotPath string
+}
+
+// NewDiffPipeline returns a DiffPipeline walking website with walker and
+// keeping its snapshot at snapshotPath, in the WriteJSON format.
+func NewDiffPipeline(walker SitemapWalker, website *url.URL, snapshotPath string) *DiffPipeline {
+	return &DiffPipeline{walker: walker, website: cloneURL(website), snapshotPath: snapshotPath}
+}
+
+// Run walks the site and returns its changes since the stored snapshot. A
+// missing snapshot counts as empty, so the first run reports every URL as
+// added. The snapshot is only replaced after a successful walk.
+func (p *DiffPipeline) Run(ctx context.Context) (*ResultDiff, error) {
+	previous := NewResultSet(nil)
+	file, err := os.Open(p.snapshotPath)
+	switch {
+	case err == nil:
+		previous, err = ReadResultSet(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	current, err := CollectResultSet(ctx, p.walker, p.website)
+	if err != nil {
+		return nil, err
+	}
+	if err := current.WriteFile(p.snapshotPath); err != nil {
+		return nil, err
+	}
+	return DiffResultSets(previous, current), nil
+}
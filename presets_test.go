@@ -0,0 +1,62 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDiffPipeline(t *testing.T) {
+	var walks atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if walks.Add(1) == 1 {
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/a</loc></url></urlset>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/a</loc></url><url><loc>/b</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+	pipeline := NewDiffPipeline(New(Options{}), sitemapURL, filepath.Join(t.TempDir(), "snapshot.json"))
+
+	diff, err := pipeline.Run(context.Background())
+	if err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if len(diff.Added) != 1 || len(diff.Removed) != 0 {
+		t.Fatalf("expected every URL to be new on the first run, got %+v", diff)
+	}
+
+	diff, err = pipeline.Run(context.Background())
+	if err != nil {
+		t.Fatalf("second run failed: %v", err)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Loc.Path != "/b" || len(diff.Removed) != 0 || len(diff.Changed) != 0 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+}
+
+func TestNewMonitorPipeline(t *testing.T) {
+	options := func(w *Watcher) Options {
+		return w.walker.(*SitemapFetcher).opts
+	}
+	site, _ := url.Parse("https://example.com")
+
+	watcher := NewMonitorPipeline(site, Options{}, WatchOptions{})
+	if opts := options(watcher); opts.Cache == nil || opts.SkipDuplicateSitemaps {
+		t.Fatalf("expected a default cache and the caller's SkipDuplicateSitemaps, got %+v", opts)
+	}
+	cache := NewMemoryCache()
+	watcher = NewMonitorPipeline(site, Options{Cache: cache, SkipDuplicateSitemaps: true}, WatchOptions{})
+	if opts := options(watcher); opts.Cache != Cache(cache) || !opts.SkipDuplicateSitemaps {
+		t.Fatalf("expected the caller's options to be kept, got %+v", opts)
+	}
+}
@@ -0,0 +1,98 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+type probeOutcome int
+
+const (
+	// probeUnknown leaves the candidate to the regular GET, e.g. when the
+	// server rejects HEAD or the request failed.
+	probeUnknown probeOutcome = iota
+	probeMiss
+	probeHit
+)
+
+// probeCandidates sends HEAD requests for the default sitemap candidates
+// concurrently and keeps the first candidate that exists, plus any earlier
+// ones HEAD could not settle, which are then fetched with GET as usual. The
+// remaining requests are cancelled as soon as the result is known.
+// Candidates disallowed by robots.txt are kept so the walk reports them.
+// HEAD requests are neither retried nor cached: a failure or a retryable
+// status leaves the candidate to the GET, which is.
+func (f *SitemapFetcher) probeCandidates(ctx context.Context, tasks []sitemapTask, robotsCache map[string]*robotsRules) []sitemapTask {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		index  int
+		result probeOutcome
+	}
+	outcomes := make(chan outcome, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		if f.checkRobots(RobotsScopeSitemaps) {
+			if allowed, err := f.allowedByRobots(ctx, task.loc, robotsCache); err != nil || !allowed {
+				outcomes <- outcome{index: i, result: probeUnknown}
+				continue
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcomes <- outcome{index: i, result: f.probe(ctx, task)}
+		}()
+	}
+
+	results := make([]probeOutcome, len(tasks))
+	done := make([]bool, len(tasks))
+	kept := tasks[:0:0]
+	for settled := 0; settled < len(tasks); {
+		o := <-outcomes
+		results[o.index] = o.result
+		done[o.index] = true
+		// Decide once every candidate up to the first hit is known.
+		for settled < len(tasks) && done[settled] {
+			if results[settled] != probeMiss {
+				kept = append(kept, tasks[settled])
+			}
+			if results[settled] == probeHit {
+				cancel()
+				wg.Wait()
+				return kept
+			}
+			settled++
+		}
+	}
+	wg.Wait()
+	return kept
+}
+
+func (f *SitemapFetcher) probe(ctx context.Context, task sitemapTask) probeOutcome {
+	req, cancel, err := f.newRequest(ctx, http.MethodHead, task.loc)
+	if err != nil {
+		return probeUnknown
+	}
+	defer cancel()
+	resp, err := f.do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			f.debug(ctx, "probe failed", urlAttr("url", task.loc), slog.Any("error", err))
+		}
+		return probeUnknown
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return probeHit
+	case resp.StatusCode == http.StatusNotFound:
+		f.debug(ctx, "sitemap not found (probe)", urlAttr("url", task.loc))
+		return probeMiss
+	default:
+		return probeUnknown
+	}
+}
@@ -0,0 +1,230 @@
+package gositemapfetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder dumps HTTP responses into a directory so a walk can be replayed
+// offline with ReplayTransport, e.g. to reproduce a parsing bug reported
+// against a site that has changed since. Each response becomes a
+// NNNNNN.body file holding the bytes exactly as received, plus a
+// NNNNNN.meta.json file with its ArchiveMeta.
+type Recorder struct {
+	dir   string
+	mu    sync.Mutex
+	count int
+}
+
+// NewRecorder returns a Recorder writing to dir, creating it if needed. A
+// recording already in dir is appended to, so replaying it serves the
+// latest capture of each URL.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	recorder := &Recorder{dir: dir}
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), ".")
+		if n, err := strconv.Atoi(prefix); err == nil && n > recorder.count {
+			recorder.count = n
+		}
+	}
+	return recorder, nil
+}
+
+// Wrap returns a RoundTripper recording every response from next, so it can
+// be used as Options.WrapTransport to capture robots.txt, probe, and sitemap
+// requests alike. Bodies are recorded once they have been read to the end;
+// bodies closed early, e.g. when the walk stopped, and requests that fail at
+// the network level are not recorded.
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	return &recordingTransport{recorder: r, next: next}
+}
+
+// Begin implements Archiver, so a Recorder can also be used as
+// Options.Archive to record sitemap bodies only.
+func (r *Recorder) Begin(meta ArchiveMeta) (ArchiveWriter, error) {
+	r.mu.Lock()
+	r.count++
+	prefix := fmt.Sprintf("%06d", r.count)
+	r.mu.Unlock()
+
+	file, err := os.CreateTemp(r.dir, prefix+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	meta.Body = prefix + ".body"
+	return &recorderWriter{dir: r.dir, prefix: prefix, meta: meta, file: file, hash: sha256.New()}, nil
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	method := req.Method
+	if method == http.MethodGet {
+		method = ""
+	}
+	writer, err := t.recorder.Begin(ArchiveMeta{
+		URL:        req.URL.String(),
+		Method:     method,
+		FetchedAt:  time.Now().UTC(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	})
+	if err != nil {
+		// Recording is best effort; the walk still gets its response.
+		return resp, nil
+	}
+	resp.Body = &archiveTee{body: resp.Body, writer: writer}
+	return resp, nil
+}
+
+type recorderWriter struct {
+	dir    string
+	prefix string
+	meta   ArchiveMeta
+	file   *os.File
+	hash   hash.Hash
+	size   int64
+}
+
+func (w *recorderWriter) Write(p []byte) (int, error) {
+	w.hash.Write(p)
+	w.size += int64(len(p))
+	return w.file.Write(p)
+}
+
+func (w *recorderWriter) Commit() error {
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	if err := os.Rename(w.file.Name(), filepath.Join(w.dir, w.meta.Body)); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	w.meta.Size = w.size
+	w.meta.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	data, err := json.MarshalIndent(w.meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.dir, w.prefix+".meta.json"), data, 0o644)
+}
+
+func (w *recorderWriter) Abort() error {
+	w.file.Close()
+	return os.Remove(w.file.Name())
+}
+
+// ReplayTransport is an http.RoundTripper serving responses recorded by
+// Recorder without network access. Use it as the transport of
+// Options.HTTPClient; robots.txt, redirects, and conditional headers are
+// replayed as recorded. Requests that were never recorded get a 404.
+type ReplayTransport struct {
+	dir       string
+	responses map[string]ArchiveMeta
+}
+
+// NewReplayTransport loads the recording in dir. When a URL was recorded
+// more than once, the latest capture wins.
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.meta.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no recorded responses in %s", dir)
+	}
+	sort.Strings(names)
+	transport := &ReplayTransport{dir: dir, responses: make(map[string]ArchiveMeta, len(names))}
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var meta ArchiveMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("invalid recording metadata %s: %w", filepath.Base(name), err)
+		}
+		loc, err := url.Parse(meta.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recorded URL %q: %w", meta.URL, err)
+		}
+		if meta.Body == "" || strings.ContainsAny(meta.Body, `/\`) {
+			return nil, fmt.Errorf("invalid recording body %q for %s", meta.Body, meta.URL)
+		}
+		transport.responses[replayKey(meta.Method, loc)] = meta
+	}
+	return transport, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	meta, ok := t.responses[replayKey(req.Method, req.URL)]
+	if !ok {
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", http.StatusNotFound, http.StatusText(http.StatusNotFound)),
+			StatusCode: http.StatusNotFound,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	body, err := os.Open(filepath.Join(t.dir, meta.Body))
+	if err != nil {
+		return nil, err
+	}
+	header := meta.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", meta.StatusCode, http.StatusText(meta.StatusCode)),
+		StatusCode:    meta.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: meta.Size,
+		Request:       req,
+	}, nil
+}
+
+func replayKey(method string, loc *url.URL) string {
+	if method == "" {
+		method = http.MethodGet
+	}
+	return method + " " + canonicalURLKey(loc)
+}
@@ -0,0 +1,102 @@
+package gositemapfetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSitemapFetcher_RecordReplay(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/one</loc></url>
+  <url><loc>/private/two</loc></url>
+</urlset>`))
+	_ = zw.Close()
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\nSitemap: /index.xml\n"))
+		case "/index.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>/pages.xml.gz</loc></sitemap></sitemapindex>`))
+		case "/pages.xml.gz":
+			w.Header().Set("Content-Type", "application/x-gzip")
+			_, _ = w.Write(gz.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	site := mustParseURL(t, server.URL)
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	recorded, err := collectItems(New(Options{HTTPClient: &http.Client{}, WrapTransport: recorder.Wrap}), site)
+	if err != nil {
+		t.Fatalf("recorded walk failed: %v", err)
+	}
+	server.Close()
+	if len(recorded) != 1 {
+		t.Fatalf("expected robots.txt to filter the recorded walk to 1 item, got %d", len(recorded))
+	}
+	if temps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(temps) != 0 {
+		t.Fatalf("expected no temporary files left behind, got %v", temps)
+	}
+	body, err := os.ReadFile(filepath.Join(dir, "000001.body"))
+	if err != nil || !bytes.Contains(body, []byte("Disallow: /private/")) {
+		t.Fatalf("expected robots.txt to be recorded first, got %q (%v)", body, err)
+	}
+
+	replay, err := NewReplayTransport(dir)
+	if err != nil {
+		t.Fatalf("NewReplayTransport failed: %v", err)
+	}
+	replayed, err := collectItems(New(Options{HTTPClient: &http.Client{Transport: replay}}), site)
+	if err != nil {
+		t.Fatalf("replayed walk failed: %v", err)
+	}
+	if len(replayed) != len(recorded) || replayed[0].Loc.String() != recorded[0].Loc.String() {
+		t.Fatalf("expected the replay to match the recording, got %v want %v", replayed, recorded)
+	}
+
+	// A second recording into the same directory appends to the first.
+	before, _ := filepath.Glob(filepath.Join(dir, "*.meta.json"))
+	server = newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/fresh</loc></url></urlset>`))
+	}))
+	again, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	fresh := mustParseURL(t, server.URL+"/sitemap.xml")
+	if _, err := collectItems(New(Options{HTTPClient: &http.Client{}, IgnoreRobots: true, WrapTransport: again.Wrap}), fresh); err != nil {
+		t.Fatalf("second recorded walk failed: %v", err)
+	}
+	server.Close()
+	after, _ := filepath.Glob(filepath.Join(dir, "*.meta.json"))
+	if len(after) != len(before)+1 {
+		t.Fatalf("expected the second recording to add 1 response to %d, got %d", len(before), len(after))
+	}
+	if body, err := os.ReadFile(filepath.Join(dir, "000001.body")); err != nil || !bytes.Contains(body, []byte("Disallow: /private/")) {
+		t.Fatalf("expected the first recording to be kept, got %q (%v)", body, err)
+	}
+	replay, err = NewReplayTransport(dir)
+	if err != nil {
+		t.Fatalf("NewReplayTransport failed: %v", err)
+	}
+	replayed, err = collectItems(New(Options{HTTPClient: &http.Client{Transport: replay}}), site)
+	if err != nil || len(replayed) != len(recorded) {
+		t.Fatalf("expected the first site to still replay, got %v (%v)", replayed, err)
+	}
+
+	if _, err := NewReplayTransport(t.TempDir()); err == nil {
+		t.Fatalf("expected an empty directory to be rejected")
+	}
+}
@@ -0,0 +1,259 @@
+package gositemapfetcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResultSet is an in-memory index of walked items that can be narrowed by
+// URL prefix, host, lastmod range, and priority. Query methods return a new
+// ResultSet sharing the items and can be chained:
+//
+//	recent := set.WithPrefix("/blog/").ModifiedBetween(since, time.Time{})
+type ResultSet struct {
+	items []Item
+}
+
+// NewResultSet builds a ResultSet over items.
+func NewResultSet(items []Item) *ResultSet {
+	return &ResultSet{items: items}
+}
+
+// CollectResultSet walks website and keeps every yielded item.
+func CollectResultSet(ctx context.Context, walker SitemapWalker, website *url.URL) (*ResultSet, error) {
+	set := &ResultSet{}
+	err := walker.Walk(ctx, website, func(item Item) error {
+		set.Add(item)
+		return nil
+	})
+	return set, err
+}
+
+// Add appends an item to the set.
+func (r *ResultSet) Add(item Item) {
+	r.items = append(r.items, item)
+}
+
+// Len returns the number of items in the set.
+func (r *ResultSet) Len() int {
+	return len(r.items)
+}
+
+// Items returns the items in walk order. The slice is shared; do not modify it.
+func (r *ResultSet) Items() []Item {
+	return r.items
+}
+
+// All iterates over the items in walk order.
+func (r *ResultSet) All() iter.Seq[Item] {
+	return func(yield func(Item) bool) {
+		for _, item := range r.items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns the items for which keep returns true.
+func (r *ResultSet) Filter(keep func(Item) bool) *ResultSet {
+	out := &ResultSet{}
+	for _, item := range r.items {
+		if keep(item) {
+			out.items = append(out.items, item)
+		}
+	}
+	return out
+}
+
+// WithPrefix returns items whose URL starts with prefix. A prefix starting
+// with "/" is matched against the URL path, anything else against the full URL.
+func (r *ResultSet) WithPrefix(prefix string) *ResultSet {
+	if strings.HasPrefix(prefix, "/") {
+		return r.Filter(func(item Item) bool {
+			return strings.HasPrefix(item.Loc.Path, prefix)
+		})
+	}
+	return r.Filter(func(item Item) bool {
+		return strings.HasPrefix(item.Loc.String(), prefix)
+	})
+}
+
+// OnHost returns items on host, compared case-insensitively.
+func (r *ResultSet) OnHost(host string) *ResultSet {
+	return r.Filter(func(item Item) bool {
+		return strings.EqualFold(item.Loc.Host, host)
+	})
+}
+
+// ModifiedBetween returns items with a lastmod in [since, until). A zero
+// bound is open; items without lastmod are dropped once a bound is set.
+func (r *ResultSet) ModifiedBetween(since, until time.Time) *ResultSet {
+	if since.IsZero() && until.IsZero() {
+		return r.Filter(func(Item) bool { return true })
+	}
+	return r.Filter(func(item Item) bool {
+		if item.LastMod == nil {
+			return false
+		}
+		if !since.IsZero() && item.LastMod.Before(since) {
+			return false
+		}
+		return until.IsZero() || item.LastMod.Before(until)
+	})
+}
+
+// PriorityBetween returns items with a priority in [low, high]. Items without
+// priority are dropped.
+func (r *ResultSet) PriorityBetween(low, high float64) *ResultSet {
+	return r.Filter(func(item Item) bool {
+		return item.Priority != nil && *item.Priority >= low && *item.Priority <= high
+	})
+}
+
+// snapshotItem is the JSON form of an Item in a snapshot. Its fields match the
+// CLI's ndjson output, so either can be loaded with ReadResultSet.
+type snapshotItem struct {
+	Loc        string   `json:"loc"`
+	LastMod    string   `json:"lastmod,omitempty"`
+	ChangeFreq string   `json:"changefreq,omitempty"`
+	Priority   *float64 `json:"priority,omitempty"`
+	Sitemap    string   `json:"sitemap,omitempty"`
+}
+
+// WriteJSON writes the set as a JSON array snapshot that ReadResultSet can
+// load later. Provenance and LastModOffset are not included.
+func (r *ResultSet) WriteJSON(w io.Writer) error {
+	out := make([]snapshotItem, 0, len(r.items))
+	for _, item := range r.items {
+		entry := snapshotItem{
+			Loc:        item.Loc.String(),
+			ChangeFreq: string(item.ChangeFreq),
+			Priority:   item.Priority,
+		}
+		if item.LastMod != nil {
+			entry.LastMod = item.LastMod.Format(time.RFC3339Nano)
+		}
+		if item.Sitemap != nil {
+			entry.Sitemap = item.Sitemap.String()
+		}
+		out = append(out, entry)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteFile writes the WriteJSON snapshot to path, replacing it atomically
+// so an interrupted run leaves the previous snapshot intact.
+func (r *ResultSet) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := r.WriteJSON(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadResultSet loads a snapshot written by WriteJSON, or newline-delimited
+// JSON objects in the same shape (the CLI's --format ndjson output).
+func ReadResultSet(r io.Reader) (*ResultSet, error) {
+	reader := bufio.NewReader(r)
+	dec := json.NewDecoder(reader)
+	set := &ResultSet{}
+
+	first, err := peekNonSpace(reader)
+	if errors.Is(err, io.EOF) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if first == '[' {
+		var entries []snapshotItem
+		if err := dec.Decode(&entries); err != nil {
+			return nil, fmt.Errorf("invalid snapshot: %w", err)
+		}
+		for i, entry := range entries {
+			item, err := entry.item()
+			if err != nil {
+				return nil, fmt.Errorf("invalid snapshot entry %d: %w", i+1, err)
+			}
+			set.Add(item)
+		}
+		return set, nil
+	}
+	for line := 1; ; line++ {
+		var entry snapshotItem
+		err := dec.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return set, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot entry %d: %w", line, err)
+		}
+		item, err := entry.item()
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot entry %d: %w", line, err)
+		}
+		set.Add(item)
+	}
+}
+
+func (s snapshotItem) item() (Item, error) {
+	if s.Loc == "" {
+		return Item{}, errors.New("missing loc")
+	}
+	loc, err := url.Parse(s.Loc)
+	if err != nil {
+		return Item{}, err
+	}
+	item := Item{Loc: loc, ChangeFreq: ParseChangeFreq(s.ChangeFreq), Priority: s.Priority}
+	if s.LastMod != "" {
+		lastMod, err := time.Parse(time.RFC3339Nano, s.LastMod)
+		if err != nil {
+			return Item{}, err
+		}
+		item.LastMod = &lastMod
+	}
+	if s.Sitemap != "" {
+		sitemap, err := url.Parse(s.Sitemap)
+		if err != nil {
+			return Item{}, err
+		}
+		item.Sitemap = sitemap
+	}
+	return item, nil
+}
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, r.UnreadByte()
+	}
+}
@@ -0,0 +1,115 @@
+package gositemapfetcher
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultSet_Queries(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/blog/old</loc><lastmod>2023-01-01</lastmod><priority>0.3</priority></url>
+  <url><loc>/blog/new</loc><lastmod>2024-07-01</lastmod><priority>0.9</priority></url>
+  <url><loc>/about</loc><lastmod>2024-08-01</lastmod></url>
+  <url><loc>https://cdn.example.com/blog/asset</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+	set, err := CollectResultSet(context.Background(), New(Options{IgnoreRobots: true}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if set.Len() != 4 {
+		t.Fatalf("expected 4 items, got %d", set.Len())
+	}
+
+	paths := func(r *ResultSet) string {
+		var out []string
+		for item := range r.All() {
+			out = append(out, item.Loc.Path)
+		}
+		return strings.Join(out, ",")
+	}
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	if got := paths(set.WithPrefix("/blog/")); got != "/blog/old,/blog/new,/blog/asset" {
+		t.Fatalf("unexpected prefix result: %s", got)
+	}
+	if got := paths(set.WithPrefix(server.URL + "/blog/")); got != "/blog/old,/blog/new" {
+		t.Fatalf("unexpected URL prefix result: %s", got)
+	}
+	if got := paths(set.OnHost("CDN.example.com")); got != "/blog/asset" {
+		t.Fatalf("unexpected host result: %s", got)
+	}
+	if got := paths(set.ModifiedBetween(since, time.Time{})); got != "/blog/new,/about" {
+		t.Fatalf("unexpected since result: %s", got)
+	}
+	if got := paths(set.ModifiedBetween(since, until)); got != "/blog/new" {
+		t.Fatalf("unexpected range result: %s", got)
+	}
+	if got := paths(set.PriorityBetween(0.5, 1)); got != "/blog/new" {
+		t.Fatalf("unexpected priority result: %s", got)
+	}
+	if got := set.WithPrefix("/blog/").ModifiedBetween(since, time.Time{}).Len(); got != 1 {
+		t.Fatalf("expected chained query to match 1 item, got %d", got)
+	}
+}
+
+func TestResultSet_Snapshot(t *testing.T) {
+	priority := 0.8
+	// Sub-second lastmods must survive, or diffing against the snapshot
+	// reports every such URL as updated.
+	lastMod := time.Date(2024, 6, 1, 12, 0, 0, 250_000_000, time.UTC)
+	loc, _ := url.Parse("https://example.com/a")
+	sitemap, _ := url.Parse("https://example.com/sitemap.xml")
+	set := NewResultSet([]Item{
+		{Loc: loc, LastMod: &lastMod, ChangeFreq: "daily", Priority: &priority, Sitemap: sitemap},
+		{Loc: sitemap.ResolveReference(&url.URL{Path: "/b"})},
+	})
+
+	var buf bytes.Buffer
+	if err := set.WriteJSON(&buf); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	loaded, err := ReadResultSet(&buf)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if loaded.Len() != 2 {
+		t.Fatalf("expected 2 items, got %d", loaded.Len())
+	}
+	first := loaded.Items()[0]
+	if first.Loc.String() != loc.String() || !first.LastMod.Equal(lastMod) || first.ChangeFreq != "daily" ||
+		first.Priority == nil || *first.Priority != priority || first.Sitemap.String() != sitemap.String() {
+		t.Fatalf("unexpected round-tripped item: %+v", first)
+	}
+
+	ndjson := `{"loc":"https://example.com/a","lastmod":"2024-06-01T12:00:00Z"}
+{"loc":"https://example.com/b"}
+`
+	loaded, err = ReadResultSet(strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("read ndjson failed: %v", err)
+	}
+	if loaded.Len() != 2 || loaded.ModifiedBetween(lastMod.Truncate(time.Second), time.Time{}).Len() != 1 {
+		t.Fatalf("unexpected ndjson result: %d items", loaded.Len())
+	}
+
+	if _, err := ReadResultSet(strings.NewReader(`{"lastmod":"2024-06-01"}`)); err == nil {
+		t.Fatalf("expected error for entry without loc")
+	}
+}
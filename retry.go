@@ -0,0 +1,67 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RetryPolicy controls how transient sitemap fetch failures are retried.
+// The zero value retries HTTP 429 up to 3 times, waiting 5s between tries
+// unless the server sends Retry-After.
+type RetryPolicy struct {
+	MaxRetries    int           // retries after the first attempt (0 => 3, negative => none)
+	BaseDelay     time.Duration // delay between tries without Retry-After (0 => 5s)
+	MaxDelay      time.Duration // cap for any single delay, including Retry-After (0 => 30s)
+	StatusCodes   []int         // retryable HTTP statuses (nil => 429 only)
+	NetworkErrors bool          // also retry transport errors from the HTTP client
+	Exponential   bool          // double BaseDelay per retry instead of waiting it each time
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxRetries == 0 {
+		p.MaxRetries = maxRetryAttempts
+	}
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultRetryDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = maxRetryDelay
+	}
+	if p.StatusCodes == nil {
+		p.StatusCodes = []int{http.StatusTooManyRequests}
+	}
+	return p
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	return slices.Contains(p.StatusCodes, code)
+}
+
+func (p RetryPolicy) retryableError(err error) bool {
+	if !p.NetworkErrors {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) && !isLimitError(err)
+}
+
+// delay returns the wait before retry number attempt (0-based), preferring a
+// server-provided Retry-After when present.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	delay := retryAfterDelay(resp)
+	if delay <= 0 {
+		delay = p.BaseDelay
+		for i := 0; p.Exponential && i < attempt && delay < p.MaxDelay; i++ {
+			delay *= 2
+		}
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,73 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRobotsTTL is how long NewMemoryRobotsCache keeps robots.txt files
+// when no TTL is given, the maximum RFC 9309 recommends.
+const DefaultRobotsTTL = 24 * time.Hour
+
+// RobotsCache stores fetched robots.txt files between walks, keyed by
+// "scheme://host". Implementations must be safe for concurrent use when the
+// fetcher walks from several goroutines.
+type RobotsCache interface {
+	// Get returns the stored entry for key, or nil when nothing fresh is stored.
+	Get(ctx context.Context, key string) (*RobotsEntry, error)
+	// Put stores entry for key.
+	Put(ctx context.Context, key string, entry RobotsEntry) error
+}
+
+// RobotsEntry is one fetched robots.txt file.
+type RobotsEntry struct {
+	// StatusCode is the HTTP status robots.txt was served with.
+	StatusCode int
+	// Body is the robots.txt content, read only when StatusCode is 200.
+	Body []byte
+	// FetchedAt is when robots.txt was requested.
+	FetchedAt time.Time
+}
+
+// MemoryRobotsCache is a concurrency-safe in-memory RobotsCache whose entries
+// expire after a fixed TTL.
+type MemoryRobotsCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]RobotsEntry
+}
+
+// NewMemoryRobotsCache returns an empty MemoryRobotsCache keeping entries for
+// ttl (0 => DefaultRobotsTTL).
+func NewMemoryRobotsCache(ttl time.Duration) *MemoryRobotsCache {
+	if ttl <= 0 {
+		ttl = DefaultRobotsTTL
+	}
+	return &MemoryRobotsCache{ttl: ttl, now: time.Now, entries: map[string]RobotsEntry{}}
+}
+
+// Get implements RobotsCache. Expired entries are dropped.
+func (c *MemoryRobotsCache) Get(_ context.Context, key string) (*RobotsEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if c.now().Sub(entry.FetchedAt) >= c.ttl {
+		delete(c.entries, key)
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Put implements RobotsCache.
+func (c *MemoryRobotsCache) Put(_ context.Context, key string, entry RobotsEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+	return nil
+}
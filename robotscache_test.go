@@ -0,0 +1,60 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryRobotsCache_TTL(t *testing.T) {
+	cache := NewMemoryRobotsCache(time.Hour)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = cache.Put(ctx, "https://example.com", RobotsEntry{StatusCode: http.StatusOK, Body: []byte("User-agent: *"), FetchedAt: now})
+	now = now.Add(59 * time.Minute)
+	if entry, _ := cache.Get(ctx, "https://example.com"); entry == nil || entry.StatusCode != http.StatusOK {
+		t.Fatalf("expected a fresh entry, got %+v", entry)
+	}
+	now = now.Add(time.Minute)
+	if entry, _ := cache.Get(ctx, "https://example.com"); entry != nil {
+		t.Fatalf("expected the entry to expire, got %+v", entry)
+	}
+}
+
+func TestSitemapFetcher_RobotsCache(t *testing.T) {
+	var robotsRequests atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsRequests.Add(1)
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private\n\nSitemap: /sitemap.xml\n"))
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/public</loc></url>
+  <url><loc>/private/page</loc></url>
+</urlset>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL := mustParseURL(t, server.URL)
+
+	fetcher := New(Options{RobotsCache: NewMemoryRobotsCache(0)})
+	for i := 0; i < 2; i++ {
+		items, err := collectItems(fetcher, baseURL)
+		if err != nil {
+			t.Fatalf("walk %d failed: %v", i, err)
+		}
+		if len(items) != 1 || items[0].Loc.Path != "/public" {
+			t.Fatalf("walk %d: expected cached rules to apply, got %+v", i, items)
+		}
+	}
+	if robotsRequests.Load() != 1 {
+		t.Fatalf("expected robots.txt to be fetched once, got %d", robotsRequests.Load())
+	}
+}
@@ -0,0 +1,144 @@
+package gositemapfetcher
+
+import (
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// SeenSet remembers URL keys for Options.DedupURLs. Implementations trade
+// memory for exactness; a set shared between walks also deduplicates across
+// them.
+type SeenSet interface {
+	// Add records key and reports whether it had been added before.
+	Add(key string) bool
+	// Contains reports whether key has been added, without adding it.
+	Contains(key string) bool
+}
+
+// MapSeenSet is an exact SeenSet backed by a map. It is safe for concurrent
+// use.
+type MapSeenSet struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// NewMapSeenSet returns an empty MapSeenSet.
+func NewMapSeenSet() *MapSeenSet {
+	return &MapSeenSet{keys: map[string]struct{}{}}
+}
+
+// Add implements SeenSet.
+func (s *MapSeenSet) Add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[key]; ok {
+		return true
+	}
+	s.keys[key] = struct{}{}
+	return false
+}
+
+// Contains implements SeenSet.
+func (s *MapSeenSet) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.keys[key]
+	return ok
+}
+
+// Len returns the number of distinct keys added.
+func (s *MapSeenSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+// BloomSeenSet is an approximate SeenSet with memory fixed at creation,
+// for walks of tens of millions of URLs. A new URL is wrongly reported as
+// seen, and so dropped, with roughly the configured false-positive rate; a
+// repeated URL is never missed. It is safe for concurrent use.
+type BloomSeenSet struct {
+	mu    sync.Mutex
+	bits  []uint64
+	m     uint64 // number of bits
+	k     uint64 // hash functions per key
+	seed1 maphash.Seed
+	seed2 maphash.Seed
+	added int
+}
+
+// NewBloomSeenSet sizes a BloomSeenSet for expected keys at the given
+// false-positive rate, e.g. NewBloomSeenSet(50_000_000, 0.001) uses about
+// 90MB. Out-of-range arguments fall back to 1M keys and a 1% rate.
+func NewBloomSeenSet(expected int, falsePositiveRate float64) *BloomSeenSet {
+	if expected <= 0 {
+		expected = 1_000_000
+	}
+	if !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+		falsePositiveRate = 0.01
+	}
+	n := float64(expected)
+	m := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / n * math.Ln2))
+	k = max(k, 1)
+	return &BloomSeenSet{
+		bits:  make([]uint64, (m+63)/64),
+		m:     m,
+		k:     k,
+		seed1: maphash.MakeSeed(),
+		seed2: maphash.MakeSeed(),
+	}
+}
+
+// Add implements SeenSet.
+func (s *BloomSeenSet) Add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	present := true
+	s.positions(key, func(word int, mask uint64) {
+		if s.bits[word]&mask == 0 {
+			present = false
+			s.bits[word] |= mask
+		}
+	})
+	if !present {
+		s.added++
+	}
+	return present
+}
+
+// Contains implements SeenSet. It may report a key that was never added.
+func (s *BloomSeenSet) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	present := true
+	s.positions(key, func(word int, mask uint64) {
+		present = present && s.bits[word]&mask != 0
+	})
+	return present
+}
+
+// positions calls fn with the word index and bit mask of each of key's k
+// bits, derived from two hashes by double hashing.
+func (s *BloomSeenSet) positions(key string, fn func(word int, mask uint64)) {
+	h1 := maphash.String(s.seed1, key)
+	h2 := maphash.String(s.seed2, key) | 1
+	for i := range s.k {
+		bit := (h1 + i*h2) % s.m
+		fn(int(bit/64), uint64(1)<<(bit%64))
+	}
+}
+
+// Len returns the number of keys reported as new.
+func (s *BloomSeenSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.added
+}
+
+// SizeBytes returns the memory used by the filter's bit array.
+func (s *BloomSeenSet) SizeBytes() int {
+	return len(s.bits) * 8
+}
@@ -0,0 +1,112 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSitemapFetcher_DedupURLs(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/one.xml</loc></sitemap>
+  <sitemap><loc>/two.xml</loc></sitemap>
+</sitemapindex>`
+	const one = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <url><loc>/shared</loc></url>
+</urlset>`
+	const two = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/shared#top</loc></url>
+  <url><loc>/b</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/one.xml":
+			_, _ = w.Write([]byte(one))
+		case "/two.xml":
+			_, _ = w.Write([]byte(two))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	indexURL := mustParseURL(t, server.URL+"/sitemap_index.xml")
+
+	walk := func(opts Options) ([]string, *WalkResult) {
+		var paths []string
+		result, err := New(opts).WalkWithResult(context.Background(), indexURL, func(item Item) error {
+			paths = append(paths, item.Loc.Path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("walk failed: %v", err)
+		}
+		return paths, result
+	}
+
+	if paths, _ := walk(Options{}); len(paths) != 4 {
+		t.Fatalf("expected duplicates without DedupURLs, got %v", paths)
+	}
+	paths, result := walk(Options{DedupURLs: true})
+	if strings.Join(paths, ",") != "/a,/shared,/b" || result.URLsDuplicate != 1 {
+		t.Fatalf("unexpected dedup walk: %v, %+v", paths, result)
+	}
+
+	shared := NewMapSeenSet()
+	walk(Options{DedupURLs: true, SeenSet: shared})
+	paths, result = walk(Options{DedupURLs: true, SeenSet: shared})
+	if len(paths) != 0 || result.URLsDuplicate != 4 || shared.Len() != 3 {
+		t.Fatalf("expected a shared set to dedup across walks, got %v, %+v", paths, result)
+	}
+
+	// URLs a stopped walk never delivered stay unseen for the next walk.
+	partial := NewMapSeenSet()
+	if _, err := New(Options{DedupURLs: true, SeenSet: partial, MaxURLs: 1}).WalkWithResult(context.Background(), indexURL, func(Item) error {
+		return nil
+	}); err == nil {
+		t.Fatalf("expected MaxURLs to stop the walk")
+	}
+	if partial.Len() != 1 || !partial.Contains(canonicalURLKey(mustParseURL(t, server.URL+"/a"))) {
+		t.Fatalf("expected only the yielded URL to be seen, got %d keys", partial.Len())
+	}
+	paths, _ = walk(Options{DedupURLs: true, SeenSet: partial})
+	if strings.Join(paths, ",") != "/shared,/b" {
+		t.Fatalf("expected the undelivered URLs in the next walk, got %v", paths)
+	}
+}
+
+func TestBloomSeenSet(t *testing.T) {
+	const n = 100_000
+	set := NewBloomSeenSet(n, 0.01)
+	for i := range n {
+		set.Add("https://example.com/page-" + strconv.Itoa(i))
+	}
+	for i := range n {
+		if !set.Add("https://example.com/page-" + strconv.Itoa(i)) {
+			t.Fatalf("repeated key %d was not reported as seen", i)
+		}
+	}
+	var falsePositives int
+	for i := range n {
+		if set.Contains("https://example.com/other-" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 0.015 {
+		t.Fatalf("false-positive rate %.4f too high", rate)
+	}
+	if set.Len() < n*99/100 {
+		t.Fatalf("expected about %d new keys, got %d", n, set.Len())
+	}
+	if size := set.SizeBytes(); size > 150_000 {
+		t.Fatalf("filter uses %d bytes, expected about 120KB", size)
+	}
+}
@@ -2,16 +2,22 @@ package gositemapfetcher
 
 import (
 	"bufio"
+	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/xml"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -20,11 +26,30 @@ import (
 )
 
 const (
-	defaultUserAgent  = "Mozilla/5.0 (Macintosh; Intel Mac OS X 26_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36"
+	// DefaultUserAgent is the User-Agent sent when Options.UserAgent is empty.
+	DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 26_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36"
+	// DefaultMaxSitemapBytes is the sitemaps.org limit for an uncompressed sitemap.
+	DefaultMaxSitemapBytes = 50 * 1024 * 1024
+)
+
+const (
 	defaultBufSize    = 64 * 1024
 	maxRetryAttempts  = 3
 	defaultRetryDelay = 5 * time.Second
 	maxRetryDelay     = 30 * time.Second
+	maxHTMLBytes      = 1 << 20
+	// defaultMaxElementBytes caps a single <url> or <sitemap> element.
+	defaultMaxElementBytes = 1 << 20
+	// defaultMaxTokenBytes and defaultMaxNesting bound what the XML decoder
+	// buffers outside elements, e.g. a huge comment or DOCTYPE, and the
+	// element stack it keeps, far beyond anything a real sitemap needs.
+	defaultMaxTokenBytes = 1 << 20
+	defaultMaxNesting    = 100
+	// maxCodecLayers bounds nested compressed streams: transfer encoding plus
+	// a compressed file, e.g. a .gz file served with Content-Encoding: gzip.
+	maxCodecLayers = 2
+	// defaultMaxCrawlDelay caps robots.txt Crawl-delay when MaxCrawlDelay is unset.
+	defaultMaxCrawlDelay = 30 * time.Second
 )
 
 // ===================== Configuration =====================
@@ -35,16 +60,471 @@ type Options struct {
 	MaxDepth          int
 	MaxSitemaps       int
 	MaxURLs           int
+	MaxSitemapBytes   int64 // uncompressed bytes per sitemap (0 => 50MB, negative => no limit)
+	MaxElementBytes   int64 // raw bytes per <url>/<sitemap> element (0 => 1MB, negative => no limit)
+	Decoder           DecoderOptions
 	AllowNon200       bool
 	IgnoreRobots      bool
+	IgnoreCrawlDelay  bool          // do not sleep for robots.txt Crawl-delay
+	MaxCrawlDelay     time.Duration // cap for Crawl-delay (0 => 30s)
+	DiscoverFromHTML  bool          // fetch the homepage when robots.txt and default probes find nothing
 	UserAgent         string
+	UserAgentSuffix   string // appended to the effective UserAgent, e.g. "+https://example.com/bot"
+	RobotsAgent       string // robots.txt group to obey, e.g. "MyBot" (empty => UserAgent)
 	PerRequestTimeout time.Duration
+	MaxDuration       time.Duration // wall-clock budget for a whole walk (0 => no limit)
+	MaxBytes          int64         // downloaded bytes for a whole walk (0 => no limit)
 	Logger            *slog.Logger
+	Cache             Cache         // nil => no conditional requests
+	Retry             RetryPolicy   // zero value => retry 429 up to 3 times
+	Archive           Archiver      // nil => raw sitemap bodies are not recorded
+	SitemapSource     SitemapSource // nil => fetch sitemaps over HTTP
+	Mirrors           []*url.URL    // fallback base URLs for the walked host, tried in order on network errors
 
 	Include []*regexp.Regexp // nil => include all
 	Exclude []*regexp.Regexp // nil => exclude none
+
+	// Sitemaps, when set, are walked instead of discovering sitemaps from
+	// robots.txt, default paths, or the homepage, e.g. a list kept from
+	// Search Console. Relative entries are resolved against the URL passed to
+	// Walk, which may then be nil.
+	Sitemaps []*url.URL
+
+	// SkipDeepSitemaps skips sitemaps nested deeper than MaxDepth, counting
+	// them in WalkResult.SitemapsSkipped, instead of failing with
+	// *ErrMaxDepth, so the rest of the tree is still walked.
+	SkipDeepSitemaps bool
+
+	// OnErrorContinue skips a sitemap that cannot be fetched or parsed and
+	// walks the rest, then returns *ErrPartial listing every failure. Unlike
+	// AllowNon200 it also covers network and parse errors. Limits, yield
+	// failures, and cancellation still end the walk.
+	OnErrorContinue bool
+
+	// OnSitemapError, when set, is called with each sitemap that cannot be
+	// fetched or parsed. Returning nil skips it and walks the rest; returning
+	// an error, e.g. err itself, ends the walk with that error. With
+	// OnErrorContinue, skipped sitemaps are also listed in *ErrPartial.
+	OnSitemapError func(loc *url.URL, err error) error
+
+	// StopWhen, when set, is called before each sitemap is fetched. Returning
+	// true ends the walk cleanly, without fetching the rest of the queue,
+	// and sets WalkResult.Stopped, e.g. once enough fresh URLs were found.
+	StopWhen func(Progress) bool
+
+	// LenientXML recovers what it can from malformed sitemaps: forbidden
+	// control characters are dropped and a syntax error, e.g. a truncated
+	// tail, ends the sitemap after the entries decoded before it instead of
+	// failing it. Unescaped ampersands are accepted in either mode.
+	LenientXML bool
+
+	// LimitBehavior controls whether reaching MaxDepth, MaxSitemaps, or
+	// MaxURLs fails the walk or ends it cleanly.
+	LimitBehavior LimitBehavior
+
+	// ProxyURL routes requests through an HTTP(S) or SOCKS5 proxy, e.g.
+	// "socks5://127.0.0.1:1080", on a copy of the HTTPClient transport. It
+	// is ignored when that transport is not an *http.Transport.
+	ProxyURL *url.URL
+
+	// TLS replaces the TLS configuration of a copy of the HTTPClient
+	// transport, e.g. with RootCAs trusting a staging CA, client
+	// Certificates, or InsecureSkipVerify for self-signed hosts. Like
+	// ProxyURL, it is ignored when that transport is not an *http.Transport.
+	TLS *tls.Config
+
+	// WrapTransport, when set, wraps the transport of a copy of the
+	// HTTPClient, after ProxyURL and TLS are applied, so every sitemap, robots
+	// and probe request passes through it, e.g. to sign, cache, or record
+	// requests. Requests reaching it already carry the User-Agent, Headers,
+	// and the PerRequestTimeout context.
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+
+	// Headers are added to requests to the walked site, e.g. an
+	// Authorization header built with BasicAuthHeader or BearerAuthHeader for
+	// a staging site. During a walk they are only sent to the host of the
+	// walked URL, the hosts of Options.Sitemaps, and HeaderHosts, so
+	// credentials do not leak to other hosts a sitemap points at. A
+	// User-Agent here overrides Options.UserAgent on every request, with
+	// UserAgentSuffix still appended. net/http
+	// drops Authorization and Cookie on redirects to other domains.
+	Headers http.Header
+
+	// HeaderHosts lists further hosts that receive Headers, e.g. a CDN
+	// serving the child sitemaps or one of Mirrors. An entry without a port
+	// matches the host on any port.
+	HeaderHosts []string
+
+	// RobotsScope limits robots.txt disallow checks to sitemaps or to the
+	// URLs they list, e.g. RobotsScopeSitemaps for consumers that never fetch
+	// the pages. IgnoreRobots overrides it.
+	RobotsScope RobotsScope
+
+	// RobotsErrorPolicy controls robots.txt answering 5xx or 429, or not
+	// answering at all.
+	RobotsErrorPolicy RobotsErrorPolicy
+
+	// RobotsCache keeps robots.txt files between walks, e.g.
+	// NewMemoryRobotsCache(0) shared by a service walking many sites. Nil
+	// fetches robots.txt once per host and walk.
+	RobotsCache RobotsCache
+
+	// NoProbe disables probing SitemapCandidates, so discovery relies on
+	// robots.txt Sitemap directives, DiscoverFromHTML, or an explicit sitemap
+	// URL. A site offering none of these fails with *ErrNoSitemaps.
+	NoProbe bool
+	// SitemapCandidates are the paths probed when robots.txt lists no
+	// sitemaps, in order of preference. Nil or empty uses
+	// DefaultSitemapCandidates.
+	SitemapCandidates []string
+
+	// StateStore, when set, records the index lastmod and ETag of every
+	// child urlset read completely. Later walks skip children whose index
+	// lastmod has not advanced since, counting them in
+	// WalkResult.SitemapsSkipped, which keeps daily walks of large indexes
+	// cheap. Nested indexes and children listed without a lastmod are always
+	// read; pair with Cache to revalidate those by ETag.
+	StateStore StateStore
+
+	// Normalize canonicalizes each URL right after it is resolved, so
+	// filters, robots.txt checks, and yield all see the normalized form.
+	Normalize NormalizeOptions
+
+	// YieldSitemaps, when set, receives every child sitemap listed in a
+	// sitemap index as it is discovered, before it is fetched, including
+	// children later skipped by ModifiedAfter or StateStore. Returning an
+	// error stops the walk with *ErrYield.
+	YieldSitemaps func(SitemapRef) error
+
+	// CountSitemapURLs makes ListSitemaps download urlsets in full to count
+	// their entries. By default it stops reading a sitemap once its root
+	// element shows it is not an index, so only indexes are downloaded.
+	CountSitemapURLs bool
+
+	// DedupURLs yields each URL once per walk even when several sitemaps
+	// list it; later copies are counted in WalkResult.URLsDuplicate. URLs
+	// are compared without their fragment, after Normalize.
+	DedupURLs bool
+	// SeenSet stores the URLs DedupURLs has yielded; a URL is added once
+	// yield has accepted it. Nil uses a fresh MapSeenSet per walk; set it to
+	// bound memory or to share it between walks.
+	SeenSet SeenSet
+
+	// Filter, when set, is called with every item that passed the other
+	// filters, just before it is yielded; returning false drops it. Unlike
+	// Include/Exclude it sees lastmod, priority, and changefreq.
+	Filter func(Item) bool
+
+	// ModifiedAfter and ModifiedBefore yield only URLs whose lastmod falls in
+	// [ModifiedAfter, ModifiedBefore); a zero bound is open. Once a bound is
+	// set, URLs without lastmod are dropped. Child sitemaps whose index-level
+	// lastmod is before ModifiedAfter are not fetched at all.
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	// CaptureExtensions keeps every child element of <url> besides loc,
+	// lastmod, changefreq, and priority in Item.Extensions, e.g. custom
+	// PageMaps or image data. Off by default, as it decodes those elements
+	// in full.
+	CaptureExtensions bool
+
+	// RequireExtensions yields only entries carrying data of at least one of
+	// these extensions, e.g. ExtensionImage for image sitemaps. Nil yields all.
+	RequireExtensions []Extension
+
+	// DisableCompression stops requesting gzip transfer encoding. By default
+	// sitemap requests send Accept-Encoding: gzip and decode the response.
+	DisableCompression bool
+
+	// ExtraTimeLayouts are time.Parse layouts tried for lastmod values none
+	// of the built-in forms match, e.g. "02/01/2006". Layouts with a zone
+	// (Z07, -07, MST) fill Item.LastModOffset.
+	ExtraTimeLayouts []string
+
+	// LastModLocation converts parsed lastmod values to this location, e.g.
+	// time.UTC. Nil keeps the offset found in the sitemap.
+	LastModLocation *time.Location
+
+	// CrossHostPolicy controls entries whose host differs from their sitemap's.
+	CrossHostPolicy CrossHostPolicy
+
+	// TraversalOrder picks which queued sitemap is fetched next.
+	TraversalOrder TraversalOrder
+
+	// Deterministic sorts the sitemaps found in robots.txt, on the homepage,
+	// and in each sitemap index by URL before they are queued, so repeated
+	// walks of an unchanged site yield the same items in the same order even
+	// when the site lists its sitemaps in varying order.
+	Deterministic bool
+
+	// Hooks observe the traversal; the zero value observes nothing.
+	Hooks Hooks
+
+	// Tracer, when set, creates a span per sitemap and robots.txt fetch
+	// under the context passed to Walk, e.g. oteltracer.NewTracer for
+	// OpenTelemetry.
+	Tracer Tracer
+
+	// PriorityPolicy controls priorities outside [0.0, 1.0], e.g. 10 or -1.
+	PriorityPolicy PriorityPolicy
+
+	// SkipDuplicateSitemaps parses a sitemap only once when several URLs
+	// serve byte-identical content, e.g. sitemap.xml and sitemap_index.xml.
+	// Later copies are reported in SitemapStats.AliasOf. Each body is
+	// buffered in memory (up to MaxSitemapBytes) to hash it before parsing.
+	SkipDuplicateSitemaps bool
+
+	// RequireNamespace rejects documents whose root is not a <urlset> or
+	// <sitemapindex> in SitemapNamespace with *ErrNotSitemap, and ignores
+	// <url> and <sitemap> elements in other namespaces. By default only
+	// local names are matched, so any XML with <url><loc> is read as a
+	// sitemap, as are sitemaps that omit the namespace.
+	RequireNamespace bool
+
+	// StrictSpec enforces the sitemaps.org protocol: offending entries are
+	// skipped and reported together in *ErrSpecViolations after the walk.
+	// Entries using CDATA are yielded and reported in WalkResult.SpecWarnings.
+	StrictSpec bool
+}
+
+// DecoderOptions tunes the streaming XML decoder for unusual documents or
+// constrained-memory environments.
+type DecoderOptions struct {
+	BufferSize    int   // read buffer size in bytes (0 => 64KB)
+	MaxTokenBytes int64 // largest single XML token, e.g. one text node (0 => 1MB, negative => no limit)
+	MaxNesting    int   // deepest element nesting (0 => 100, negative => no limit)
+}
+
+// CrossHostPolicy decides what happens to sitemap entries on a foreign host.
+type CrossHostPolicy int
+
+const (
+	// CrossHostAllow yields foreign-host entries like any other (default).
+	CrossHostAllow CrossHostPolicy = iota
+	// CrossHostSkip drops foreign-host entries with a debug log.
+	CrossHostSkip
+	// CrossHostError fails the walk with *ErrCrossHost.
+	CrossHostError
+)
+
+// LimitBehavior decides how a walk ends once MaxDepth, MaxSitemaps, MaxURLs,
+// or MaxBytes is reached.
+type LimitBehavior int
+
+const (
+	// LimitError fails the walk with *ErrMaxDepth, *ErrMaxSitemaps,
+	// *ErrMaxURLs, or *ErrMaxBytes (default).
+	LimitError LimitBehavior = iota
+	// LimitStop ends the walk with a nil error, e.g. to take the first N
+	// URLs; WalkResult.LimitReached records which limit stopped it.
+	LimitStop
+)
+
+// TraversalOrder decides the order in which queued sitemaps are fetched.
+type TraversalOrder int
+
+const (
+	// TraversalBFS fetches sitemaps in the order they were found, so root
+	// sitemaps come before their children (default).
+	TraversalBFS TraversalOrder = iota
+	// TraversalDFS walks the children of a sitemap index, in document order,
+	// before the sitemaps queued ahead of them.
+	TraversalDFS
+	// TraversalNewestFirst fetches queued sitemaps with the newest index
+	// lastmod first, those without one last, so limited walks reach the
+	// freshest content before MaxURLs, MaxBytes, or MaxDuration stop them.
+	TraversalNewestFirst
+)
+
+// orderQueue applies Options.TraversalOrder to queue, whose entries from
+// known on were added by the last sitemap fetched.
+func (f *SitemapFetcher) orderQueue(queue []sitemapTask, known int) []sitemapTask {
+	if known >= len(queue) {
+		return queue
+	}
+	if f.opts.Deterministic {
+		sortTasks(queue[known:])
+	}
+	switch f.opts.TraversalOrder {
+	case TraversalDFS:
+		if known > 0 {
+			return slices.Concat(queue[known:], queue[:known])
+		}
+	case TraversalNewestFirst:
+		slices.SortStableFunc(queue, func(a, b sitemapTask) int {
+			return compareNewestFirst(a.lastMod(), b.lastMod())
+		})
+	}
+	return queue
+}
+
+// sortTasks sorts tasks by URL, keeping duplicates in their order.
+func sortTasks(tasks []sitemapTask) {
+	slices.SortStableFunc(tasks, func(a, b sitemapTask) int {
+		return strings.Compare(a.loc.String(), b.loc.String())
+	})
+}
+
+// compareNewestFirst orders later times first and nil last.
+func compareNewestFirst(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return b.Compare(*a)
+}
+
+// isSitemapFailure reports whether err concerns only the sitemap being
+// walked, so OnErrorContinue may skip it and move on.
+func isSitemapFailure(ctx context.Context, err error) bool {
+	var yieldErr *ErrYield
+	var crossHost *ErrCrossHost
+	var robotsErr *ErrRobotsUnavailable
+	switch {
+	case ctx.Err() != nil, isLimitError(err):
+		return false
+	case errors.As(err, &yieldErr), errors.As(err, &crossHost), errors.As(err, &robotsErr):
+		return false
+	}
+	return true
+}
+
+// isLimitError reports whether err is one of the configured limit errors.
+func isLimitError(err error) bool {
+	var maxDepth *ErrMaxDepth
+	var maxSitemaps *ErrMaxSitemaps
+	var maxURLs *ErrMaxURLs
+	var maxBytes *ErrMaxBytes
+	return errors.As(err, &maxDepth) || errors.As(err, &maxSitemaps) || errors.As(err, &maxURLs) || errors.As(err, &maxBytes)
+}
+
+// RobotsScope selects what robots.txt disallow rules are checked against.
+// Sitemap directives and Crawl-delay are used under every scope.
+type RobotsScope int
+
+const (
+	// RobotsScopeBoth checks sitemaps and the URLs they list (default).
+	RobotsScopeBoth RobotsScope = iota
+	// RobotsScopeSitemaps checks only the sitemap files being fetched.
+	RobotsScopeSitemaps
+	// RobotsScopeURLs checks only the listed URLs.
+	RobotsScopeURLs
+	// RobotsScopeNone checks nothing.
+	RobotsScopeNone
+)
+
+// checkRobots reports whether disallow rules apply to scope, which is
+// RobotsScopeSitemaps or RobotsScopeURLs.
+func (f *SitemapFetcher) checkRobots(scope RobotsScope) bool {
+	if f.opts.IgnoreRobots {
+		return false
+	}
+	return f.opts.RobotsScope == RobotsScopeBoth || f.opts.RobotsScope == scope
+}
+
+// RobotsErrorPolicy decides what an unreachable robots.txt means: one that
+// answers 5xx or 429 after retries, or fails at the network level. Other 4xx
+// responses always allow everything, as RFC 9309 specifies.
+type RobotsErrorPolicy int
+
+const (
+	// RobotsErrorAllow treats an unreachable robots.txt as allowing
+	// everything (default). Only the statuses and errors Options.Retry
+	// retries for sitemaps are retried first.
+	RobotsErrorAllow RobotsErrorPolicy = iota
+	// RobotsErrorRFC9309 retries per Options.Retry and then disallows every
+	// sitemap and URL on the host, as RFC 9309 requires.
+	RobotsErrorRFC9309
+	// RobotsErrorFail retries per Options.Retry and then fails the walk with
+	// *ErrRobotsUnavailable.
+	RobotsErrorFail
+)
+
+// Hooks receive traversal events, e.g. for metrics or progress reporting.
+// Nil callbacks are skipped. Callbacks run synchronously on the walking
+// goroutine.
+type Hooks struct {
+	// OnSitemapStart is called before a sitemap is fetched.
+	OnSitemapStart func(loc *url.URL)
+	// OnSitemapDone is called once a sitemap has been handled, with the
+	// number of URLs yielded from it, the decompressed bytes read, and the
+	// error that stopped the walk, if any. Skipped sitemaps (probe misses,
+	// unchanged cache entries) report zero URLs and a nil error.
+	OnSitemapDone func(loc *url.URL, urlCount int, bytes int64, duration time.Duration, err error)
+	// OnRobotsFetched is called once per host after robots.txt was
+	// requested; found is false when it was missing or unreadable.
+	OnRobotsFetched func(host string, found bool)
+	// OnQueueChange is called whenever sitemaps are added to or taken from
+	// the walk queue, with the number still waiting to be fetched.
+	OnQueueChange func(pending int)
+	// OnSitemapStats is called after OnSitemapDone with the record added to
+	// WalkResult.Sitemaps, e.g. for inventory tools that need each
+	// document's type and entry count as the walk goes.
+	OnSitemapStats func(stats SitemapStats)
+	// OnSitemapSkipped is called for a listed sitemap that is not fetched,
+	// with the reason, one of the Skip* constants.
+	OnSitemapSkipped func(loc *url.URL, reason string)
+}
+
+// Reasons passed to Hooks.OnSitemapSkipped.
+const (
+	SkipMaxDepth      = "max-depth"      // beyond MaxDepth with SkipDeepSitemaps
+	SkipModifiedAfter = "modified-after" // index lastmod before ModifiedAfter
+	SkipUnchanged     = "unchanged"      // index lastmod not after the StateStore's
+)
+
+func (h Hooks) sitemapStart(loc *url.URL) {
+	if h.OnSitemapStart != nil {
+		h.OnSitemapStart(cloneURL(loc))
+	}
+}
+
+func (h Hooks) sitemapDone(loc *url.URL, urlCount int, bytes int64, duration time.Duration, err error) {
+	if h.OnSitemapDone != nil {
+		h.OnSitemapDone(cloneURL(loc), urlCount, bytes, duration, err)
+	}
+}
+
+func (h Hooks) sitemapStats(stats SitemapStats) {
+	if h.OnSitemapStats != nil {
+		h.OnSitemapStats(stats)
+	}
+}
+
+func (h Hooks) robotsFetched(host string, found bool) {
+	if h.OnRobotsFetched != nil {
+		h.OnRobotsFetched(host, found)
+	}
+}
+
+func (h Hooks) queueChange(pending int) {
+	if h.OnQueueChange != nil {
+		h.OnQueueChange(pending)
+	}
+}
+
+func (h Hooks) sitemapSkipped(loc *url.URL, reason string) {
+	if h.OnSitemapSkipped != nil {
+		h.OnSitemapSkipped(cloneURL(loc), reason)
+	}
 }
 
+// PriorityPolicy decides what happens to priorities outside [0.0, 1.0].
+type PriorityPolicy int
+
+const (
+	// PriorityKeep passes out-of-range priorities through unchanged (default).
+	PriorityKeep PriorityPolicy = iota
+	// PriorityClamp clamps out-of-range priorities into [0.0, 1.0].
+	PriorityClamp
+	// PriorityReject drops out-of-range priorities, leaving Item.Priority nil.
+	PriorityReject
+)
+
 // SitemapFetcher streams sitemap URLs and implements SitemapWalker.
 type SitemapFetcher struct {
 	opts   Options
@@ -60,61 +540,243 @@ func New(opts Options) *SitemapFetcher {
 		opts.HTTPClient = http.DefaultClient
 	}
 	if opts.UserAgent == "" {
-		opts.UserAgent = defaultUserAgent
+		opts.UserAgent = DefaultUserAgent
+	}
+	opts.UserAgentSuffix = strings.TrimSpace(opts.UserAgentSuffix)
+	if opts.UserAgentSuffix != "" {
+		opts.UserAgent = opts.UserAgent + " " + opts.UserAgentSuffix
+	}
+	if opts.RobotsAgent == "" {
+		opts.RobotsAgent = opts.UserAgent
 	}
 	if opts.Logger == nil {
 		opts.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
+	if opts.MaxSitemapBytes == 0 {
+		opts.MaxSitemapBytes = DefaultMaxSitemapBytes
+	}
+	if opts.MaxElementBytes == 0 {
+		opts.MaxElementBytes = defaultMaxElementBytes
+	}
+	if opts.Decoder.MaxTokenBytes == 0 {
+		opts.Decoder.MaxTokenBytes = defaultMaxTokenBytes
+	}
+	if opts.Decoder.MaxNesting == 0 {
+		opts.Decoder.MaxNesting = defaultMaxNesting
+	}
+	opts.Retry = opts.Retry.withDefaults()
+	if opts.MaxCrawlDelay <= 0 {
+		opts.MaxCrawlDelay = defaultMaxCrawlDelay
+	}
 	return &SitemapFetcher{
 		opts:   opts,
-		client: opts.HTTPClient,
+		client: configureClient(opts.HTTPClient, opts),
 		logger: opts.Logger,
 	}
 }
 
 // Walk traverses sitemaps discovered from the given website or sitemap URL.
+// A file:// URL is read from the local filesystem as a sitemap.
 func (f *SitemapFetcher) Walk(ctx context.Context, website *url.URL, yield func(Item) error) error {
+	_, err := f.WalkWithResult(ctx, website, yield)
+	return err
+}
+
+// WalkWithResult is Walk returning aggregate statistics about the traversal.
+// The result is never nil and covers the work done up to an error.
+func (f *SitemapFetcher) WalkWithResult(ctx context.Context, website *url.URL, yield func(Item) error) (*WalkResult, error) {
+	result := &WalkResult{}
+	started := time.Now()
+	err := f.walk(ctx, website, yield, result, nil)
+	result.Duration = time.Since(started)
+	return result, err
+}
+
+// ListSitemaps discovers every sitemap reachable from website, like Walk,
+// without yielding page URLs. Indexes are downloaded and followed; other
+// sitemaps are only read up to their root element to tell their type, unless
+// Options.CountSitemapURLs asks for their entries to be counted. Sitemaps
+// are listed in fetch order; on error, those handled so far are returned
+// with it.
+func (f *SitemapFetcher) ListSitemaps(ctx context.Context, website *url.URL) ([]SitemapInfo, error) {
+	list := []SitemapInfo{}
+	err := f.walk(ctx, website, func(Item) error { return nil }, &WalkResult{}, &list)
+	return list, err
+}
+
+// errSkimmed stops parsing a sitemap ListSitemaps lists without counting.
+var errSkimmed = errors.New("sitemap not counted")
+
+// walk traverses the sitemaps. When list is non-nil, page URLs are only
+// counted and each sitemap is appended to list.
+func (f *SitemapFetcher) walk(ctx context.Context, website *url.URL, yield func(Item) error, result *WalkResult, list *[]SitemapInfo) (err error) {
 	if yield == nil {
 		return &ErrNilYield{}
 	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = f.withWalkLogger(ctx)
+	f.debug(ctx, "walk started", urlAttr("url", website))
+	if f.opts.LimitBehavior == LimitStop {
+		defer func() {
+			if isLimitError(err) {
+				result.LimitReached = err
+				err = nil
+			}
+		}()
+	}
+	if f.opts.MaxDuration > 0 {
+		deadline := &ErrDeadline{MaxDuration: f.opts.MaxDuration}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, f.opts.MaxDuration, deadline)
+		defer cancel()
+		// Whatever failed once the budget ran out, report the budget.
+		defer func() {
+			if err != nil && context.Cause(ctx) == deadline {
+				err = deadline
+			}
+		}()
+	}
+	walkStarted := time.Now()
+	ctx, budget := f.withByteBudget(ctx)
+	defer func() {
+		result.BytesDownloaded = budget.used.Load()
+		// Likewise, report the byte budget rather than what it broke. Other
+		// errors, e.g. from yield, are returned as they are.
+		var maxBytes *ErrMaxBytes
+		if errors.As(err, &maxBytes) {
+			err = maxBytes
+		}
+	}()
 
+	if website == nil && len(f.opts.Sitemaps) > 0 {
+		website = f.opts.Sitemaps[0]
+	}
 	inputURL, baseURL, err := normalizeInputURL(website)
 	if err != nil {
 		return err
 	}
+	ctx = f.withHeaderHosts(ctx, inputURL)
 
 	robotsCache := map[string]*robotsRules{}
+	lastFetch := map[string]time.Time{}
+	validator := &specValidator{}
+	defer func() { result.SpecWarnings = validator.warnings }()
 	var baseRobots *robotsRules
-	if !f.opts.IgnoreRobots && !isLikelySitemapURL(inputURL) {
-		baseRobots, _ = f.getRobots(ctx, baseURL, robotsCache)
+	if !f.opts.IgnoreRobots && len(f.opts.Sitemaps) == 0 && !isLikelySitemapURL(inputURL) && !isFileURL(inputURL) {
+		if baseRobots, err = f.getRobots(ctx, baseURL, robotsCache); err != nil {
+			return err
+		}
 	}
 
 	initial := f.initialSitemaps(inputURL, baseURL, baseRobots)
-	if len(initial) == 0 {
+	// With NoProbe, a site without robots.txt sitemaps can still be found
+	// through its homepage.
+	probing := len(initial) > 0 && initial[0].allowMissing
+	// A SitemapSource serves sitemaps only, so the live homepage is not read.
+	htmlFallback := f.opts.DiscoverFromHTML && f.opts.SitemapSource == nil && (probing || len(initial) == 0)
+	if len(initial) == 0 && !htmlFallback {
 		return &ErrNoSitemaps{URL: baseURL}
 	}
 
+	if probing && f.opts.SitemapSource == nil && !f.crawlDelayed(baseRobots) {
+		initial = f.probeCandidates(ctx, initial, robotsCache)
+	}
+
 	queue := make([]sitemapTask, 0, len(initial))
 	for _, task := range initial {
 		queue = append(queue, task)
 	}
+	f.opts.Hooks.queueChange(len(queue))
+	// queued counts the entries that were in the queue before the last
+	// sitemap added its children.
+	queued := len(queue)
 
 	seen := make(map[string]struct{}, len(initial))
+	var seenURLs SeenSet
+	if f.opts.DedupURLs {
+		seenURLs = f.opts.SeenSet
+		if seenURLs == nil {
+			seenURLs = NewMapSeenSet()
+		}
+	}
+	// contents maps a sitemap body hash to the first URL that served it.
+	contents := map[[sha256.Size]byte]*url.URL{}
 	var sitemapCount int
-	var urlCount int
+	var probeHit bool
+	var failures []SitemapFailure
+	// sitemapFailed returns nil when the walk may go on without the failed
+	// sitemap, or the error to end it with.
+	sitemapFailed := func(loc *url.URL, err error) error {
+		if !isSitemapFailure(ctx, err) {
+			return err
+		}
+		if f.opts.OnSitemapError != nil {
+			if err := f.opts.OnSitemapError(cloneURL(loc), err); err != nil {
+				return err
+			}
+		} else if !f.opts.OnErrorContinue {
+			return err
+		}
+		f.debug(ctx, "skipping failed sitemap", urlAttr("url", loc), slog.Any("error", err))
+		if f.opts.OnErrorContinue {
+			failures = append(failures, SitemapFailure{URL: cloneURL(loc), Err: err})
+		}
+		return nil
+	}
+	htmlTried := !htmlFallback
 
-	for len(queue) > 0 {
+	for {
+		if len(queue) == 0 {
+			if probeHit || htmlTried {
+				break
+			}
+			htmlTried = true
+			queue = append(queue, f.discoverFromHTML(ctx, baseURL)...)
+			if len(queue) == 0 && !probing {
+				return &ErrNoSitemaps{URL: baseURL}
+			}
+			f.opts.Hooks.queueChange(len(queue))
+			continue
+		}
 		if err := ctx.Err(); err != nil {
 			return err
 		}
+		queue = f.orderQueue(queue, queued)
+		if f.opts.StopWhen != nil && f.opts.StopWhen(Progress{
+			SitemapsFetched: result.SitemapsFetched,
+			SitemapsQueued:  len(queue),
+			URLsYielded:     result.URLsYielded,
+			URLsFiltered:    result.URLsFiltered,
+			BytesRead:       result.BytesRead,
+			BytesDownloaded: budget.used.Load(),
+			Elapsed:         time.Since(walkStarted),
+		}) {
+			f.debug(ctx, "walk stopped by StopWhen", slog.Int("queued", len(queue)))
+			result.Stopped = true
+			break
+		}
 		current := queue[0]
 		queue = queue[1:]
+		queued = len(queue)
+		f.opts.Hooks.queueChange(len(queue))
+
+		// Probing stops at the first candidate that exists, as it does
+		// with HEAD requests.
+		if current.allowMissing && probeHit {
+			f.debug(ctx, "skipping probe candidate after a hit", urlAttr("url", current.loc))
+			continue
+		}
 
 		if f.opts.MaxDepth > 0 && current.depth > f.opts.MaxDepth {
-			return &ErrMaxDepth{MaxDepth: f.opts.MaxDepth, URL: current.loc}
+			if !f.opts.SkipDeepSitemaps {
+				return &ErrMaxDepth{MaxDepth: f.opts.MaxDepth, URL: current.loc}
+			}
+			f.debug(ctx, "skipping sitemap beyond max depth", urlAttr("url", current.loc), slog.Int("depth", current.depth), slog.Int("max_depth", f.opts.MaxDepth))
+			result.SitemapsSkipped++
+			f.opts.Hooks.sitemapSkipped(current.loc, SkipMaxDepth)
+			continue
 		}
 
 		key := canonicalURLKey(current.loc)
@@ -123,13 +785,25 @@ func (f *SitemapFetcher) Walk(ctx context.Context, website *url.URL, yield func(
 		}
 		seen[key] = struct{}{}
 
-		if !f.opts.IgnoreRobots {
+		// A remote document must not make the walk read from local disk.
+		if hop := current.provenance[len(current.provenance)-1]; isFileURL(current.loc) &&
+			(!isFileURL(inputURL) || (hop.Ref != nil && !isFileURL(hop.Ref))) {
+			err := &ErrLocalSitemap{URL: cloneURL(current.loc), Ref: cloneURL(hop.Ref)}
+			f.debug(ctx, "refusing local sitemap listed by a remote document", urlAttr("url", current.loc), urlAttr("ref", hop.Ref))
+			if err := sitemapFailed(current.loc, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.checkRobots(RobotsScopeSitemaps) {
 			allowed, err := f.allowedByRobots(ctx, current.loc, robotsCache)
 			if err != nil {
 				return err
 			}
 			if !allowed {
-				f.logger.Debug(fmt.Sprintf("robots.txt disallows sitemap %s", current.loc))
+				f.debug(ctx, "robots.txt disallows sitemap", urlAttr("url", current.loc))
+				result.RobotsBlockedSitemaps++
 				continue
 			}
 		}
@@ -139,75 +813,320 @@ func (f *SitemapFetcher) Walk(ctx context.Context, website *url.URL, yield func(
 		}
 		sitemapCount++
 
-		reader, err := f.fetchSitemap(ctx, current.loc, current.allowMissing)
+		if !f.opts.IgnoreRobots && !f.opts.IgnoreCrawlDelay {
+			if err := f.waitCrawlDelay(ctx, current.loc, robotsCache, lastFetch); err != nil {
+				return err
+			}
+		}
+
+		started := time.Now()
+		f.opts.Hooks.sitemapStart(current.loc)
+		hop := current.provenance[len(current.provenance)-1]
+		spanCtx, span := f.startSpan(ctx, SpanSitemapFetch, current.loc,
+			slog.String(AttrSitemapVia, hop.Via), slog.Int(AttrSitemapDepth, current.depth))
+		var aliasOf, finalURL *url.URL
+		var redirects []*url.URL
+		var rootName string
+		var head []byte // first bytes of the document, see sitemapType
+		var fileURLs, fileSitemaps, fileYielded int
+		finish := func(urls int, bytes int64, err error) {
+			if f.opts.LimitBehavior == LimitStop && isLimitError(err) {
+				err = nil
+			}
+			duration := time.Since(started)
+			span.SetAttributes(slog.Int(AttrSitemapURLs, urls), slog.Int64(AttrSitemapBytes, bytes))
+			span.End(err)
+			result.BytesRead += bytes
+			if aliasOf != nil {
+				result.SitemapAliases++
+			}
+			stats := SitemapStats{
+				URL:       cloneURL(current.loc),
+				URLs:      urls,
+				Entries:   fileURLs + fileSitemaps,
+				Bytes:     bytes,
+				Duration:  duration,
+				LastMod:   hop.LastMod,
+				Err:       err,
+				AliasOf:   cloneURL(aliasOf),
+				FinalURL:  cloneURL(finalURL),
+				Redirects: redirects,
+			}
+			if aliasOf == nil && bytes > 0 {
+				stats.Type = sitemapType(rootName, head)
+			}
+			result.Sitemaps = append(result.Sitemaps, stats)
+			f.opts.Hooks.sitemapDone(current.loc, urls, bytes, duration, err)
+			f.opts.Hooks.sitemapStats(stats)
+		}
+		reader, source, err := f.openWithMirrors(spanCtx, current.loc, current.allowMissing, inputURL.Host)
 		if err != nil {
-			return err
+			finish(0, 0, err)
+			if err := sitemapFailed(current.loc, err); err != nil {
+				return err
+			}
+			continue
 		}
 		if reader == nil {
+			span.End(nil)
+			f.opts.Hooks.sitemapDone(current.loc, 0, 0, time.Since(started), nil)
 			continue
 		}
+		probeHit = true
+		result.SitemapsFetched++
+		if source != current.loc {
+			current.provenance[len(current.provenance)-1].Source = cloneURL(source)
+			span.SetAttributes(slog.String(AttrSitemapSource, source.String()))
+		}
+		// Relative entries resolve against the URL that served the sitemap
+		// after redirects; mirrors keep the walked site's URL.
+		base := current.loc
+		var etag string
+		if fetched, ok := reader.(*fetchedBody); ok {
+			etag = fetched.etag
+			if fetched.final != nil {
+				finalURL, redirects = fetched.final, fetched.chain
+				if source == current.loc {
+					base = finalURL
+				}
+				f.debug(ctx, "sitemap redirected", urlAttr("url", current.loc), urlAttr("final_url", finalURL))
+			}
+		}
 
-		err = parseSitemap(ctx, reader, func(entry xmlURLEntry) error {
-			loc, err := resolveLocation(current.loc, entry.Loc)
+		var bytesRead int64
+		// skipURLs and skipSitemaps count entries already handled before a
+		// corrupt gzip stream forced the sitemap to be fetched again.
+		var skipURLs, skipSitemaps int
+		parser := sitemapParser{
+			maxElementBytes:   f.opts.MaxElementBytes,
+			decoder:           f.opts.Decoder,
+			captureExtensions: f.opts.CaptureExtensions,
+			extensionNames:    len(f.opts.RequireExtensions) > 0,
+			detectCDATA:       f.opts.StrictSpec,
+			requireNamespace:  f.opts.RequireNamespace,
+			lenient:           f.opts.LenientXML,
+		}
+		parser.onMalformed = func(err error) {
+			f.debug(ctx, "malformed XML, keeping the entries before it", urlAttr("url", current.loc), slog.Any("error", err))
+		}
+		var skimmed bool
+		parser.onRoot = func(root xml.StartElement) error {
+			rootName = root.Name.Local
+			if f.opts.StrictSpec && skipURLs+skipSitemaps == 0 {
+				validator.checkRoot(current.loc, root)
+			}
+			if list != nil && !f.opts.CountSitemapURLs && rootName != "sitemapindex" {
+				skimmed = true
+				return errSkimmed
+			}
+			return nil
+		}
+		parser.onURL = func(entry xmlURLEntry) error {
+			fileURLs++
+			if fileURLs <= skipURLs {
+				return nil
+			}
+			if list != nil {
+				fileYielded++
+				return nil
+			}
+			loc, err := resolveLocation(base, entry.Loc)
 			if err != nil {
-				f.logger.Debug(fmt.Sprintf("invalid URL %q in %s: %v", entry.Loc, current.loc, err))
+				if f.debugEnabled(ctx) {
+					f.debug(ctx, "invalid URL", slog.String("loc", entry.Loc), urlAttr("sitemap", current.loc), slog.Any("error", err))
+				}
+				return nil
+			}
+			if f.opts.Normalize.enabled() {
+				loc = f.opts.Normalize.Apply(loc)
+			}
+			if f.opts.StrictSpec && !validator.checkURLEntry(current.loc, base, loc, entry, fileURLs) {
+				result.URLsFiltered++
+				return nil
+			}
+			if len(f.opts.RequireExtensions) > 0 && !entry.hasAnyExtension(f.opts.RequireExtensions) {
+				result.URLsFiltered++
+				return nil
+			}
+			// The host is that of the URL that served the sitemap after
+			// redirects, like the base relative entries resolve against.
+			if f.opts.CrossHostPolicy != CrossHostAllow && !isFileURL(base) && !strings.EqualFold(loc.Host, base.Host) {
+				if f.opts.CrossHostPolicy == CrossHostError {
+					return &ErrCrossHost{Sitemap: cloneURL(current.loc), URL: loc}
+				}
+				if f.debugEnabled(ctx) {
+					f.debug(ctx, "skipping cross-host URL", urlAttr("url", loc), urlAttr("sitemap", current.loc))
+				}
+				result.URLsFiltered++
 				return nil
 			}
-			if !f.opts.IgnoreRobots {
+			if f.checkRobots(RobotsScopeURLs) {
 				allowed, err := f.allowedByRobots(ctx, loc, robotsCache)
 				if err != nil {
 					return err
 				}
 				if !allowed {
-					f.logger.Debug(fmt.Sprintf("robots.txt disallows URL %s", loc))
+					if f.debugEnabled(ctx) {
+						f.debug(ctx, "robots.txt disallows URL", urlAttr("url", loc))
+					}
+					result.RobotsBlockedURLs++
 					return nil
 				}
 			}
 			if !f.shouldInclude(loc) {
+				result.URLsFiltered++
 				return nil
 			}
-			if f.opts.MaxURLs > 0 && urlCount >= f.opts.MaxURLs {
-				return &ErrMaxURLs{MaxURLs: f.opts.MaxURLs}
+			lastMod, lastModOffset := f.lastMod(entry.LastMod)
+			if !f.inModifiedWindow(lastMod) {
+				result.URLsFiltered++
+				return nil
 			}
 			item := Item{
-				Loc:        loc,
-				LastMod:    parseTimeValue(entry.LastMod),
-				ChangeFreq: strings.TrimSpace(entry.ChangeFreq),
-				Priority:   parsePriority(entry.Priority),
-				Sitemap:    cloneURL(current.loc),
+				Loc:            loc,
+				LastMod:        lastMod,
+				LastModOffset:  lastModOffset,
+				ChangeFreq:     ParseChangeFreq(entry.ChangeFreq),
+				Priority:       f.priority(ctx, entry.Priority, loc),
+				Sitemap:        cloneURL(current.loc),
+				SitemapLastMod: hop.LastMod,
+				Provenance:     current.provenance,
+				Extensions:     entry.captured,
+			}
+			item.RawLastMod, item.RawPriority = rawMetadata(entry, item.LastMod, item.Priority)
+			if f.opts.Filter != nil && !f.opts.Filter(item) {
+				result.URLsFiltered++
+				return nil
+			}
+			key := canonicalURLKey(loc)
+			if seenURLs != nil && seenURLs.Contains(key) {
+				result.URLsDuplicate++
+				return nil
+			}
+			if f.opts.MaxURLs > 0 && result.URLsYielded >= f.opts.MaxURLs {
+				return &ErrMaxURLs{MaxURLs: f.opts.MaxURLs}
 			}
 			if err := yield(item); err != nil {
 				return &ErrYield{Err: err}
 			}
-			urlCount++
+			// Only yielded URLs count as seen, so a shared SeenSet does not
+			// drop URLs a stopped walk never delivered.
+			if seenURLs != nil {
+				seenURLs.Add(key)
+			}
+			result.URLsYielded++
+			fileYielded++
 			return nil
-		}, func(entry xmlSitemapEntry) error {
-			loc, err := resolveLocation(current.loc, entry.Loc)
+		}
+		parser.onSitemap = func(entry xmlSitemapEntry) error {
+			fileSitemaps++
+			if fileSitemaps <= skipSitemaps {
+				return nil
+			}
+			if f.opts.StrictSpec && !validator.checkSitemapEntry(current.loc, entry, fileSitemaps) {
+				return nil
+			}
+			loc, err := resolveLocation(base, entry.Loc)
 			if err != nil {
-				f.logger.Debug(fmt.Sprintf("invalid sitemap URL %q in %s: %v", entry.Loc, current.loc, err))
+				f.debug(ctx, "invalid sitemap URL", slog.String("loc", entry.Loc), urlAttr("sitemap", current.loc), slog.Any("error", err))
+				return nil
+			}
+			indexLastMod, _ := f.lastMod(entry.LastMod)
+			if f.opts.YieldSitemaps != nil {
+				ref := SitemapRef{Loc: cloneURL(loc), LastMod: indexLastMod, Index: cloneURL(current.loc), Depth: current.depth + 1}
+				if err := f.opts.YieldSitemaps(ref); err != nil {
+					return &ErrYield{Err: err}
+				}
+			}
+			if indexLastMod != nil && !f.opts.ModifiedAfter.IsZero() && indexLastMod.Before(f.opts.ModifiedAfter) {
+				f.debug(ctx, "skipping sitemap modified before ModifiedAfter", urlAttr("url", loc), slog.Time("lastmod", *indexLastMod), slog.Time("modified_after", f.opts.ModifiedAfter))
+				result.SitemapsSkipped++
+				f.opts.Hooks.sitemapSkipped(loc, SkipModifiedAfter)
+				return nil
+			}
+			if f.unchangedSince(ctx, loc, indexLastMod) {
+				f.debug(ctx, "skipping unchanged sitemap", urlAttr("url", loc), slog.Time("lastmod", *indexLastMod))
+				result.SitemapsSkipped++
+				f.opts.Hooks.sitemapSkipped(loc, SkipUnchanged)
 				return nil
 			}
-			queue = append(queue, sitemapTask{loc: loc, depth: current.depth + 1})
+			queue = append(queue, current.child(loc, indexLastMod))
+			f.opts.Hooks.queueChange(len(queue))
 			return nil
-		})
-		reader.Close()
-		if err != nil {
-			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-				return err
+		}
+		parse := func(reader io.ReadCloser) error {
+			defer reader.Close()
+			counted := &countingReader{ReadCloser: reader, n: &bytesRead}
+			var body io.Reader = f.limitSitemap(counted, current.loc)
+			if f.opts.SkipDuplicateSitemaps {
+				data, err := io.ReadAll(body)
+				if err != nil {
+					return parseFailure(current.loc, err)
+				}
+				sum := sha256.Sum256(data)
+				if first, ok := contents[sum]; ok {
+					f.debug(ctx, "skipping sitemap with duplicate content", urlAttr("url", current.loc), urlAttr("alias_of", first))
+					aliasOf = first
+					return nil
+				}
+				contents[sum] = current.loc
+				body = bytes.NewReader(data)
 			}
-			var maxURLs *ErrMaxURLs
-			if errors.As(err, &maxURLs) {
-				return err
+			recorder := &headRecorder{r: body}
+			err := parser.parse(ctx, recorder)
+			head = recorder.head
+			if err != nil && !errors.Is(err, errSkimmed) {
+				return parseFailure(current.loc, err)
 			}
-			var yieldErr *ErrYield
-			if errors.As(err, &yieldErr) {
+			return nil
+		}
+		err = parse(reader)
+		if err != nil && isGzipCorruption(err) && f.opts.SitemapSource == nil && !isFileURL(source) {
+			f.debug(ctx, "corrupt gzip stream, fetching it again uncompressed", urlAttr("url", source), slog.Any("error", err))
+			reader, err = f.fetchSitemap(spanCtx, source, current.allowMissing, "identity")
+			if err == nil && reader != nil {
+				skipURLs, skipSitemaps = fileURLs, fileSitemaps
+				fileURLs, fileSitemaps = 0, 0
+				err = parse(reader)
+			}
+		}
+		finish(fileYielded, bytesRead, err)
+		if err != nil {
+			if err := sitemapFailed(current.loc, err); err != nil {
 				return err
 			}
-			return &ErrSitemapParse{URL: current.loc, Err: err}
+			continue
+		}
+		// An index is not recorded: skipping it later would also skip
+		// children that changed without the index noticing. Nor is anything
+		// ListSitemaps opened, since its URLs were never yielded.
+		if list == nil && rootName != "sitemapindex" {
+			f.recordState(ctx, current.loc, hop.LastMod, etag)
+		}
+		if list != nil {
+			*list = append(*list, SitemapInfo{
+				URL:      cloneURL(current.loc),
+				Type:     result.Sitemaps[len(result.Sitemaps)-1].Type,
+				Depth:    current.depth,
+				LastMod:  hop.LastMod,
+				Via:      hop.Via,
+				Ref:      cloneURL(hop.Ref),
+				URLs:     fileYielded,
+				Sitemaps: fileSitemaps,
+				Bytes:    bytesRead,
+				Counted:  !skimmed,
+			})
 		}
 	}
 
-	return nil
+	if len(failures) == 0 {
+		return validator.err()
+	}
+	partial := &ErrPartial{Failures: failures}
+	if specErr := validator.err(); specErr != nil {
+		return errors.Join(partial, specErr)
+	}
+	return partial
 }
 
 // ===================== Internal Types =====================
@@ -217,11 +1136,47 @@ type sitemapTask struct {
 	depth int
 	// allowMissing treats 404 responses as a non-fatal probe miss.
 	allowMissing bool
+	// provenance ends with the hop for loc itself.
+	provenance []Hop
+}
+
+func rootTask(loc *url.URL, via string, ref *url.URL, allowMissing bool) sitemapTask {
+	return sitemapTask{
+		loc:          loc,
+		depth:        0,
+		allowMissing: allowMissing,
+		provenance:   []Hop{{URL: cloneURL(loc), Via: via, Ref: cloneURL(ref), Depth: 0}},
+	}
+}
+
+// lastMod is the lastmod the parent index gave the task, if any.
+func (t sitemapTask) lastMod() *time.Time {
+	return t.provenance[len(t.provenance)-1].LastMod
+}
+
+func (t sitemapTask) child(loc *url.URL, lastMod *time.Time) sitemapTask {
+	provenance := make([]Hop, len(t.provenance), len(t.provenance)+1)
+	copy(provenance, t.provenance)
+	provenance = append(provenance, Hop{
+		URL:     cloneURL(loc),
+		Via:     ViaIndex,
+		Ref:     cloneURL(t.loc),
+		Depth:   t.depth + 1,
+		LastMod: lastMod,
+	})
+	return sitemapTask{loc: loc, depth: t.depth + 1, provenance: provenance}
 }
 
 type robotsRules struct {
-	group    *robotstxt.Group
-	sitemaps []*url.URL
+	group      *robotstxt.Group
+	sitemaps   []*url.URL
+	crawlDelay time.Duration
+
+	// found is set when robots.txt was served and parsed.
+	found bool
+	// disallowAll blocks the host after an unreachable robots.txt under
+	// RobotsErrorRFC9309.
+	disallowAll bool
 }
 
 type xmlURLEntry struct {
@@ -229,38 +1184,89 @@ type xmlURLEntry struct {
 	LastMod    string `xml:"lastmod"`
 	ChangeFreq string `xml:"changefreq"`
 	Priority   string `xml:"priority"`
+	// Extensions holds the names of extension elements such as <image:image>,
+	// decoded only with sitemapParser.extensionNames or captureExtensions.
+	Extensions []xmlExtension `xml:"-"`
+	// captured holds the whole extension elements with CaptureExtensions.
+	captured []ExtensionElement
+	// line is the line of the <url> start tag, reported in SpecViolation.
+	line int
+	// cdata is set when the element contained a CDATA section; only
+	// detected with sitemapParser.detectCDATA.
+	cdata bool
 }
 
 type xmlSitemapEntry struct {
 	Loc     string `xml:"loc"`
 	LastMod string `xml:"lastmod"`
+	line    int
+	cdata   bool
+}
+
+type cancelCloser struct {
+	cancel context.CancelFunc
 }
 
-type readCloser struct {
-	reader io.Reader
-	close  func() error
+func (c cancelCloser) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
 }
 
-func (r *readCloser) Read(p []byte) (int, error) {
-	return r.reader.Read(p)
+// sizeLimitedReader fails with ErrSitemapTooLarge once more than remaining
+// bytes have been read from the (decompressed) sitemap stream.
+type sizeLimitedReader struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+	loc       *url.URL
 }
 
-func (r *readCloser) Close() error {
-	if r.close == nil {
-		return nil
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// Allow a clean EOF exactly at the limit.
+		var probe [1]byte
+		if n, err := r.ReadCloser.Read(probe[:]); n == 0 && err != nil {
+			return 0, err
+		}
+		return 0, &ErrSitemapTooLarge{URL: r.loc, Limit: r.limit}
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
 	}
-	return r.close()
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	return n, err
 }
 
-type cancelCloser struct {
-	cancel context.CancelFunc
+// countingReader adds the bytes read through it to n.
+type countingReader struct {
+	io.ReadCloser
+	n *int64
 }
 
-func (c cancelCloser) Close() error {
-	if c.cancel != nil {
-		c.cancel()
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.n += int64(n)
+	return n, err
+}
+
+// headRecorder keeps the first sniffBytes read through it, so a document
+// without an XML root can still be classified.
+type headRecorder struct {
+	r    io.Reader
+	head []byte
+}
+
+const sniffBytes = 512
+
+func (h *headRecorder) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if room := sniffBytes - len(h.head); room > 0 {
+		h.head = append(h.head, p[:min(n, room)]...)
 	}
-	return nil
+	return n, err
 }
 
 type multiCloser struct {
@@ -289,6 +1295,17 @@ func normalizeInputURL(website *url.URL) (*url.URL, *url.URL, error) {
 		return nil, nil, &ErrInvalidURL{Err: errors.New("nil URL")}
 	}
 	input := *website
+	if isFileURL(&input) {
+		if input.Host != "" && input.Host != "localhost" {
+			return nil, nil, &ErrInvalidURL{URL: website.String(), Err: errors.New("file URL must not name a remote host")}
+		}
+		if input.Path == "" {
+			return nil, nil, &ErrInvalidURL{URL: website.String(), Err: errors.New("missing path")}
+		}
+		input.Host = ""
+		input.Fragment = ""
+		return &input, &url.URL{Scheme: "file"}, nil
+	}
 	if input.Scheme == "" {
 		input.Scheme = "https"
 	}
@@ -301,51 +1318,162 @@ func normalizeInputURL(website *url.URL) (*url.URL, *url.URL, error) {
 }
 
 func (f *SitemapFetcher) initialSitemaps(input, base *url.URL, robots *robotsRules) []sitemapTask {
-	if isLikelySitemapURL(input) {
-		return []sitemapTask{{loc: cloneURL(input), depth: 0}}
+	if len(f.opts.Sitemaps) > 0 {
+		tasks := make([]sitemapTask, 0, len(f.opts.Sitemaps))
+		for _, loc := range f.opts.Sitemaps {
+			if loc != nil {
+				tasks = append(tasks, rootTask(input.ResolveReference(loc), ViaSeed, nil, false))
+			}
+		}
+		return tasks
+	}
+	if isLikelySitemapURL(input) || isFileURL(input) {
+		return []sitemapTask{rootTask(cloneURL(input), ViaInput, nil, false)}
 	}
 	if robots != nil && len(robots.sitemaps) > 0 {
 		tasks := make([]sitemapTask, 0, len(robots.sitemaps))
+		robotsURL := base.ResolveReference(&url.URL{Path: "/robots.txt"})
 		for _, loc := range robots.sitemaps {
-			tasks = append(tasks, sitemapTask{loc: loc, depth: 0})
+			tasks = append(tasks, rootTask(loc, ViaRobots, robotsURL, false))
+		}
+		if f.opts.Deterministic {
+			sortTasks(tasks)
 		}
 		return tasks
 	}
-	paths := defaultSitemaps(base)
+	if f.opts.NoProbe {
+		return nil
+	}
+	paths := sitemapCandidates(base, f.opts.SitemapCandidates)
 	tasks := make([]sitemapTask, 0, len(paths))
 	for _, loc := range paths {
-		tasks = append(tasks, sitemapTask{loc: loc, depth: 0, allowMissing: true})
+		tasks = append(tasks, rootTask(loc, ViaProbe, nil, true))
 	}
 	return tasks
 }
 
+// crawlDelayed reports whether robots asks for a Crawl-delay the walk
+// honors, in which case candidates are not probed concurrently.
+func (f *SitemapFetcher) crawlDelayed(robots *robotsRules) bool {
+	return robots != nil && robots.crawlDelay > 0 && !f.opts.IgnoreRobots && !f.opts.IgnoreCrawlDelay
+}
+
 func isLikelySitemapURL(u *url.URL) bool {
 	if u == nil {
 		return false
 	}
 	path := strings.ToLower(u.Path)
-	return strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz")
+	if strings.HasSuffix(path, ".xml") || strings.HasSuffix(path, ".xml.gz") {
+		return true
+	}
+	// e.g. sitemap.xml.xz with a registered ".xz" codec.
+	codec, trimmed := matchExtension(path, registeredCodecs())
+	return codec != nil && strings.HasSuffix(trimmed, ".xml")
+}
+
+// DefaultSitemapCandidates are the paths probed when robots.txt lists no
+// sitemaps and Options.SitemapCandidates is empty. Extend a copy for
+// CMS-specific layouts, e.g. "/sitemap/sitemap.xml".
+var DefaultSitemapCandidates = []string{
+	"/sitemap.xml",
+	"/sitemap_index.xml",
+	"/sitemap-index.xml",
+	"/wp-sitemap.xml",
+	"/sitemap.xml.gz",
+	"/sitemap_index.xml.gz",
+	"/sitemap-index.xml.gz",
 }
 
-func defaultSitemaps(base *url.URL) []*url.URL {
-	candidates := []string{
-		"/sitemap.xml",
-		"/sitemap_index.xml",
-		"/sitemap-index.xml",
-		"/sitemap.xml.gz",
-		"/sitemap_index.xml.gz",
-		"/sitemap-index.xml.gz",
+func sitemapCandidates(base *url.URL, paths []string) []*url.URL {
+	if len(paths) == 0 {
+		paths = DefaultSitemapCandidates
 	}
-	out := make([]*url.URL, 0, len(candidates))
-	for _, path := range candidates {
+	out := make([]*url.URL, 0, len(paths))
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
 		out = append(out, base.ResolveReference(&url.URL{Path: path}))
 	}
 	return out
 }
 
-// ===================== Filtering =====================
+var (
+	htmlLinkTag   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	htmlAttr      = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	wordPressHint = regexp.MustCompile(`(?i)/wp-content/|/wp-includes/|<meta[^>]+content=["']?WordPress`)
+)
 
-func (f *SitemapFetcher) shouldInclude(u *url.URL) bool {
+// discoverFromHTML fetches the homepage and collects sitemap URLs from
+// <link rel="sitemap"> tags and well-known CMS hints.
+func (f *SitemapFetcher) discoverFromHTML(ctx context.Context, base *url.URL) []sitemapTask {
+	home := base.ResolveReference(&url.URL{Path: "/"})
+	req, cancel, err := f.newRequest(ctx, http.MethodGet, home)
+	if err != nil {
+		return nil
+	}
+	defer cancel()
+
+	resp, err := f.do(req)
+	if err != nil {
+		f.debug(ctx, "homepage fetch failed", urlAttr("url", home), slog.Any("error", err))
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		f.debug(ctx, "non-200 status for homepage", urlAttr("url", home), slog.Int("status", resp.StatusCode))
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTMLBytes))
+	if err != nil {
+		return nil
+	}
+
+	pageURL := home
+	if resp.Request != nil && resp.Request.URL != nil {
+		pageURL = resp.Request.URL
+	}
+
+	var tasks []sitemapTask
+	for _, tag := range htmlLinkTag.FindAll(body, -1) {
+		attrs := map[string]string{}
+		for _, m := range htmlAttr.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(m[1]))] = string(m[2]) + string(m[3]) + string(m[4])
+		}
+		if !strings.EqualFold(strings.TrimSpace(attrs["rel"]), "sitemap") {
+			continue
+		}
+		loc, err := resolveLocation(pageURL, attrs["href"])
+		if err != nil {
+			f.debug(ctx, "invalid sitemap link", slog.String("href", attrs["href"]), urlAttr("page", pageURL), slog.Any("error", err))
+			continue
+		}
+		tasks = append(tasks, rootTask(loc, ViaHTML, pageURL, false))
+	}
+	if wordPressHint.Match(body) {
+		loc := base.ResolveReference(&url.URL{Path: "/wp-sitemap.xml"})
+		tasks = append(tasks, rootTask(loc, ViaHTML, pageURL, true))
+	}
+	return tasks
+}
+
+// ===================== Filtering =====================
+
+// inModifiedWindow reports whether lastMod passes ModifiedAfter/ModifiedBefore.
+func (f *SitemapFetcher) inModifiedWindow(lastMod *time.Time) bool {
+	if f.opts.ModifiedAfter.IsZero() && f.opts.ModifiedBefore.IsZero() {
+		return true
+	}
+	if lastMod == nil {
+		return false
+	}
+	if !f.opts.ModifiedAfter.IsZero() && lastMod.Before(f.opts.ModifiedAfter) {
+		return false
+	}
+	return f.opts.ModifiedBefore.IsZero() || lastMod.Before(f.opts.ModifiedBefore)
+}
+
+func (f *SitemapFetcher) shouldInclude(u *url.URL) bool {
 	if u == nil {
 		return false
 	}
@@ -380,19 +1508,97 @@ func (f *SitemapFetcher) newRequest(ctx context.Context, method string, u *url.U
 			cancel()
 			return nil, nil, err
 		}
-		req.Header.Set("User-Agent", f.opts.UserAgent)
+		f.setHeaders(req)
 		return req, cancel, nil
 	}
 	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
 	if err != nil {
 		return nil, nil, err
 	}
-	req.Header.Set("User-Agent", f.opts.UserAgent)
+	f.setHeaders(req)
 	return req, func() {}, nil
 }
 
-func (f *SitemapFetcher) fetchSitemap(ctx context.Context, loc *url.URL, allowMissing bool) (io.ReadCloser, error) {
-	for attempt := 0; attempt <= maxRetryAttempts; attempt++ {
+func (f *SitemapFetcher) setHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", f.opts.UserAgent)
+	allowed := headerHostAllowed(req)
+	for name, values := range f.opts.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if !allowed && name != "User-Agent" {
+			continue
+		}
+		req.Header[name] = append([]string(nil), values...)
+		// Options.UserAgent already carries the suffix; an override from
+		// Headers keeps it too, so operators can still contact the crawler.
+		if name == "User-Agent" && f.opts.UserAgentSuffix != "" {
+			if ua := req.Header.Get(name); ua != "" {
+				req.Header.Set(name, ua+" "+f.opts.UserAgentSuffix)
+			}
+		}
+	}
+}
+
+type headerHostsKey struct{}
+
+// withHeaderHosts returns ctx limiting Options.Headers to the hosts of the
+// walk: input, the Options.Sitemaps seeds, and Options.HeaderHosts.
+func (f *SitemapFetcher) withHeaderHosts(ctx context.Context, input *url.URL) context.Context {
+	if len(f.opts.Headers) == 0 {
+		return ctx
+	}
+	hosts := map[string]struct{}{strings.ToLower(input.Host): {}}
+	for _, seed := range f.opts.Sitemaps {
+		if seed != nil && seed.Host != "" {
+			hosts[strings.ToLower(seed.Host)] = struct{}{}
+		}
+	}
+	for _, host := range f.opts.HeaderHosts {
+		hosts[strings.ToLower(strings.TrimSpace(host))] = struct{}{}
+	}
+	return context.WithValue(ctx, headerHostsKey{}, hosts)
+}
+
+// headerHostAllowed reports whether req may carry Options.Headers. Requests
+// made outside a walk go to a URL the caller chose and always may.
+func headerHostAllowed(req *http.Request) bool {
+	hosts, ok := req.Context().Value(headerHostsKey{}).(map[string]struct{})
+	if !ok {
+		return true
+	}
+	if _, ok := hosts[strings.ToLower(req.URL.Host)]; ok {
+		return true
+	}
+	_, ok = hosts[strings.ToLower(req.URL.Hostname())]
+	return ok
+}
+
+// BasicAuthHeader returns an Authorization header value for HTTP Basic
+// authentication, for use in Options.Headers.
+func BasicAuthHeader(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// BearerAuthHeader returns an Authorization header value carrying a bearer
+// token, for use in Options.Headers.
+func BearerAuthHeader(token string) string {
+	return "Bearer " + token
+}
+
+// fetchSitemap fetches loc over HTTP, sending acceptEncoding as the
+// Accept-Encoding header.
+func (f *SitemapFetcher) fetchSitemap(ctx context.Context, loc *url.URL, allowMissing bool, acceptEncoding string) (io.ReadCloser, error) {
+	cacheKey := canonicalURLKey(loc)
+	var cached *CacheEntry
+	if f.opts.Cache != nil {
+		entry, err := f.opts.Cache.Get(ctx, cacheKey)
+		if err != nil {
+			f.debug(ctx, "cache lookup failed", urlAttr("url", loc), slog.Any("error", err))
+		}
+		cached = entry
+	}
+
+	retry := f.opts.Retry
+	for attempt := 0; ; attempt++ {
 		req, cancel, err := f.newRequest(ctx, http.MethodGet, loc)
 		if err != nil {
 			if cancel != nil {
@@ -400,63 +1606,206 @@ func (f *SitemapFetcher) fetchSitemap(ctx context.Context, loc *url.URL, allowMi
 			}
 			return nil, err
 		}
+		if cached != nil {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+		// Setting the header ourselves keeps net/http from decoding the body,
+		// so the cache and archive see the bytes on the wire.
+		req.Header.Set("Accept-Encoding", acceptEncoding)
 
-		resp, err := f.client.Do(req)
+		resp, err := f.do(req)
 		if err != nil {
 			if cancel != nil {
 				cancel()
 			}
-			return nil, err
+			if attempt >= retry.MaxRetries || ctx.Err() != nil || !retry.retryableError(err) {
+				return nil, &ErrFetch{URL: loc, Attempt: attempt + 1, Err: err}
+			}
+			delay := retry.delay(attempt, nil)
+			f.debug(ctx, "request failed, retrying", urlAttr("url", loc), slog.Int("attempt", attempt+1), slog.Duration("delay", delay), slog.Any("error", err))
+			if err := sleepWithContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
 		}
-		if resp.StatusCode == http.StatusTooManyRequests {
-			delay := retryAfterDelay(resp)
+		f.recordStatus(ctx, resp.StatusCode)
+		if retry.retryableStatus(resp.StatusCode) {
+			delay := retry.delay(attempt, resp)
 			resp.Body.Close()
 			if cancel != nil {
 				cancel()
 			}
-			if attempt == maxRetryAttempts {
+			if attempt >= retry.MaxRetries {
 				return nil, &ErrHTTPStatus{URL: loc, StatusCode: resp.StatusCode, Status: resp.Status}
 			}
-			if delay <= 0 {
-				delay = defaultRetryDelay
-			}
-			if delay > maxRetryDelay {
-				delay = maxRetryDelay
-			}
-			f.logger.Debug(fmt.Sprintf("received 429 for %s, retrying in %s", loc, delay))
+			f.debug(ctx, "retryable status, retrying", urlAttr("url", loc), slog.Int("status", resp.StatusCode), slog.Int("attempt", attempt+1), slog.Duration("delay", delay))
 			if err := sleepWithContext(ctx, delay); err != nil {
 				return nil, err
 			}
 			continue
 		}
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			if cancel != nil {
+				cancel()
+			}
+			reader, err := f.openCached(ctx, loc, cached)
+			if reader == nil || err != nil {
+				return reader, err
+			}
+			etag := resp.Header.Get("ETag")
+			if etag == "" {
+				etag = cached.ETag
+			}
+			return withResponse(reader, resp, etag), nil
+		}
 		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
 			resp.Body.Close()
 			if cancel != nil {
 				cancel()
 			}
 			if f.opts.AllowNon200 {
-				f.logger.Debug(fmt.Sprintf("non-200 status for %s: %s", loc, resp.Status))
+				f.debug(ctx, "non-200 status", urlAttr("url", loc), slog.Int("status", resp.StatusCode))
 				return nil, nil
 			}
 			if allowMissing && resp.StatusCode == http.StatusNotFound {
-				f.logger.Debug(fmt.Sprintf("sitemap not found (probe) %s", loc))
+				f.debug(ctx, "sitemap not found (probe)", urlAttr("url", loc))
 				return nil, nil
 			}
 			return nil, &ErrHTTPStatus{URL: loc, StatusCode: resp.StatusCode, Status: resp.Status}
 		}
+		if encoding := resp.Header.Get("Content-Encoding"); !supportedContentEncoding(encoding) {
+			resp.Body.Close()
+			if cancel != nil {
+				cancel()
+			}
+			return nil, &ErrContentEncoding{URL: loc, Encoding: encoding}
+		}
 
-		reader, err := wrapReader(resp, cancel)
+		body := f.archiveBody(ctx, loc, resp.StatusCode, resp.Header, f.cacheBody(ctx, cacheKey, resp))
+		reader, err := wrapReader(body, loc, resp.Header.Get("Content-Encoding"), cancel)
 		if err != nil {
-			resp.Body.Close()
+			body.Close()
 			if cancel != nil {
 				cancel()
 			}
 			return nil, err
 		}
-		return reader, nil
+		return withResponse(reader, resp, resp.Header.Get("ETag")), nil
+	}
+}
+
+// fetchedBody is a sitemap body carrying what its response said about it.
+type fetchedBody struct {
+	io.ReadCloser
+	etag string
+	// final is the URL that served the body after HTTP redirects, nil when
+	// it was not redirected.
+	final *url.URL
+	// chain lists the URLs that answered with a redirect, in order.
+	chain []*url.URL
+}
+
+// withResponse attaches the ETag and any redirects of resp to reader.
+func withResponse(reader io.ReadCloser, resp *http.Response, etag string) io.ReadCloser {
+	body := &fetchedBody{ReadCloser: reader, etag: etag}
+	if resp.Request != nil && resp.Request.Response != nil {
+		body.final = cloneURL(resp.Request.URL)
+		for req := resp.Request; req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+			body.chain = append([]*url.URL{cloneURL(req.Response.Request.URL)}, body.chain...)
+		}
+	}
+	if body.final == nil && body.etag == "" {
+		return reader
+	}
+	return body
+}
+
+// openCached serves an unchanged sitemap from the cache, or returns nil to
+// skip it when the cache holds validators only.
+func (f *SitemapFetcher) openCached(ctx context.Context, loc *url.URL, cached *CacheEntry) (io.ReadCloser, error) {
+	if cached.Body == nil {
+		f.debug(ctx, "sitemap not modified, skipping", urlAttr("url", loc))
+		return nil, nil
+	}
+	body, err := cached.Body()
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		f.debug(ctx, "sitemap not modified, skipping", urlAttr("url", loc))
+		return nil, nil
+	}
+	f.debug(ctx, "sitemap not modified, using cached copy", urlAttr("url", loc))
+	// The cached copy is what the walk reads, so it is archived like a fresh
+	// response; otherwise an archive would miss every unchanged sitemap.
+	header := http.Header{}
+	for name, value := range map[string]string{
+		"ETag":             cached.ETag,
+		"Last-Modified":    cached.LastModified,
+		"Content-Encoding": cached.ContentEncoding,
+	} {
+		if value != "" {
+			header.Set(name, value)
+		}
+	}
+	body = f.archiveBody(ctx, loc, http.StatusOK, header, body)
+	reader, err := wrapReader(body, loc, cached.ContentEncoding, nil)
+	if err != nil {
+		body.Close()
+		return nil, err
 	}
+	return reader, nil
+}
 
-	return nil, &ErrHTTPStatus{URL: loc, StatusCode: http.StatusTooManyRequests, Status: http.StatusText(http.StatusTooManyRequests)}
+// cacheBody tees a fresh response into the cache when it carries validators.
+func (f *SitemapFetcher) cacheBody(ctx context.Context, key string, resp *http.Response) io.ReadCloser {
+	if f.opts.Cache == nil {
+		return resp.Body
+	}
+	entry := CacheEntry{
+		ETag:            resp.Header.Get("ETag"),
+		LastModified:    resp.Header.Get("Last-Modified"),
+		ContentEncoding: resp.Header.Get("Content-Encoding"),
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return resp.Body
+	}
+	writer, err := f.opts.Cache.Put(ctx, key, entry)
+	if err != nil {
+		f.debug(ctx, "cache store failed", slog.String("key", key), slog.Any("error", err))
+		return resp.Body
+	}
+	if writer == nil {
+		return resp.Body
+	}
+	return &cacheTee{body: resp.Body, writer: writer}
+}
+
+// archiveBody tees a sitemap body, fresh or replayed from the cache, into the
+// configured Archiver.
+func (f *SitemapFetcher) archiveBody(ctx context.Context, loc *url.URL, statusCode int, header http.Header, body io.ReadCloser) io.ReadCloser {
+	if f.opts.Archive == nil {
+		return body
+	}
+	writer, err := f.opts.Archive.Begin(ArchiveMeta{
+		URL:        loc.String(),
+		FetchedAt:  time.Now().UTC(),
+		StatusCode: statusCode,
+		Header:     header.Clone(),
+	})
+	if err != nil || writer == nil {
+		if err != nil {
+			f.debug(ctx, "archive failed", urlAttr("url", loc), slog.Any("error", err))
+		}
+		return body
+	}
+	return &archiveTee{body: body, writer: writer}
 }
 
 func (f *SitemapFetcher) getRobots(ctx context.Context, base *url.URL, cache map[string]*robotsRules) (*robotsRules, error) {
@@ -464,40 +1813,138 @@ func (f *SitemapFetcher) getRobots(ctx context.Context, base *url.URL, cache map
 	if rules, ok := cache[key]; ok {
 		return rules, nil
 	}
+	if isFileURL(base) {
+		rules := &robotsRules{}
+		cache[key] = rules
+		return rules, nil
+	}
 
 	robotsURL := base.ResolveReference(&url.URL{Path: "/robots.txt"})
+	if f.opts.RobotsCache != nil {
+		entry, err := f.opts.RobotsCache.Get(ctx, key)
+		if err != nil {
+			f.debug(ctx, "robots cache lookup failed", slog.String("key", key), slog.Any("error", err))
+		}
+		if entry != nil {
+			rules := f.parseRobots(ctx, base, robotsURL, entry)
+			cache[key] = rules
+			return rules, nil
+		}
+	}
+
+	ctx, span := f.startSpan(ctx, SpanRobotsFetch, robotsURL)
+	entry, err := f.fetchRobots(ctx, robotsURL)
+	defer span.End(err)
+	if unavailable := err != nil || robotsUnreachableStatus(entry.StatusCode); unavailable {
+		span.SetAttributes(slog.Bool(AttrRobotsFound, false))
+		f.opts.Hooks.robotsFetched(base.Host, false)
+		rules := &robotsRules{}
+		switch f.opts.RobotsErrorPolicy {
+		case RobotsErrorFail:
+			unavailableErr := &ErrRobotsUnavailable{URL: robotsURL, Err: err}
+			if entry != nil {
+				unavailableErr.StatusCode = entry.StatusCode
+			}
+			return nil, unavailableErr
+		case RobotsErrorRFC9309:
+			f.debug(ctx, "robots.txt unreachable, disallowing host", urlAttr("url", robotsURL), slog.String("host", base.Host))
+			rules.disallowAll = true
+		}
+		cache[key] = rules
+		return rules, nil
+	}
+	if f.opts.RobotsCache != nil {
+		if err := f.opts.RobotsCache.Put(ctx, key, *entry); err != nil {
+			f.debug(ctx, "robots cache store failed", slog.String("key", key), slog.Any("error", err))
+		}
+	}
+
+	rules := f.parseRobots(ctx, base, robotsURL, entry)
+	span.SetAttributes(slog.Bool(AttrRobotsFound, rules.found))
+	f.opts.Hooks.robotsFetched(base.Host, rules.found)
+	cache[key] = rules
+	return rules, nil
+}
+
+// fetchRobots requests robots.txt. Only network errors are returned; any
+// status is recorded in the entry. Responses and errors Options.Retry
+// retries for sitemaps are retried under every policy; unless
+// RobotsErrorPolicy is RobotsErrorAllow, so is any unreachable response.
+func (f *SitemapFetcher) fetchRobots(ctx context.Context, robotsURL *url.URL) (*RobotsEntry, error) {
+	retry := f.opts.Retry
+	for attempt := 0; ; attempt++ {
+		entry, resp, err := f.fetchRobotsOnce(ctx, robotsURL)
+		if err == nil && !robotsUnreachableStatus(entry.StatusCode) {
+			return entry, nil
+		}
+		retryable := f.opts.RobotsErrorPolicy != RobotsErrorAllow
+		if err != nil {
+			retryable = retryable || retry.retryableError(err)
+		} else {
+			retryable = retryable || retry.retryableStatus(entry.StatusCode)
+		}
+		if !retryable || attempt >= retry.MaxRetries || ctx.Err() != nil {
+			return entry, err
+		}
+		delay := retry.delay(attempt, resp)
+		f.debug(ctx, "robots.txt unreachable, retrying", urlAttr("url", robotsURL), slog.Int("attempt", attempt+1), slog.Duration("delay", delay))
+		if err := sleepWithContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fetchRobotsOnce requests robots.txt once. The response is returned with
+// its body closed, so its headers, e.g. Retry-After, can drive a retry.
+func (f *SitemapFetcher) fetchRobotsOnce(ctx context.Context, robotsURL *url.URL) (*RobotsEntry, *http.Response, error) {
 	req, cancel, err := f.newRequest(ctx, http.MethodGet, robotsURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer cancel()
 
-	resp, err := f.client.Do(req)
+	fetchedAt := time.Now()
+	resp, err := f.do(req)
 	if err != nil {
-		rules := &robotsRules{}
-		cache[key] = rules
-		return rules, nil
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
+	f.recordStatus(ctx, resp.StatusCode)
 
-	if resp.StatusCode != http.StatusOK {
-		rules := &robotsRules{}
-		cache[key] = rules
-		return rules, nil
+	entry := &RobotsEntry{StatusCode: resp.StatusCode, FetchedAt: fetchedAt}
+	if resp.StatusCode == http.StatusOK {
+		if entry.Body, err = io.ReadAll(resp.Body); err != nil {
+			return nil, nil, err
+		}
 	}
+	return entry, resp, nil
+}
 
-	data, err := robotstxt.FromResponse(resp)
+// robotsUnreachableStatus reports whether RFC 9309 treats a robots.txt
+// response as unreachable rather than unavailable: server errors and 429.
+func robotsUnreachableStatus(code int) bool {
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
+
+// parseRobots builds the rules for base from a fetched or cached robots.txt.
+// Anything but a parseable 200 response allows everything.
+func (f *SitemapFetcher) parseRobots(ctx context.Context, base, robotsURL *url.URL, entry *RobotsEntry) *robotsRules {
+	if entry.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+	data, err := robotstxt.FromBytes(entry.Body)
 	if err != nil {
-		rules := &robotsRules{}
-		cache[key] = rules
-		return rules, nil
+		return &robotsRules{}
 	}
 
-	rules := &robotsRules{group: data.FindGroup(f.opts.UserAgent)}
+	rules := &robotsRules{found: true, group: data.FindGroup(f.opts.RobotsAgent)}
+	if rules.group != nil {
+		rules.crawlDelay = rules.group.CrawlDelay
+	}
 	for _, loc := range data.Sitemaps {
 		parsed, err := url.Parse(strings.TrimSpace(loc))
 		if err != nil {
-			f.logger.Debug(fmt.Sprintf("invalid sitemap URL %q in robots.txt %s: %v", loc, robotsURL, err))
+			f.debug(ctx, "invalid sitemap URL in robots.txt", slog.String("loc", loc), urlAttr("robots", robotsURL), slog.Any("error", err))
 			continue
 		}
 		if !parsed.IsAbs() {
@@ -505,18 +1952,19 @@ func (f *SitemapFetcher) getRobots(ctx context.Context, base *url.URL, cache map
 		}
 		rules.sitemaps = append(rules.sitemaps, parsed)
 	}
-
-	cache[key] = rules
-	return rules, nil
+	return rules
 }
 
 func (f *SitemapFetcher) allowedByRobots(ctx context.Context, loc *url.URL, cache map[string]*robotsRules) (bool, error) {
 	base := &url.URL{Scheme: loc.Scheme, Host: loc.Host}
 	rules, err := f.getRobots(ctx, base, cache)
 	if err != nil {
-		return true, nil
+		return false, err
 	}
-	if rules == nil || rules.group == nil {
+	if rules.disallowAll {
+		return false, nil
+	}
+	if rules.group == nil {
 		return true, nil
 	}
 	path := loc.EscapedPath()
@@ -526,25 +1974,103 @@ func (f *SitemapFetcher) allowedByRobots(ctx context.Context, loc *url.URL, cach
 	return rules.group.Test(path), nil
 }
 
-func wrapReader(resp *http.Response, cancel context.CancelFunc) (io.ReadCloser, error) {
-	reader := bufio.NewReaderSize(resp.Body, defaultBufSize)
-	peek, err := reader.Peek(2)
-	if err == nil && len(peek) == 2 && peek[0] == 0x1f && peek[1] == 0x8b {
-		gz, err := gzip.NewReader(reader)
+// waitCrawlDelay sleeps until the robots.txt Crawl-delay for loc's host has
+// elapsed since the previous sitemap request to that host.
+func (f *SitemapFetcher) waitCrawlDelay(ctx context.Context, loc *url.URL, cache map[string]*robotsRules, lastFetch map[string]time.Time) error {
+	base := &url.URL{Scheme: loc.Scheme, Host: loc.Host}
+	key := base.Scheme + "://" + base.Host
+	rules, err := f.getRobots(ctx, base, cache)
+	if err != nil || rules == nil || rules.crawlDelay <= 0 {
+		lastFetch[key] = time.Now()
+		return nil
+	}
+	delay := rules.crawlDelay
+	if delay > f.opts.MaxCrawlDelay {
+		delay = f.opts.MaxCrawlDelay
+	}
+	if last, ok := lastFetch[key]; ok {
+		if wait := delay - time.Since(last); wait > 0 {
+			f.debug(ctx, "honoring crawl-delay", slog.String("host", key), slog.Duration("wait", wait))
+			if err := sleepWithContext(ctx, wait); err != nil {
+				return err
+			}
+		}
+	}
+	lastFetch[key] = time.Now()
+	return nil
+}
+
+// limitSitemap applies Options.MaxSitemapBytes to a decompressed sitemap.
+func (f *SitemapFetcher) limitSitemap(reader io.ReadCloser, loc *url.URL) io.ReadCloser {
+	if f.opts.MaxSitemapBytes <= 0 {
+		return reader
+	}
+	return &sizeLimitedReader{
+		ReadCloser: reader,
+		remaining:  f.opts.MaxSitemapBytes,
+		limit:      f.opts.MaxSitemapBytes,
+		loc:        loc,
+	}
+}
+
+// isGzipCorruption reports whether err comes from a damaged gzip stream, as
+// opposed to a damaged sitemap inside a valid one.
+func isGzipCorruption(err error) bool {
+	if errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) {
+		return true
+	}
+	var corrupt flate.CorruptInputError
+	return errors.As(err, &corrupt)
+}
+
+// supportedContentEncoding reports whether wrapReader can decode a body sent
+// with the given Content-Encoding: gzip or the name of a registered codec.
+func supportedContentEncoding(encoding string) bool {
+	switch encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding {
+	case "", "identity", "gzip", "x-gzip":
+		return true
+	default:
+		return codecByName(encoding) != nil
+	}
+}
+
+// wrapReader decompresses body by the magic bytes of the registered codecs,
+// or by loc's file extension for codecs without magic. Up to maxCodecLayers
+// are removed so a sitemap.xml.gz served with Content-Encoding: gzip, and the
+// raw copies kept by the cache and archive, decode the same way.
+func wrapReader(body io.ReadCloser, loc *url.URL, encoding string, cancel context.CancelFunc) (io.ReadCloser, error) {
+	var reader io.Reader = body
+	closers := []io.Closer{body, cancelCloser{cancel: cancel}}
+	// Codecs without magic bytes are only recognizable by the header.
+	if codec := headerCodec(encoding); codec != nil {
+		decoded, err := codec.NewReader(body)
 		if err != nil {
 			return nil, err
 		}
-		return &multiCloser{reader: gz, closers: []io.Closer{gz, resp.Body, cancelCloser{cancel: cancel}}}, nil
+		closers = append([]io.Closer{decoded}, closers...)
+		reader = decoded
 	}
-	return &readCloser{
-		reader: reader,
-		close: func() error {
-			if cancel != nil {
-				cancel()
-			}
-			return resp.Body.Close()
-		},
-	}, nil
+	registered := registeredCodecs()
+	extensionUsed := false
+	for layer := 0; layer < maxCodecLayers; layer++ {
+		buffered := bufio.NewReaderSize(reader, defaultBufSize)
+		reader = buffered
+		codec := sniffCodec(buffered, registered)
+		if codec == nil && !extensionUsed {
+			codec = extensionCodec(loc, registered)
+			extensionUsed = codec != nil
+		}
+		if codec == nil {
+			break
+		}
+		decoded, err := codec.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		closers = append([]io.Closer{decoded}, closers...)
+		reader = decoded
+	}
+	return &multiCloser{reader: reader, closers: closers}, nil
 }
 
 func retryAfterDelay(resp *http.Response) time.Duration {
@@ -583,10 +2109,103 @@ func sleepWithContext(ctx context.Context, delay time.Duration) error {
 
 // ===================== XML Parsing =====================
 
-func parseSitemap(ctx context.Context, reader io.Reader, onURL func(xmlURLEntry) error, onSitemap func(xmlSitemapEntry) error) error {
-	decoder := xml.NewDecoder(reader)
+// parseFailure passes typed limit, yield, and context errors from a parse
+// through unchanged and wraps everything else in *ErrSitemapParse.
+func parseFailure(loc *url.URL, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	var maxURLs *ErrMaxURLs
+	if errors.As(err, &maxURLs) {
+		return err
+	}
+	var maxBytes *ErrMaxBytes
+	if errors.As(err, &maxBytes) {
+		return err
+	}
+	var tooLarge *ErrSitemapTooLarge
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	var crossHost *ErrCrossHost
+	if errors.As(err, &crossHost) {
+		return err
+	}
+	var elementErr *ErrElementTooLarge
+	if errors.As(err, &elementErr) {
+		elementErr.URL = cloneURL(loc)
+		return err
+	}
+	var limitErr *ErrXMLLimit
+	if errors.As(err, &limitErr) {
+		limitErr.URL = cloneURL(loc)
+		return err
+	}
+	var notSitemap *ErrNotSitemap
+	if errors.As(err, &notSitemap) {
+		notSitemap.URL = cloneURL(loc)
+		return err
+	}
+	var yieldErr *ErrYield
+	if errors.As(err, &yieldErr) {
+		return err
+	}
+	return &ErrSitemapParse{URL: loc, Err: err}
+}
+
+// sitemapParser streams a sitemap document and hands its elements to the
+// configured callbacks.
+type sitemapParser struct {
+	onRoot    func(xml.StartElement) error
+	onURL     func(xmlURLEntry) error
+	onSitemap func(xmlSitemapEntry) error
+
+	// maxElementBytes caps the raw size of a single <url> or <sitemap>
+	// element (0 => no limit).
+	maxElementBytes   int64
+	decoder           DecoderOptions
+	captureExtensions bool
+	// extensionNames decodes the names of extension elements, e.g. for
+	// Options.RequireExtensions; other walks skip them without allocating.
+	extensionNames bool
+	// detectCDATA flags entries containing CDATA sections.
+	detectCDATA bool
+	// requireNamespace accepts only sitemaps.org roots and entries.
+	requireNamespace bool
+
+	// lenient strips control characters and ends the document at the first
+	// syntax error, keeping the entries decoded before it, after reporting
+	// the error to onMalformed.
+	lenient     bool
+	onMalformed func(error)
+}
+
+func (h sitemapParser) parse(ctx context.Context, reader io.Reader) error {
+	bufSize := h.decoder.BufferSize
+	if bufSize <= 0 {
+		bufSize = defaultBufSize
+	}
+	if h.lenient {
+		reader = &controlStripper{r: reader}
+	}
+	guard := &byteGuard{
+		reader:       bufio.NewReaderSize(reader, bufSize),
+		elementLimit: h.maxElementBytes,
+		tokenLimit:   h.decoder.MaxTokenBytes,
+		detectCDATA:  h.detectCDATA,
+	}
+	// encoding/xml never reads a DTD: entities declared in a DOCTYPE,
+	// including external ones, are left unexpanded as literal text, so
+	// billion-laughs and XXE documents only cost their own bytes. Keep
+	// Entity nil; only the predefined XML entities are decoded.
+	raw := xml.NewDecoder(guard)
+	raw.Strict = false
+	raw.Entity = nil
+	raw.CharsetReader = charsetReader
+	decoder := xml.NewTokenDecoder(&limitedTokens{raw: raw, guard: guard, maxNesting: h.decoder.MaxNesting})
 	decoder.Strict = false
 
+	sawRoot := false
 	for {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -596,30 +2215,70 @@ func parseSitemap(ctx context.Context, reader io.Reader, onURL func(xmlURLEntry)
 			if errors.Is(err, io.EOF) {
 				return nil
 			}
-			return err
+			return h.malformed(err)
 		}
 		start, ok := tok.(xml.StartElement)
 		if !ok {
 			continue
 		}
+		if !sawRoot {
+			sawRoot = true
+			if h.requireNamespace && !isSitemapRoot(start.Name) {
+				return &ErrNotSitemap{Root: start.Name}
+			}
+			if h.onRoot != nil {
+				if err := h.onRoot(start); err != nil {
+					return err
+				}
+			}
+		}
+		// Entries in another namespace, e.g. inside an unrelated extension,
+		// are not sitemap entries.
+		if h.requireNamespace && start.Name.Space != SitemapNamespace {
+			continue
+		}
+		// The raw decoder has just read the start tag, so this is its line.
+		line, _ := raw.InputPos()
 		switch start.Name.Local {
 		case "url":
 			var entry xmlURLEntry
-			if err := decoder.DecodeElement(&entry, &start); err != nil {
-				return err
+			guard.beginElement()
+			var err error
+			switch {
+			case h.captureExtensions:
+				var captured xmlCapturedURLEntry
+				err = decoder.DecodeElement(&captured, &start)
+				entry = captured.entry()
+			case h.extensionNames:
+				var named xmlNamedURLEntry
+				err = decoder.DecodeElement(&named, &start)
+				entry = named.entry()
+			default:
+				err = decoder.DecodeElement(&entry, &start)
+			}
+			entry.line = line
+			entry.cdata = guard.sawCDATA
+			guard.endElement()
+			if err != nil {
+				return h.malformed(err)
 			}
-			if onURL != nil {
-				if err := onURL(entry); err != nil {
+			if h.onURL != nil {
+				if err := h.onURL(entry); err != nil {
 					return err
 				}
 			}
 		case "sitemap":
 			var entry xmlSitemapEntry
-			if err := decoder.DecodeElement(&entry, &start); err != nil {
-				return err
+			guard.beginElement()
+			err := decoder.DecodeElement(&entry, &start)
+			entry.line = line
+			entry.cdata = guard.sawCDATA
+			guard.endElement()
+			if err != nil {
+				return h.malformed(err)
 			}
-			if onSitemap != nil {
-				if err := onSitemap(entry); err != nil {
+			if h.onSitemap != nil {
+				if err := h.onSitemap(entry); err != nil {
 					return err
 				}
 			}
@@ -627,6 +2286,143 @@ func parseSitemap(ctx context.Context, reader io.Reader, onURL func(xmlURLEntry)
 	}
 }
 
+// isSitemapRoot reports whether name is a sitemaps.org <urlset> or
+// <sitemapindex>.
+func isSitemapRoot(name xml.Name) bool {
+	return name.Space == SitemapNamespace && (name.Local == string(SitemapTypeURLSet) || name.Local == string(SitemapTypeIndex))
+}
+
+// malformed returns err, or nil in lenient mode when err is a syntax error
+// that ends the document early.
+func (h sitemapParser) malformed(err error) error {
+	if !h.lenient || !isMalformedXML(err) {
+		return err
+	}
+	if h.onMalformed != nil {
+		h.onMalformed(err)
+	}
+	return nil
+}
+
+// byteGuard counts bytes handed to the XML decoder and fails once a single
+// token or element grows beyond its limit, before the decoder buffers it.
+type byteGuard struct {
+	reader *bufio.Reader
+
+	elementLimit  int64
+	elementActive bool
+	elementUsed   int64
+
+	tokenLimit int64
+	tokenUsed  int64
+
+	// detectCDATA sets sawCDATA when a CDATA section starts within the
+	// current element; cdataMatched counts the bytes of cdataOpen seen.
+	detectCDATA  bool
+	cdataMatched int
+	sawCDATA     bool
+}
+
+var cdataOpen = []byte("<![CDATA[")
+
+func (g *byteGuard) beginElement() {
+	g.elementActive = g.elementLimit > 0
+	g.elementUsed = 0
+	g.sawCDATA = false
+}
+
+func (g *byteGuard) scanCDATA(b byte) {
+	switch {
+	case b == cdataOpen[g.cdataMatched]:
+		g.cdataMatched++
+		if g.cdataMatched == len(cdataOpen) {
+			g.sawCDATA = true
+			g.cdataMatched = 0
+		}
+	case b == cdataOpen[0]:
+		g.cdataMatched = 1
+	default:
+		g.cdataMatched = 0
+	}
+}
+
+func (g *byteGuard) endElement() {
+	g.elementActive = false
+}
+
+func (g *byteGuard) beginToken() {
+	g.tokenUsed = 0
+}
+
+func (g *byteGuard) ReadByte() (byte, error) {
+	if g.elementActive {
+		g.elementUsed++
+		if g.elementUsed > g.elementLimit {
+			return 0, &ErrElementTooLarge{Limit: g.elementLimit}
+		}
+	}
+	if g.tokenLimit > 0 {
+		g.tokenUsed++
+		if g.tokenUsed > g.tokenLimit {
+			return 0, &ErrXMLLimit{Limit: XMLLimitTokenBytes, Value: g.tokenLimit}
+		}
+	}
+	b, err := g.reader.ReadByte()
+	if err == nil && g.detectCDATA {
+		g.scanCDATA(b)
+	}
+	return b, err
+}
+
+func (g *byteGuard) Read(p []byte) (int, error) {
+	// The XML decoder reads through ReadByte; Read only serves other callers.
+	if g.elementActive || g.tokenLimit > 0 {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		b, err := g.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		p[0] = b
+		return 1, nil
+	}
+	n, err := g.reader.Read(p)
+	if g.detectCDATA {
+		for _, b := range p[:n] {
+			g.scanCDATA(b)
+		}
+	}
+	return n, err
+}
+
+// limitedTokens feeds raw tokens to the namespace-aware decoder while
+// enforcing per-token size and nesting depth limits.
+type limitedTokens struct {
+	raw        *xml.Decoder
+	guard      *byteGuard
+	maxNesting int
+	depth      int
+}
+
+func (l *limitedTokens) Token() (xml.Token, error) {
+	l.guard.beginToken()
+	tok, err := l.raw.RawToken()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.(type) {
+	case xml.StartElement:
+		l.depth++
+		if l.maxNesting > 0 && l.depth > l.maxNesting {
+			return nil, &ErrXMLLimit{Limit: XMLLimitNesting, Value: int64(l.maxNesting)}
+		}
+	case xml.EndElement:
+		l.depth--
+	}
+	return tok, nil
+}
+
 func resolveLocation(base *url.URL, loc string) (*url.URL, error) {
 	trimmed := strings.TrimSpace(loc)
 	if trimmed == "" {
@@ -640,32 +2436,152 @@ func resolveLocation(base *url.URL, loc string) (*url.URL, error) {
 		parsed.Fragment = ""
 		return parsed, nil
 	}
+	if base == nil {
+		return nil, errors.New("relative loc without a base URL")
+	}
 	resolved := base.ResolveReference(parsed)
 	resolved.Fragment = ""
 	return resolved, nil
 }
 
+// timeLayout is a lastmod layout and whether it carries a UTC offset.
+type timeLayout struct {
+	layout  string
+	hasZone bool
+}
+
+// lastModLayouts are the W3C datetime forms the sitemaps.org protocol
+// allows, plus RFC 1123 dates, which StrictSpec accepts as well.
+var lastModLayouts = []timeLayout{
+	{time.RFC3339Nano, true},
+	{time.RFC3339, true},
+	{"2006-01-02T15:04Z07:00", true},
+	{"2006-01-02", false},
+	{"2006-01", false},
+	{"2006", false},
+	{"2006-01-02T15:04:05", false},
+	{time.RFC1123, true},
+	{time.RFC1123Z, true},
+}
+
+// lenientLastModLayouts are common forms outside the protocol: a space
+// instead of T, and offsets without a colon or minutes.
+var lenientLastModLayouts = []timeLayout{
+	{"2006-01-02T15:04:05Z0700", true},
+	{"2006-01-02T15:04:05Z07", true},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02 15:04:05Z07:00", true},
+	{"2006-01-02 15:04:05Z0700", true},
+	{"2006-01-02 15:04:05 -0700", true},
+	{"2006-01-02 15:04", false},
+}
+
+// minUnixLastMod is 2000-01-01T00:00:00Z, the earliest Unix seconds value
+// read as a lastmod.
+const minUnixLastMod = 946684800
+
+// parseTimeValue parses a lastmod value in one of lastModLayouts.
 func parseTimeValue(value string) *time.Time {
+	parsed, _ := parseLayouts(strings.TrimSpace(value), lastModLayouts)
+	return parsed
+}
+
+// parseTimeValueZone parses a lastmod value in any supported form, then in
+// the extra layouts, and reports whether it carried an explicit time zone.
+// Values of 9 or 10 digits are read as Unix seconds when they fall between
+// 2000 and a year from now; other numbers are more likely IDs or versions.
+// They name an instant without writing an offset, so they report no zone.
+func parseTimeValueZone(value string, extra []string) (*time.Time, bool) {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
-		return nil
+		return nil, false
+	}
+	if parsed, hasZone := parseLayouts(trimmed, lastModLayouts); parsed != nil {
+		return parsed, hasZone
 	}
-	layouts := []string{
-		time.RFC3339Nano,
-		time.RFC3339,
-		"2006-01-02",
-		"2006-01-02T15:04:05",
-		time.RFC1123,
-		time.RFC1123Z,
+	if parsed, hasZone := parseLayouts(trimmed, lenientLastModLayouts); parsed != nil {
+		return parsed, hasZone
 	}
-	for _, layout := range layouts {
+	if len(trimmed) == 9 || len(trimmed) == 10 {
+		if seconds, err := strconv.ParseInt(trimmed, 10, 64); err == nil && seconds >= minUnixLastMod {
+			if parsed := time.Unix(seconds, 0).UTC(); parsed.Before(time.Now().AddDate(1, 0, 0)) {
+				return &parsed, false
+			}
+		}
+	}
+	for _, layout := range extra {
 		if parsed, err := time.Parse(layout, trimmed); err == nil {
-			return &parsed
+			hasZone := strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") || strings.Contains(layout, "MST")
+			return &parsed, hasZone
 		}
 	}
+	return nil, false
+}
+
+func parseLayouts(value string, layouts []timeLayout) (*time.Time, bool) {
+	if value == "" {
+		return nil, false
+	}
+	for _, l := range layouts {
+		if parsed, err := time.Parse(l.layout, value); err == nil {
+			return &parsed, l.hasZone
+		}
+	}
+	return nil, false
+}
+
+// lastMod parses a lastmod value, converts it to Options.LastModLocation and
+// returns the original UTC offset in seconds when the value had one.
+func (f *SitemapFetcher) lastMod(value string) (*time.Time, *int) {
+	parsed, hasZone := parseTimeValueZone(value, f.opts.ExtraTimeLayouts)
+	if parsed == nil {
+		return nil, nil
+	}
+	var offset *int
+	if hasZone {
+		_, seconds := parsed.Zone()
+		offset = &seconds
+	}
+	if f.opts.LastModLocation != nil {
+		converted := parsed.In(f.opts.LastModLocation)
+		parsed = &converted
+	}
+	return parsed, offset
+}
+
+// priority parses a priority value and applies Options.PriorityPolicy.
+func (f *SitemapFetcher) priority(ctx context.Context, value string, loc *url.URL) *float64 {
+	parsed := parsePriority(value)
+	if parsed == nil || priorityInRange(*parsed) || f.opts.PriorityPolicy == PriorityKeep {
+		return parsed
+	}
+	if f.opts.PriorityPolicy == PriorityClamp && !math.IsNaN(*parsed) {
+		clamped := math.Min(math.Max(*parsed, 0), 1)
+		return &clamped
+	}
+	if f.debugEnabled(ctx) {
+		f.debug(ctx, "dropping out-of-range priority", slog.String("priority", strings.TrimSpace(value)), urlAttr("url", loc))
+	}
 	return nil
 }
 
+// rawMetadata returns the lastmod and priority as written in entry when the
+// parsed values do not reflect them: unparsable, dropped, or clamped.
+func rawMetadata(entry xmlURLEntry, lastMod *time.Time, priority *float64) (rawLastMod, rawPriority string) {
+	if lastMod == nil {
+		rawLastMod = strings.TrimSpace(entry.LastMod)
+	}
+	if parsed := parsePriority(entry.Priority); priority == nil || parsed == nil || *parsed != *priority {
+		rawPriority = strings.TrimSpace(entry.Priority)
+	}
+	return rawLastMod, rawPriority
+}
+
+// priorityInRange reports whether p is a valid sitemaps.org priority; NaN is not.
+func priorityInRange(p float64) bool {
+	return p >= 0 && p <= 1
+}
+
 func parsePriority(value string) *float64 {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
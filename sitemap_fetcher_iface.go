@@ -3,6 +3,7 @@ package gositemapfetcher
 import (
 	"context"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -16,7 +17,214 @@ type SitemapWalker interface {
 type Item struct {
 	Loc        *url.URL
 	LastMod    *time.Time
-	ChangeFreq string
+	ChangeFreq ChangeFreq
 	Priority   *float64
 	Sitemap    *url.URL
+	// SitemapLastMod is the lastmod the parent sitemap index gave Sitemap,
+	// nil for root sitemaps or when the index entry had none.
+	SitemapLastMod *time.Time
+	// LastModOffset is the UTC offset in seconds written in the sitemap's
+	// lastmod, preserved when Options.LastModLocation converts LastMod.
+	// Nil when lastmod is missing or had no time zone.
+	LastModOffset *int
+	// Provenance is the chain of sitemaps that led to this item, from the
+	// discovery root to Sitemap. It is shared between items; do not modify it.
+	Provenance []Hop
+	// RawLastMod and RawPriority hold lastmod and priority as written in
+	// the sitemap when LastMod or Priority does not reflect them, e.g. an
+	// unparsable date, or a priority dropped or clamped by PriorityPolicy.
+	// They are empty when the value parsed cleanly or was missing.
+	RawLastMod  string
+	RawPriority string
+	// Extensions holds the other child elements of <url> in document order
+	// when Options.CaptureExtensions is set.
+	Extensions []ExtensionElement
+}
+
+// ChangeFreq is a <changefreq> value. Valid values are normalized to the
+// lowercase constants; anything else keeps the text as written, so check
+// Valid before switching on it. Empty means the entry had none.
+type ChangeFreq string
+
+const (
+	ChangeFreqAlways  ChangeFreq = "always"
+	ChangeFreqHourly  ChangeFreq = "hourly"
+	ChangeFreqDaily   ChangeFreq = "daily"
+	ChangeFreqWeekly  ChangeFreq = "weekly"
+	ChangeFreqMonthly ChangeFreq = "monthly"
+	ChangeFreqYearly  ChangeFreq = "yearly"
+	ChangeFreqNever   ChangeFreq = "never"
+)
+
+// ParseChangeFreq trims value and matches it case-insensitively against the
+// sitemaps.org values, returning the constant or the trimmed text.
+func ParseChangeFreq(value string) ChangeFreq {
+	trimmed := strings.TrimSpace(value)
+	if normalized := ChangeFreq(strings.ToLower(trimmed)); normalized.Valid() {
+		return normalized
+	}
+	return ChangeFreq(trimmed)
+}
+
+// Valid reports whether c is one of the ChangeFreq constants.
+func (c ChangeFreq) Valid() bool {
+	switch c {
+	case ChangeFreqAlways, ChangeFreqHourly, ChangeFreqDaily, ChangeFreqWeekly, ChangeFreqMonthly, ChangeFreqYearly, ChangeFreqNever:
+		return true
+	}
+	return false
+}
+
+// SitemapRef is a child sitemap listed in a sitemap index, see
+// Options.YieldSitemaps.
+type SitemapRef struct {
+	Loc     *url.URL
+	LastMod *time.Time // lastmod from the index entry, if any
+	Index   *url.URL   // sitemap index listing Loc
+	Depth   int        // depth Loc is walked at, 1 for children of a root index
+}
+
+// SitemapType is the kind of a fetched sitemap document, sniffed from its
+// root element. Documents with another root report its local name.
+type SitemapType string
+
+const (
+	SitemapTypeURLSet SitemapType = "urlset"
+	SitemapTypeIndex  SitemapType = "sitemapindex"
+	SitemapTypeFeed   SitemapType = "feed" // RSS or Atom feed
+	// SitemapTypeText marks a text sitemap: one absolute http(s) URL per
+	// line. Text sitemaps are recognized but yield no URLs.
+	SitemapTypeText SitemapType = "text"
+	// SitemapTypeUnknown marks any other document without an XML root
+	// element, e.g. garbage or an error page.
+	SitemapTypeUnknown SitemapType = "unknown"
+)
+
+// sitemapType classifies a parsed document by its root element name, or by
+// its first bytes when the decoder found no root.
+func sitemapType(root string, head []byte) SitemapType {
+	switch root {
+	case "":
+		if isTextSitemap(head) {
+			return SitemapTypeText
+		}
+		return SitemapTypeUnknown
+	case "rss", "feed", "RDF":
+		return SitemapTypeFeed
+	}
+	return SitemapType(root)
+}
+
+// isTextSitemap reports whether head, the start of a document, holds only
+// absolute http(s) URLs, one per line. A line cut off at the end of head is
+// not checked.
+func isTextSitemap(head []byte) bool {
+	text := strings.TrimPrefix(string(head), "\ufeff")
+	lines := strings.Split(text, "\n")
+	if len(head) >= sniffBytes && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	found := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		loc, err := url.Parse(line)
+		if err != nil || (loc.Scheme != "http" && loc.Scheme != "https") || loc.Host == "" || strings.ContainsAny(line, " \t<>") {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// SitemapInfo describes one sitemap found by SitemapFetcher.ListSitemaps.
+type SitemapInfo struct {
+	URL      *url.URL
+	Type     SitemapType // empty when the document was not parsed, e.g. a SkipDuplicateSitemaps alias
+	Depth    int         // sitemap index depth, 0 for root sitemaps
+	LastMod  *time.Time  // lastmod from the parent index entry, if any
+	Via      string      // how URL was discovered, one of the Via* constants
+	Ref      *url.URL    // document that referenced URL (robots.txt, homepage, or parent index)
+	URLs     int         // <url> entries listed, 0 unless Counted
+	Sitemaps int         // <sitemap> entries listed
+	Bytes    int64       // decompressed bytes read
+	// Counted reports whether the document was read to its end, so URLs is
+	// its entry count: always for indexes, for other sitemaps only with
+	// Options.CountSitemapURLs.
+	Counted bool
+}
+
+// WalkResult summarizes a traversal, see SitemapFetcher.WalkWithResult.
+type WalkResult struct {
+	SitemapsFetched       int   // sitemaps opened and parsed, excluding probe misses
+	URLsYielded           int   // items passed to yield
+	URLsFiltered          int   // entries dropped by Include/Exclude, Filter, ModifiedAfter/Before, RequireExtensions, CrossHostSkip, or StrictSpec
+	URLsDuplicate         int   // entries suppressed by DedupURLs
+	RobotsBlockedURLs     int   // entries disallowed by robots.txt
+	RobotsBlockedSitemaps int   // sitemaps disallowed by robots.txt
+	BytesRead             int64 // decompressed sitemap bytes parsed
+	BytesDownloaded       int64 // response bytes as received over HTTP, from SitemapSource, or from local files, robots.txt and homepage included
+	SitemapAliases        int   // sitemaps skipped by SkipDuplicateSitemaps
+	SitemapsSkipped       int   // child sitemaps not fetched: index lastmod before ModifiedAfter or unchanged in StateStore, or beyond MaxDepth with SkipDeepSitemaps
+	Duration              time.Duration
+	Sitemaps              []SitemapStats // per-sitemap breakdown in fetch order
+	// LimitReached is the limit error that ended a walk with LimitStop.
+	LimitReached error
+	// Stopped is set when Options.StopWhen ended the walk.
+	Stopped bool
+	// SpecWarnings lists advisory findings of Options.StrictSpec, such as
+	// CDATA sections, that neither skip entries nor fail the walk. It holds
+	// at most the first 1000.
+	SpecWarnings []SpecViolation
+}
+
+// Progress is the state of a walk passed to Options.StopWhen.
+type Progress struct {
+	SitemapsFetched int   // sitemaps opened and parsed so far
+	SitemapsQueued  int   // sitemaps waiting to be fetched, including the next one
+	URLsYielded     int   // items passed to yield
+	URLsFiltered    int   // entries dropped by filters
+	BytesRead       int64 // decompressed sitemap bytes parsed
+	BytesDownloaded int64 // response bytes received, see WalkResult.BytesDownloaded
+	Elapsed         time.Duration
+}
+
+// SitemapStats describes one fetched sitemap in a WalkResult.
+type SitemapStats struct {
+	URL      *url.URL
+	URLs     int         // items yielded from this sitemap
+	Entries  int         // <url> or <sitemap> entries listed, including filtered ones
+	Type     SitemapType // empty when the document was not parsed, e.g. an alias or a failed fetch
+	Bytes    int64       // decompressed bytes parsed
+	Duration time.Duration
+	LastMod  *time.Time // lastmod from the parent index entry, if any
+	Err      error      // error that stopped the walk at this sitemap, if any
+	AliasOf  *url.URL   // earlier sitemap with identical content; this one was not parsed
+	// FinalURL is the URL that served the sitemap after HTTP redirects, and
+	// the base for its relative entries; nil when it was not redirected.
+	FinalURL *url.URL
+	// Redirects lists the URLs that answered with a redirect, starting with URL.
+	Redirects []*url.URL
+}
+
+// Hop values for Hop.Via.
+const (
+	ViaInput  = "input"      // the sitemap URL passed to Walk
+	ViaSeed   = "seed"       // an Options.Sitemaps entry
+	ViaRobots = "robots.txt" // a Sitemap directive in robots.txt
+	ViaProbe  = "probe"      // a default candidate path
+	ViaHTML   = "html"       // homepage <link rel="sitemap"> or CMS hint
+	ViaIndex  = "index"      // a <sitemap> entry of a sitemap index
+)
+
+// Hop is one sitemap in an item's provenance chain.
+type Hop struct {
+	URL     *url.URL
+	Via     string     // how URL was discovered, one of the Via* constants
+	Ref     *url.URL   // document that referenced URL (robots.txt, homepage, or parent index)
+	Depth   int        // sitemap index depth, 0 for root sitemaps
+	LastMod *time.Time // lastmod from the parent index entry, if any
+	Source  *url.URL   // mirror that served URL when the primary host failed, nil otherwise
 }
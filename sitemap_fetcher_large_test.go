@@ -61,7 +61,7 @@ func TestSitemapFetcher_LargeRandom(t *testing.T) {
 		t.Fatalf("failed to parse sitemap URL: %v", err)
 	}
 
-	fetcher := New(Options{})
+	fetcher := New(Options{MaxSitemapBytes: -1})
 	var count int
 	lastReport := time.Now()
 	reportEvery := 1_000_000
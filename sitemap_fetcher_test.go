@@ -4,13 +4,23 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -189,6 +199,149 @@ func TestSitemapFetcher_RespectRobots_Default(t *testing.T) {
 	}
 }
 
+func TestSitemapFetcher_RobotsAgent(t *testing.T) {
+	const robots = "User-agent: *\nAllow: /\n\nUser-agent: mybot\nDisallow: /private\n\nSitemap: /sitemap.xml\n"
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/public</loc></url>
+  <url><loc>/private/page</loc></url>
+</urlset>`
+
+	var userAgent atomic.Value
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent.Store(r.UserAgent())
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte(robots))
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL := mustParseURL(t, server.URL)
+
+	items, err := collectItems(New(Options{UserAgent: "MyBot/1.0 (+https://example.com/bot)", RobotsAgent: "Other"}), baseURL)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected the * group to allow both URLs, got %d items (%v)", len(items), err)
+	}
+
+	items, err = collectItems(New(Options{UserAgent: "Fetcher/2.0", RobotsAgent: "MyBot"}), baseURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Loc.Path != "/public" {
+		t.Fatalf("expected the mybot group to block /private, got %+v", items)
+	}
+	if got := userAgent.Load(); got != "Fetcher/2.0" {
+		t.Fatalf("expected requests to keep the User-Agent, got %v", got)
+	}
+}
+
+func TestSitemapFetcher_RobotsErrorPolicy(t *testing.T) {
+	var robotsRequests atomic.Int32
+	var robotsStatus atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			robotsRequests.Add(1)
+			if robotsStatus.Load() == http.StatusTooManyRequests && robotsRequests.Load() == 1 {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(int(robotsStatus.Load()))
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL := mustParseURL(t, server.URL)
+	retry := RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond}
+
+	robotsStatus.Store(http.StatusServiceUnavailable)
+	items, err := collectItems(New(Options{Retry: retry}), baseURL)
+	if err != nil || len(items) != 1 || robotsRequests.Load() != 1 {
+		t.Fatalf("expected the default policy to allow everything without retries, got %d items, %d requests (%v)", len(items), robotsRequests.Load(), err)
+	}
+
+	robotsRequests.Store(0)
+	result, err := New(Options{Retry: retry, RobotsErrorPolicy: RobotsErrorRFC9309}).WalkWithResult(context.Background(), baseURL, func(Item) error { return nil })
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if result.URLsYielded != 0 || result.RobotsBlockedSitemaps == 0 || robotsRequests.Load() != 2 {
+		t.Fatalf("expected a retried, disallowed host, got %+v after %d requests", result, robotsRequests.Load())
+	}
+
+	var unavailable *ErrRobotsUnavailable
+	_, err = collectItems(New(Options{Retry: retry, RobotsErrorPolicy: RobotsErrorFail}), baseURL)
+	if !errors.As(err, &unavailable) || unavailable.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected ErrRobotsUnavailable with status 503, got %v", err)
+	}
+
+	// The default policy still retries what Retry covers, after Retry-After
+	// capped by MaxDelay rather than BaseDelay.
+	robotsRequests.Store(0)
+	robotsStatus.Store(http.StatusTooManyRequests)
+	started := time.Now()
+	items, err = collectItems(New(Options{Retry: RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}}), baseURL)
+	if err != nil || len(items) != 1 || robotsRequests.Load() != 2 {
+		t.Fatalf("expected a retried 429 to allow everything, got %d items, %d requests (%v)", len(items), robotsRequests.Load(), err)
+	}
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected the retry to wait for Retry-After, waited %v", elapsed)
+	}
+
+	robotsStatus.Store(http.StatusForbidden)
+	items, err = collectItems(New(Options{Retry: retry, RobotsErrorPolicy: RobotsErrorFail}), baseURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected a 4xx robots.txt to allow everything, got %d items (%v)", len(items), err)
+	}
+}
+
+func TestSitemapFetcher_RobotsScope(t *testing.T) {
+	const robots = "User-agent: *\nDisallow: /private\nDisallow: /blocked.xml\n\nSitemap: /sitemap.xml\nSitemap: /blocked.xml\n"
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte(robots))
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/public</loc></url><url><loc>/private/page</loc></url></urlset>`))
+		case "/blocked.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/other</loc></url></urlset>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL := mustParseURL(t, server.URL)
+
+	cases := []struct {
+		scope RobotsScope
+		want  []string
+	}{
+		{RobotsScopeBoth, []string{"/public"}},
+		{RobotsScopeSitemaps, []string{"/public", "/private/page"}},
+		{RobotsScopeURLs, []string{"/public", "/other"}},
+		{RobotsScopeNone, []string{"/public", "/private/page", "/other"}},
+	}
+	for _, tc := range cases {
+		items, err := collectItems(New(Options{RobotsScope: tc.scope}), baseURL)
+		if err != nil {
+			t.Fatalf("scope %d: walk failed: %v", tc.scope, err)
+		}
+		got := make([]string, 0, len(items))
+		for _, item := range items {
+			got = append(got, item.Loc.Path)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("scope %d: expected %v, got %v", tc.scope, tc.want, got)
+		}
+	}
+}
+
 func TestSitemapFetcher_IgnoreRobots(t *testing.T) {
 	const robots = "User-agent: *\nDisallow: /sitemap.xml\n"
 	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
@@ -308,6 +461,120 @@ func TestSitemapFetcher_MaxURLs(t *testing.T) {
 	if len(items) != 1 {
 		t.Fatalf("expected 1 item, got %d", len(items))
 	}
+
+	fetcher = New(Options{MaxURLs: 1, LimitBehavior: LimitStop})
+	result, err := fetcher.WalkWithResult(context.Background(), sitemapURL, func(Item) error { return nil })
+	if err != nil {
+		t.Fatalf("expected LimitStop to end the walk cleanly, got %v", err)
+	}
+	if !errors.As(result.LimitReached, &maxErr) || result.URLsYielded != 1 || result.Sitemaps[0].Err != nil {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestSitemapFetcher_SkipDeepSitemaps(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/pages.xml</loc></sitemap>
+  <sitemap><loc>/nested.xml</loc></sitemap>
+</sitemapindex>`))
+		case "/pages.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		case "/nested.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>/deep.xml</loc></sitemap></sitemapindex>`))
+		case "/deep.xml":
+			t.Errorf("expected /deep.xml not to be fetched")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	var maxErr *ErrMaxDepth
+	if _, err := collectItems(New(Options{MaxDepth: 1, IgnoreRobots: true}), sitemapURL); !errors.As(err, &maxErr) {
+		t.Fatalf("expected ErrMaxDepth by default, got %v", err)
+	}
+
+	var skipped []string
+	fetcher := New(Options{
+		MaxDepth:         1,
+		SkipDeepSitemaps: true,
+		IgnoreRobots:     true,
+		Hooks: Hooks{OnSitemapSkipped: func(loc *url.URL, reason string) {
+			skipped = append(skipped, loc.Path+" "+reason)
+		}},
+	})
+	result, err := fetcher.WalkWithResult(context.Background(), sitemapURL, func(Item) error { return nil })
+	if err != nil {
+		t.Fatalf("expected the walk to complete, got %v", err)
+	}
+	if result.URLsYielded != 1 || result.SitemapsSkipped != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if !reflect.DeepEqual(skipped, []string{"/deep.xml " + SkipMaxDepth}) {
+		t.Fatalf("unexpected skip notifications: %v", skipped)
+	}
+}
+
+func TestSitemapFetcher_OnErrorContinue(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/broken.xml</loc></sitemap>
+  <sitemap><loc>/missing.xml</loc></sitemap>
+  <sitemap><loc>/pages.xml</loc></sitemap>
+</sitemapindex>`))
+		case "/broken.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/a</loc>`))
+		case "/pages.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	var parseErr *ErrSitemapParse
+	if _, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL); !errors.As(err, &parseErr) {
+		t.Fatalf("expected the first failure to end the walk by default, got %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, OnErrorContinue: true}), sitemapURL)
+	if len(items) != 1 || items[0].Loc.Path != "/page" {
+		t.Fatalf("expected the healthy sitemap to be walked, got %+v", items)
+	}
+	var partial *ErrPartial
+	if !errors.As(err, &partial) || len(partial.Failures) != 2 {
+		t.Fatalf("expected ErrPartial with 2 failures, got %v", err)
+	}
+	if partial.Failures[0].URL.Path != "/broken.xml" || partial.Failures[1].URL.Path != "/missing.xml" {
+		t.Fatalf("unexpected failures: %+v", partial.Failures)
+	}
+	var statusErr *ErrHTTPStatus
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected ErrPartial to unwrap to ErrHTTPStatus, got %v", err)
+	}
+
+	// OnSitemapError skips parse errors but gives up on server errors.
+	var reported []string
+	fetcher := New(Options{IgnoreRobots: true, OnSitemapError: func(loc *url.URL, err error) error {
+		reported = append(reported, loc.Path)
+		if errors.As(err, &statusErr) {
+			return err
+		}
+		return nil
+	}})
+	if _, err := collectItems(fetcher, sitemapURL); !errors.As(err, &statusErr) || errors.As(err, &partial) {
+		t.Fatalf("expected the callback's error to end the walk, got %v", err)
+	}
+	if !reflect.DeepEqual(reported, []string{"/broken.xml", "/missing.xml"}) {
+		t.Fatalf("unexpected callback calls: %v", reported)
+	}
 }
 
 func collectItems(fetcher *SitemapFetcher, sitemapURL *url.URL) ([]Item, error) {
@@ -366,6 +633,193 @@ func TestSitemapFetcher_DefaultDiscovery(t *testing.T) {
 	}
 }
 
+func TestSitemapFetcher_ParallelProbe(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/auto</loc></url></urlset>`
+
+	var mu sync.Mutex
+	var gets []string
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			mu.Lock()
+			gets = append(gets, r.URL.Path)
+			mu.Unlock()
+		}
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			// Rejecting HEAD leaves the candidate to a regular GET.
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case "/sitemap-index.xml", "/sitemap.xml.gz":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	items, err := collectItems(New(Options{Deterministic: true}), mustParseURL(t, server.URL))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	want := []string{"/robots.txt", "/sitemap_index.xml", "/sitemap-index.xml"}
+	if !reflect.DeepEqual(gets, want) {
+		t.Fatalf("expected GETs %v, got %v", want, gets)
+	}
+}
+
+func TestSitemapFetcher_SequentialProbe(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/auto</loc></url></urlset>`
+
+	var mu sync.Mutex
+	var requests []string
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/robots.txt":
+			// A Crawl-delay keeps probing sequential.
+			_, _ = w.Write([]byte("User-agent: *\nCrawl-delay: 0.001\n"))
+		case "/first.xml", "/second.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{SitemapCandidates: []string{"/missing.xml", "/first.xml", "/second.xml"}})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	want := []string{"GET /robots.txt", "GET /missing.xml", "GET /first.xml"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("expected requests %v, got %v", want, requests)
+	}
+}
+
+func TestSitemapFetcher_SitemapCandidates(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/custom</loc></url></urlset>`
+
+	var probed atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+		case "/sitemap/sitemap.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			probed.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{SitemapCandidates: []string{"/missing.xml", "sitemap/sitemap.xml"}})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Loc.Path != "/custom" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if probed.Load() != 1 {
+		t.Fatalf("expected only /missing.xml to miss, got %d misses", probed.Load())
+	}
+}
+
+func TestSitemapFetcher_NoProbe(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/linked</loc></url></urlset>`
+
+	var probed atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`<html><head><link rel="sitemap" href="/linked.xml"></head></html>`))
+		case "/linked.xml":
+			_, _ = w.Write([]byte(sitemap))
+		case "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			probed.Add(1)
+			_, _ = w.Write([]byte(sitemap))
+		}
+	}))
+	defer server.Close()
+	baseURL := mustParseURL(t, server.URL)
+
+	var noSitemaps *ErrNoSitemaps
+	if _, err := collectItems(New(Options{NoProbe: true}), baseURL); !errors.As(err, &noSitemaps) {
+		t.Fatalf("expected ErrNoSitemaps, got %v", err)
+	}
+
+	items, err := collectItems(New(Options{NoProbe: true, DiscoverFromHTML: true}), baseURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Loc.Path != "/linked" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if probed.Load() != 0 {
+		t.Fatalf("expected no default paths to be requested, got %d", probed.Load())
+	}
+}
+
+func TestSitemapFetcher_SeedSitemaps(t *testing.T) {
+	var probed atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+		case "/one.xml", "/two.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>` +
+				strings.TrimSuffix(r.URL.Path, ".xml") + `</loc></url></urlset>`))
+		default:
+			probed.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{Sitemaps: []*url.URL{
+		mustParseURL(t, server.URL+"/one.xml"),
+		mustParseURL(t, "/two.xml"),
+	}})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 2 || items[0].Loc.Path != "/one" || items[1].Loc.Path != "/two" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if via := items[1].Provenance[0].Via; via != ViaSeed {
+		t.Fatalf("expected via %q, got %q", ViaSeed, via)
+	}
+	if probed.Load() != 0 {
+		t.Fatalf("expected no discovery requests, got %d", probed.Load())
+	}
+
+	fetcher = New(Options{Sitemaps: []*url.URL{mustParseURL(t, server.URL+"/one.xml")}})
+	items, err = collectItems(fetcher, nil)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected a nil website to walk the seeds, got %d items (%v)", len(items), err)
+	}
+}
+
 func TestSitemapFetcher_PerRequestTimeout(t *testing.T) {
 	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/sitemap.xml" {
@@ -388,3 +842,2080 @@ func TestSitemapFetcher_PerRequestTimeout(t *testing.T) {
 		t.Fatalf("expected timeout error, got nil")
 	}
 }
+
+func TestSitemapFetcher_MaxDuration(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sitemap_index.xml" {
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/fast.xml</loc></sitemap>
+  <sitemap><loc>/slow.xml</loc></sitemap>
+</sitemapindex>`))
+			return
+		}
+		if r.URL.Path == "/slow.xml" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{IgnoreRobots: true, MaxDuration: 100 * time.Millisecond})
+	result, err := fetcher.WalkWithResult(context.Background(), mustParseURL(t, server.URL+"/sitemap_index.xml"), func(Item) error { return nil })
+	var deadline *ErrDeadline
+	if !errors.As(err, &deadline) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrDeadline, got %v", err)
+	}
+	if result.URLsYielded != 1 {
+		t.Fatalf("expected partial results before the deadline, got %d URLs", result.URLsYielded)
+	}
+}
+
+func TestSitemapFetcher_MaxBytes(t *testing.T) {
+	const index = `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/one.xml</loc></sitemap>
+  <sitemap><loc>/two.xml</loc></sitemap>
+  <sitemap><loc>/three.xml</loc></sitemap>
+</sitemapindex>`
+	const sitemap = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`
+	var requests atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/sitemap_index.xml" {
+			_, _ = w.Write([]byte(index))
+			return
+		}
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+	indexURL := mustParseURL(t, server.URL+"/sitemap_index.xml")
+
+	// The budget covers the index and one sitemap, and runs out inside the second.
+	budget := int64(len(index) + len(sitemap) + 10)
+	fetcher := New(Options{IgnoreRobots: true, MaxBytes: budget})
+	result, err := fetcher.WalkWithResult(context.Background(), indexURL, func(Item) error { return nil })
+	var maxBytes *ErrMaxBytes
+	if !errors.As(err, &maxBytes) || maxBytes.MaxBytes != budget || maxBytes.URL == nil || maxBytes.URL.Path != "/two.xml" {
+		t.Fatalf("expected ErrMaxBytes at /two.xml, got %v", err)
+	}
+	if result.URLsYielded != 1 || result.BytesDownloaded != budget {
+		t.Fatalf("expected partial results within the budget, got %d URLs and %d bytes", result.URLsYielded, result.BytesDownloaded)
+	}
+	if requests.Load() != 3 {
+		t.Fatalf("expected no request once the budget was spent, got %d", requests.Load())
+	}
+
+	fetcher = New(Options{IgnoreRobots: true, MaxBytes: budget, LimitBehavior: LimitStop})
+	result, err = fetcher.WalkWithResult(context.Background(), indexURL, func(Item) error { return nil })
+	if err != nil || !errors.As(result.LimitReached, &maxBytes) {
+		t.Fatalf("expected LimitStop to end the walk cleanly, got %v (%v)", err, result.LimitReached)
+	}
+
+	// Local sitemaps count against the budget too.
+	dir := t.TempDir()
+	localIndex := strings.ReplaceAll(index, "<loc>/", "<loc>")
+	budget = int64(len(localIndex) + len(sitemap) + 10)
+	for name, body := range map[string]string{"sitemap_index.xml": localIndex, "one.xml": sitemap, "two.xml": sitemap, "three.xml": sitemap} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+	fetcher = New(Options{IgnoreRobots: true, MaxBytes: budget})
+	result, err = fetcher.WalkWithResult(context.Background(), FileURL(filepath.Join(dir, "sitemap_index.xml")), func(Item) error { return nil })
+	if !errors.As(err, &maxBytes) || maxBytes.URL == nil || !strings.HasSuffix(maxBytes.URL.Path, "/two.xml") {
+		t.Fatalf("expected ErrMaxBytes at the local two.xml, got %v", err)
+	}
+	if result.URLsYielded != 1 || result.BytesDownloaded != budget {
+		t.Fatalf("expected partial local results within the budget, got %d URLs and %d bytes", result.URLsYielded, result.BytesDownloaded)
+	}
+}
+
+func TestSitemapFetcher_StopWhen(t *testing.T) {
+	var requests atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/sitemap_index.xml" {
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/one.xml</loc></sitemap>
+  <sitemap><loc>/two.xml</loc></sitemap>
+  <sitemap><loc>/three.xml</loc></sitemap>
+</sitemapindex>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/a</loc></url><url><loc>/b</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	var seen []Progress
+	fetcher := New(Options{IgnoreRobots: true, StopWhen: func(p Progress) bool {
+		seen = append(seen, p)
+		return p.URLsYielded >= 3
+	}})
+	result, err := fetcher.WalkWithResult(context.Background(), mustParseURL(t, server.URL+"/sitemap_index.xml"), func(Item) error { return nil })
+	if err != nil {
+		t.Fatalf("expected StopWhen to end the walk cleanly, got %v", err)
+	}
+	if !result.Stopped || result.URLsYielded != 4 || requests.Load() != 3 {
+		t.Fatalf("expected the walk to stop before /three.xml, got stopped=%v, %d URLs, %d requests", result.Stopped, result.URLsYielded, requests.Load())
+	}
+	last := seen[len(seen)-1]
+	if len(seen) != 4 || last.SitemapsFetched != 3 || last.SitemapsQueued != 1 || last.BytesDownloaded == 0 {
+		t.Fatalf("unexpected progress: %+v", seen)
+	}
+}
+
+func TestSitemapFetcher_TraversalOrder(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/nested.xml</loc><lastmod>2023-01-01</lastmod></sitemap>
+  <sitemap><loc>/old.xml</loc><lastmod>2020-01-01</lastmod></sitemap>
+  <sitemap><loc>/none.xml</loc></sitemap>
+  <sitemap><loc>/new.xml</loc><lastmod>2024-01-01</lastmod></sitemap>
+  <sitemap><loc>/mid.xml</loc><lastmod>2022-01-01</lastmod></sitemap>
+</sitemapindex>`))
+		case "/nested.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/deep.xml</loc><lastmod>2021-01-01</lastmod></sitemap>
+</sitemapindex>`))
+		default:
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		order TraversalOrder
+		want  []string
+	}{
+		{TraversalBFS, []string{"/sitemap_index.xml", "/nested.xml", "/old.xml", "/none.xml", "/new.xml", "/mid.xml", "/deep.xml"}},
+		{TraversalDFS, []string{"/sitemap_index.xml", "/nested.xml", "/deep.xml", "/old.xml", "/none.xml", "/new.xml", "/mid.xml"}},
+		{TraversalNewestFirst, []string{"/sitemap_index.xml", "/new.xml", "/nested.xml", "/mid.xml", "/deep.xml", "/old.xml", "/none.xml"}},
+	}
+	for _, tt := range tests {
+		fetched = nil
+		fetcher := New(Options{IgnoreRobots: true, TraversalOrder: tt.order})
+		if _, err := collectItems(fetcher, mustParseURL(t, server.URL+"/sitemap_index.xml")); err != nil {
+			t.Fatalf("order %d: walk failed: %v", tt.order, err)
+		}
+		if !reflect.DeepEqual(fetched, tt.want) {
+			t.Fatalf("order %d: expected %v, got %v", tt.order, tt.want, fetched)
+		}
+	}
+}
+
+func TestSitemapFetcher_Deterministic(t *testing.T) {
+	// The site lists its sitemaps in reverse order on every other run.
+	var reversed atomic.Bool
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			lines := []string{"Sitemap: /b-index.xml", "Sitemap: /a.xml"}
+			if reversed.Load() {
+				lines[0], lines[1] = lines[1], lines[0]
+			}
+			_, _ = w.Write([]byte(strings.Join(lines, "\n") + "\n"))
+		case "/b-index.xml":
+			children := []string{"/z.xml", "/y.xml", "/x.xml"}
+			if reversed.Load() {
+				slices.Reverse(children)
+			}
+			body := `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`
+			for _, child := range children {
+				body += "<sitemap><loc>" + child + "</loc></sitemap>"
+			}
+			_, _ = w.Write([]byte(body + "</sitemapindex>"))
+		default:
+			page := strings.TrimSuffix(r.URL.Path, ".xml") + "-page"
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>` + page + `</loc></url></urlset>`))
+		}
+	}))
+	defer server.Close()
+
+	walk := func(opts Options) []string {
+		items, err := collectItems(New(opts), mustParseURL(t, server.URL))
+		if err != nil {
+			t.Fatalf("walk failed: %v", err)
+		}
+		var paths []string
+		for _, item := range items {
+			paths = append(paths, item.Loc.Path)
+		}
+		return paths
+	}
+	want := []string{"/a-page", "/x-page", "/y-page", "/z-page"}
+	for run := 0; run < 2; run++ {
+		reversed.Store(run == 1)
+		if got := walk(Options{Deterministic: true}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("run %d: expected %v, got %v", run, want, got)
+		}
+	}
+	reversed.Store(false)
+	if got := walk(Options{}); reflect.DeepEqual(got, want) {
+		t.Fatalf("expected document order without Deterministic, got %v", got)
+	}
+}
+
+func TestSitemapFetcher_DiscoverFromHTML(t *testing.T) {
+	const homepage = `<!doctype html><html><head>
+<link rel="stylesheet" href="/style.css">
+<link rel="sitemap" type="application/xml" title="Sitemap" href="/maps/site.xml">
+</head><body></body></html>`
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>/from-html</loc>
+  </url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(homepage))
+		case "/maps/site.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse base URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{}), baseURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expected no items without HTML discovery, got %d", len(items))
+	}
+
+	items, err = collectItems(New(Options{DiscoverFromHTML: true}), baseURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if !strings.HasSuffix(items[0].Loc.String(), "/from-html") {
+		t.Fatalf("expected /from-html URL, got %s", items[0].Loc.String())
+	}
+}
+
+func TestSitemapFetcher_UserAgentSuffix(t *testing.T) {
+	var gotUA atomic.Value
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA.Store(r.UserAgent())
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><urlset></urlset>`))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"default agent", Options{UserAgentSuffix: "+https://example.com/bot"}, DefaultUserAgent + " +https://example.com/bot"},
+		{"custom agent", Options{UserAgent: "MyBot/1.0", UserAgentSuffix: " +https://example.com/bot "}, "MyBot/1.0 +https://example.com/bot"},
+		{"agent from headers", Options{Headers: http.Header{"user-agent": {"HeaderBot/2.0"}}, UserAgentSuffix: "+https://example.com/bot"}, "HeaderBot/2.0 +https://example.com/bot"},
+		{"agent from headers without suffix", Options{Headers: http.Header{"User-Agent": {"HeaderBot/2.0"}}}, "HeaderBot/2.0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := collectItems(New(tc.opts), sitemapURL); err != nil {
+				t.Fatalf("walk failed: %v", err)
+			}
+			if got, _ := gotUA.Load().(string); got != tc.want {
+				t.Fatalf("expected user agent %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSitemapFetcher_Headers(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "staging" || password != "secret" || r.Header.Get("X-Env") != "preview" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{Headers: http.Header{
+		"Authorization": {BasicAuthHeader("staging", "secret")},
+		"x-env":         {"preview"},
+	}})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if got := BearerAuthHeader("token"); got != "Bearer token" {
+		t.Fatalf("unexpected bearer header %q", got)
+	}
+}
+
+func TestSitemapFetcher_HeadersCrossHost(t *testing.T) {
+	var leaked atomic.Value
+	cdn := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/page</loc></url></urlset>`))
+	}))
+	defer cdn.Close()
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>` + cdn.URL + `/pages.xml</loc></sitemap></sitemapindex>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	headers := http.Header{"Authorization": {BearerAuthHeader("secret")}}
+	items, err := collectItems(New(Options{Headers: headers}), mustParseURL(t, server.URL))
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected 1 item from the cross-host child, got %d (%v)", len(items), err)
+	}
+	if got, _ := leaked.Load().(string); got != "" {
+		t.Fatalf("expected no Authorization on the cross-host child, got %q", got)
+	}
+
+	cdnHost := mustParseURL(t, cdn.URL).Host
+	if _, err := collectItems(New(Options{Headers: headers, HeaderHosts: []string{cdnHost}}), mustParseURL(t, server.URL)); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if got, _ := leaked.Load().(string); got != "Bearer secret" {
+		t.Fatalf("expected HeaderHosts to send Authorization to %s, got %q", cdnHost, got)
+	}
+}
+
+func TestSitemapFetcher_CDATAAndWhitespace(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc><![CDATA[
+      /nested.xml
+    ]]></loc>
+  </sitemap>
+</sitemapindex>`
+	const nested = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc><![CDATA[https://example.com/a?x=1&y=2]]></loc>
+    <lastmod>
+      2024-01-02
+    </lastmod>
+    <changefreq> <![CDATA[weekly]]> </changefreq>
+    <priority>
+      0.4
+    </priority>
+  </url>
+  <url>
+    <loc>
+      https://example.com/b
+    </loc>
+  </url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/nested.xml":
+			_, _ = w.Write([]byte(nested))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	indexURL, err := url.Parse(server.URL + "/index.xml")
+	if err != nil {
+		t.Fatalf("failed to parse index URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), indexURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if got := items[0].Loc.String(); got != "https://example.com/a?x=1&y=2" {
+		t.Fatalf("expected CDATA loc to be unwrapped, got %q", got)
+	}
+	if items[0].LastMod == nil || items[0].LastMod.Format("2006-01-02") != "2024-01-02" {
+		t.Fatalf("expected padded lastmod to be parsed, got %v", items[0].LastMod)
+	}
+	if items[0].ChangeFreq != "weekly" {
+		t.Fatalf("expected changefreq weekly, got %q", items[0].ChangeFreq)
+	}
+	if items[0].Priority == nil || *items[0].Priority != 0.4 {
+		t.Fatalf("expected priority 0.4, got %v", items[0].Priority)
+	}
+	if got := items[1].Loc.String(); got != "https://example.com/b" {
+		t.Fatalf("expected padded loc to be trimmed, got %q", got)
+	}
+}
+
+func TestSitemapFetcher_CrawlDelay(t *testing.T) {
+	const robots = "User-agent: *\nCrawl-delay: 5\n"
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/a.xml</loc></sitemap>
+  <sitemap><loc>/b.xml</loc></sitemap>
+</sitemapindex>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte(robots))
+		case "/index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/a.xml", "/b.xml":
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><urlset></urlset>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	indexURL, err := url.Parse(server.URL + "/index.xml")
+	if err != nil {
+		t.Fatalf("failed to parse index URL: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := collectItems(New(Options{MaxCrawlDelay: 50 * time.Millisecond}), indexURL); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("expected capped crawl-delay between requests, walk took %s", elapsed)
+	}
+
+	start = time.Now()
+	if _, err := collectItems(New(Options{IgnoreCrawlDelay: true}), indexURL); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("expected crawl-delay to be ignored, walk took %s", elapsed)
+	}
+}
+
+func TestSitemapFetcher_RetryPolicy(t *testing.T) {
+	var requests int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>/ok</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	_, err = collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	var statusErr *ErrHTTPStatus
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without retry policy, got %v", err)
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	fetcher := New(Options{
+		IgnoreRobots: true,
+		Retry: RetryPolicy{
+			StatusCodes: []int{http.StatusServiceUnavailable},
+			BaseDelay:   time.Millisecond,
+		},
+	})
+	items, err := collectItems(fetcher, sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if got := atomic.LoadInt32(&requests); got != 3 {
+		t.Fatalf("expected 3 requests, got %d", got)
+	}
+
+	// Without Retry-After the delay is fixed unless Exponential is set.
+	fixed := RetryPolicy{}.withDefaults()
+	exponential := RetryPolicy{Exponential: true}.withDefaults()
+	for attempt, want := range []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 30 * time.Second} {
+		if got := fixed.delay(attempt, nil); got != 5*time.Second {
+			t.Fatalf("attempt %d: expected the default delay to stay at 5s, got %v", attempt, got)
+		}
+		if got := exponential.delay(attempt, nil); got != want {
+			t.Fatalf("attempt %d: expected an exponential delay of %v, got %v", attempt, want, got)
+		}
+	}
+}
+
+func TestSitemapFetcher_FetchError(t *testing.T) {
+	server := newTestServer(t, http.NotFoundHandler())
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+	server.Close()
+
+	for _, tc := range []struct {
+		retry   RetryPolicy
+		attempt int
+	}{
+		{RetryPolicy{}, 1},
+		{RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, NetworkErrors: true}, 3},
+	} {
+		_, err := collectItems(New(Options{IgnoreRobots: true, Retry: tc.retry}), sitemapURL)
+		var fetchErr *ErrFetch
+		if !errors.As(err, &fetchErr) || fetchErr.URL.String() != sitemapURL.String() || fetchErr.Attempt != tc.attempt {
+			t.Fatalf("expected ErrFetch for %s after %d attempts, got %v", sitemapURL, tc.attempt, err)
+		}
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) {
+			t.Fatalf("expected ErrFetch to unwrap to the transport error, got %v", err)
+		}
+	}
+}
+
+func TestSitemapFetcher_StructuredLogs(t *testing.T) {
+	var calls atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`<urlset><url><loc>/page</loc></url></urlset>`))
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	fetcher := New(Options{
+		IgnoreRobots: true,
+		Logger:       logger,
+		Retry:        RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, StatusCodes: []int{http.StatusServiceUnavailable}},
+	})
+	if _, err := collectItems(fetcher, sitemapURL); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON log line %q: %v", line, err)
+		}
+		if record["msg"] != "retryable status, retrying" {
+			continue
+		}
+		if record["url"] != sitemapURL.String() || record["status"] != float64(http.StatusServiceUnavailable) || record["attempt"] != float64(1) {
+			t.Fatalf("expected url, status, and attempt attributes, got %v", record)
+		}
+		return
+	}
+	t.Fatalf("expected a retry log record, got %s", logs.String())
+}
+
+func TestSitemapFetcher_WalkIDLogs(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset><url><loc>/page</loc><priority>7</priority></url></urlset>`))
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	fetcher := New(Options{IgnoreRobots: true, Logger: logger, PriorityPolicy: PriorityReject})
+
+	var walkIDs []string
+	for range 2 {
+		logs.Reset()
+		if _, err := collectItems(fetcher, sitemapURL); err != nil {
+			t.Fatalf("walk failed: %v", err)
+		}
+		ids := map[any]bool{}
+		for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("invalid JSON log line %q: %v", line, err)
+			}
+			ids[record["walk_id"]] = true
+		}
+		if len(ids) != 1 || ids[nil] || ids[""] {
+			t.Fatalf("expected one walk_id on every record of a walk, got %v in %s", ids, logs.String())
+		}
+		for id := range ids {
+			walkIDs = append(walkIDs, id.(string))
+		}
+	}
+	if walkIDs[0] == walkIDs[1] {
+		t.Fatalf("expected a new walk_id per walk, got %s twice", walkIDs[0])
+	}
+}
+
+func TestSitemapFetcher_Charset(t *testing.T) {
+	sitemaps := map[string]string{
+		// "café" and "новости" in ISO-8859-1 and Windows-1251.
+		"/latin1.xml":  "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><urlset><url><loc>/caf\xe9</loc></url></urlset>",
+		"/cp1251.xml":  "<?xml version=\"1.0\" encoding=\"windows-1251\"?><urlset><url><loc>/\xed\xee\xe2\xee\xf1\xf2\xe8</loc></url></urlset>",
+		"/unknown.xml": "<?xml version=\"1.0\" encoding=\"x-unknown\"?><urlset><url><loc>/page</loc></url></urlset>",
+	}
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemaps[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	for path, want := range map[string]string{"/latin1.xml": "/café", "/cp1251.xml": "/новости"} {
+		items, err := collectItems(New(Options{IgnoreRobots: true}), mustParseURL(t, server.URL+path))
+		if err != nil {
+			t.Fatalf("%s: walk failed: %v", path, err)
+		}
+		if len(items) != 1 || items[0].Loc.Path != want {
+			t.Fatalf("%s: expected %s, got %+v", path, want, items)
+		}
+	}
+	var parseErr *ErrSitemapParse
+	if _, err := collectItems(New(Options{IgnoreRobots: true}), mustParseURL(t, server.URL+"/unknown.xml")); !errors.As(err, &parseErr) {
+		t.Fatalf("expected an unknown encoding to fail parsing, got %v", err)
+	}
+}
+
+func TestSitemapFetcher_ContentEncoding(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/encoded</loc></url>
+</urlset>`
+
+	gzipBytes := func(data []byte) []byte {
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		_, _ = gzipWriter.Write(data)
+		_ = gzipWriter.Close()
+		return buf.Bytes()
+	}
+	once := gzipBytes([]byte(sitemap))
+	twice := gzipBytes(once)
+
+	var acceptEncoding atomic.Value
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding.Store(r.Header.Get("Accept-Encoding"))
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			if r.Header.Get("Accept-Encoding") != "gzip" {
+				_, _ = w.Write([]byte(sitemap))
+				return
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(once)
+		case "/sitemap.xml.gz":
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(twice)
+		case "/brotli.xml":
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = w.Write([]byte("not brotli"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/sitemap.xml", "/sitemap.xml.gz"} {
+		sitemapURL, _ := url.Parse(server.URL + path)
+		items, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+		if err != nil {
+			t.Fatalf("walk %s failed: %v", path, err)
+		}
+		if len(items) != 1 || items[0].Loc.Path != "/encoded" {
+			t.Fatalf("unexpected items for %s: %+v", path, items)
+		}
+		if got := acceptEncoding.Load(); got != "gzip" {
+			t.Fatalf("expected Accept-Encoding gzip, got %v", got)
+		}
+	}
+
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+	items, err := collectItems(New(Options{IgnoreRobots: true, DisableCompression: true}), sitemapURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected uncompressed walk to succeed, got %d (%v)", len(items), err)
+	}
+	if got := acceptEncoding.Load(); got != "identity" {
+		t.Fatalf("expected Accept-Encoding identity, got %v", got)
+	}
+
+	brotliURL, _ := url.Parse(server.URL + "/brotli.xml")
+	_, err = collectItems(New(Options{IgnoreRobots: true}), brotliURL)
+	var encodingErr *ErrContentEncoding
+	if !errors.As(err, &encodingErr) || encodingErr.Encoding != "br" {
+		t.Fatalf("expected ErrContentEncoding, got %v", err)
+	}
+}
+
+func TestSitemapFetcher_ParseFile(t *testing.T) {
+	dir := t.TempDir()
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>pages/sitemap-pages.xml.gz</loc></sitemap>
+</sitemapindex>`
+	const pages = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/a</loc></url>
+  <url><loc>https://example.com/b</loc></url>
+</urlset>`
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte(pages))
+	_ = gzipWriter.Close()
+
+	if err := os.MkdirAll(filepath.Join(dir, "pages"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sitemap.xml"), []byte(index), 0o644); err != nil {
+		t.Fatalf("write index failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pages", "sitemap-pages.xml.gz"), gzipped.Bytes(), 0o644); err != nil {
+		t.Fatalf("write sitemap failed: %v", err)
+	}
+
+	fetcher := New(Options{IgnoreRobots: true, CrossHostPolicy: CrossHostError, StrictSpec: true})
+	var items []Item
+	err := fetcher.ParseFile(context.Background(), filepath.Join(dir, "sitemap.xml"), func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parse file failed: %v", err)
+	}
+	if len(items) != 2 || items[0].Loc.String() != "https://example.com/a" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if items[0].Sitemap.Scheme != "file" || !strings.HasSuffix(items[0].Sitemap.Path, "/pages/sitemap-pages.xml.gz") {
+		t.Fatalf("unexpected sitemap URL: %v", items[0].Sitemap)
+	}
+
+	fileURL, _ := url.Parse("file://" + filepath.ToSlash(filepath.Join(dir, "missing.xml")))
+	_, err = collectItems(fetcher, fileURL)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestSitemapFetcher_RemoteFileSitemap(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret.xml")
+	if err := os.WriteFile(secret, []byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/secret</loc></url></urlset>`), 0o644); err != nil {
+		t.Fatalf("write sitemap failed: %v", err)
+	}
+	local := FileURL(secret).String()
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("Sitemap: " + local + "\n"))
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>` + local + `</loc></sitemap></sitemapindex>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, input := range []string{server.URL + "/sitemap_index.xml", server.URL} {
+		items, err := collectItems(New(Options{}), mustParseURL(t, input))
+		var local *ErrLocalSitemap
+		if !errors.As(err, &local) || len(items) != 0 {
+			t.Fatalf("%s: expected ErrLocalSitemap and no items, got %d items (%v)", input, len(items), err)
+		}
+	}
+
+	var partial *ErrPartial
+	items, err := collectItems(New(Options{OnErrorContinue: true}), mustParseURL(t, server.URL+"/sitemap_index.xml"))
+	if !errors.As(err, &partial) || len(items) != 0 {
+		t.Fatalf("expected the local child to be skipped as a failed sitemap, got %d items (%v)", len(items), err)
+	}
+}
+
+func TestSitemapFetcher_ChangeFreq(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc><changefreq> Daily </changefreq></url>
+  <url><loc>/b</loc><changefreq>sometimes</changefreq></url>
+  <url><loc>/c</loc></url>
+</urlset>`))
+	}))
+	defer server.Close()
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), mustParseURL(t, server.URL+"/sitemap.xml"))
+	if err != nil || len(items) != 3 {
+		t.Fatalf("walk failed: %d items (%v)", len(items), err)
+	}
+	for i, want := range []struct {
+		freq  ChangeFreq
+		valid bool
+	}{{ChangeFreqDaily, true}, {"sometimes", false}, {"", false}} {
+		if items[i].ChangeFreq != want.freq || items[i].ChangeFreq.Valid() != want.valid {
+			t.Fatalf("%s: expected %q (valid %t), got %q", items[i].Loc.Path, want.freq, want.valid, items[i].ChangeFreq)
+		}
+	}
+}
+
+func TestSitemapFetcher_RawMetadata(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/clean</loc><lastmod>2024-05-01</lastmod><priority>0.5</priority></url>
+  <url><loc>/broken</loc><lastmod> yesterday </lastmod><priority>high</priority></url>
+  <url><loc>/clamped</loc><priority>5</priority></url>
+  <url><loc>/bare</loc></url>
+</urlset>`))
+	}))
+	defer server.Close()
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, PriorityPolicy: PriorityClamp}), mustParseURL(t, server.URL+"/sitemap.xml"))
+	if err != nil || len(items) != 4 {
+		t.Fatalf("walk failed: %d items (%v)", len(items), err)
+	}
+	want := map[string][2]string{
+		"/clean":   {"", ""},
+		"/broken":  {"yesterday", "high"},
+		"/clamped": {"", "5"},
+		"/bare":    {"", ""},
+	}
+	for _, item := range items {
+		if got := [2]string{item.RawLastMod, item.RawPriority}; got != want[item.Loc.Path] {
+			t.Fatalf("%s: expected raw values %q, got %q", item.Loc.Path, want[item.Loc.Path], got)
+		}
+	}
+}
+
+func TestSitemapFetcher_PriorityPolicy(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/high</loc><priority>10</priority></url>
+  <url><loc>/low</loc><priority>-1</priority></url>
+  <url><loc>/nan</loc><priority>NaN</priority></url>
+  <url><loc>/ok</loc><priority>0.5</priority></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	format := func(p *float64) string {
+		if p == nil {
+			return "nil"
+		}
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	}
+	cases := []struct {
+		policy PriorityPolicy
+		want   []string
+	}{
+		{PriorityKeep, []string{"10", "-1", "NaN", "0.5"}},
+		{PriorityClamp, []string{"1", "0", "nil", "0.5"}},
+		{PriorityReject, []string{"nil", "nil", "nil", "0.5"}},
+	}
+	for _, tc := range cases {
+		items, err := collectItems(New(Options{IgnoreRobots: true, PriorityPolicy: tc.policy}), sitemapURL)
+		if err != nil {
+			t.Fatalf("walk failed for policy %d: %v", tc.policy, err)
+		}
+		if len(items) != len(tc.want) {
+			t.Fatalf("expected %d items for policy %d, got %d", len(tc.want), tc.policy, len(items))
+		}
+		for i, item := range items {
+			if got := format(item.Priority); got != tc.want[i] {
+				t.Fatalf("policy %d: expected priority %s for %s, got %s", tc.policy, tc.want[i], item.Loc.Path, got)
+			}
+		}
+	}
+}
+
+func TestSitemapFetcher_Mirrors(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-pages.xml</loc></sitemap>
+</sitemapindex>`
+	const pages = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/page</loc></url>
+</urlset>`
+
+	var mirrorPaths []string
+	mirror := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorPaths = append(mirrorPaths, r.URL.Path)
+		switch r.URL.Path {
+		case "/origin/sitemap.xml":
+			_, _ = w.Write([]byte(index))
+		case "/origin/sitemap-pages.xml":
+			_, _ = w.Write([]byte(pages))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mirror.Close()
+
+	primary := newTestServer(t, http.NotFoundHandler())
+	primaryURL := primary.URL
+	primary.Close()
+
+	sitemapURL, _ := url.Parse(primaryURL + "/sitemap.xml")
+	mirrorURL, _ := url.Parse(mirror.URL + "/origin/")
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	if err == nil {
+		t.Fatalf("expected network error without mirrors, got %d items", len(items))
+	}
+
+	items, err = collectItems(New(Options{IgnoreRobots: true, Mirrors: []*url.URL{mirrorURL}}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	item := items[0]
+	if !strings.HasPrefix(item.Loc.String(), primaryURL) || !strings.HasPrefix(item.Sitemap.String(), primaryURL) {
+		t.Fatalf("expected URLs on the primary host, got %s in %s", item.Loc, item.Sitemap)
+	}
+	if len(item.Provenance) != 2 {
+		t.Fatalf("expected 2 hops, got %d", len(item.Provenance))
+	}
+	for i, want := range []string{"/origin/sitemap.xml", "/origin/sitemap-pages.xml"} {
+		hop := item.Provenance[i]
+		if hop.Source == nil || hop.Source.String() != mirror.URL+want {
+			t.Fatalf("hop %d: expected source %s, got %v", i, mirror.URL+want, hop.Source)
+		}
+	}
+	if len(mirrorPaths) != 2 {
+		t.Fatalf("expected 2 mirror requests, got %v", mirrorPaths)
+	}
+}
+
+func TestSitemapFetcher_GzipCorruptionRetry(t *testing.T) {
+	var first, second strings.Builder
+	first.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+`)
+	for i := 0; i < 100; i++ {
+		first.WriteString("  <url><loc>/first-" + strconv.Itoa(i) + "</loc></url>\n")
+		second.WriteString("  <url><loc>/second-" + strconv.Itoa(i) + "</loc></url>\n")
+	}
+	second.WriteString("</urlset>")
+	plain := first.String() + second.String()
+
+	gzipBytes := func(data string) []byte {
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		_, _ = gzipWriter.Write([]byte(data))
+		_ = gzipWriter.Close()
+		return buf.Bytes()
+	}
+	// The first gzip member carries a bad CRC, so the stream fails halfway
+	// through the sitemap, after the first entries have been yielded.
+	corrupt := gzipBytes(first.String())
+	corrupt[len(corrupt)-8] ^= 0xff
+	corrupt = append(corrupt, gzipBytes(second.String())...)
+
+	var requests []string
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("Accept-Encoding"))
+		if r.Header.Get("Accept-Encoding") == "gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(corrupt)
+			return
+		}
+		_, _ = w.Write([]byte(plain))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(requests) != 2 || requests[0] != "gzip" || requests[1] != "identity" {
+		t.Fatalf("expected a gzip request followed by an identity retry, got %v", requests)
+	}
+	seen := map[string]bool{}
+	for _, item := range items {
+		if seen[item.Loc.Path] {
+			t.Fatalf("duplicate item %s after retry", item.Loc.Path)
+		}
+		seen[item.Loc.Path] = true
+	}
+	if len(items) != 200 {
+		t.Fatalf("expected 200 items, got %d", len(items))
+	}
+}
+
+func TestSitemapFetcher_Hooks(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-pages.xml</loc></sitemap>
+  <sitemap><loc>/sitemap-broken.xml</loc></sitemap>
+</sitemapindex>`
+	const pages = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <url><loc>/b</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\nSitemap: /sitemap_index.xml\n"))
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/sitemap-pages.xml":
+			_, _ = w.Write([]byte(pages))
+		case "/sitemap-broken.xml":
+			_, _ = w.Write([]byte("<urlset><url>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	type done struct {
+		path  string
+		urls  int
+		bytes int64
+		err   error
+	}
+	var starts []string
+	var dones []done
+	var robots []bool
+	var pending []string
+	fetcher := New(Options{Hooks: Hooks{
+		OnSitemapStart: func(loc *url.URL) { starts = append(starts, loc.Path) },
+		OnSitemapDone: func(loc *url.URL, urlCount int, bytes int64, duration time.Duration, err error) {
+			dones = append(dones, done{path: loc.Path, urls: urlCount, bytes: bytes, err: err})
+		},
+		OnRobotsFetched: func(host string, found bool) { robots = append(robots, found) },
+		OnQueueChange:   func(n int) { pending = append(pending, strconv.Itoa(n)) },
+	}})
+
+	websiteURL, _ := url.Parse(server.URL)
+	_, err := collectItems(fetcher, websiteURL)
+	var parseErr *ErrSitemapParse
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ErrSitemapParse, got %v", err)
+	}
+
+	if len(robots) != 1 || !robots[0] {
+		t.Fatalf("expected one successful robots fetch, got %v", robots)
+	}
+	wantStarts := []string{"/sitemap_index.xml", "/sitemap-pages.xml", "/sitemap-broken.xml"}
+	if strings.Join(starts, ",") != strings.Join(wantStarts, ",") {
+		t.Fatalf("unexpected starts: %v", starts)
+	}
+	if len(dones) != 3 {
+		t.Fatalf("expected 3 done events, got %+v", dones)
+	}
+	if dones[0].urls != 0 || dones[0].bytes != int64(len(index)) || dones[0].err != nil {
+		t.Fatalf("unexpected index event: %+v", dones[0])
+	}
+	if dones[1].urls != 2 || dones[1].bytes != int64(len(pages)) || dones[1].err != nil {
+		t.Fatalf("unexpected pages event: %+v", dones[1])
+	}
+	if !errors.As(dones[2].err, &parseErr) {
+		t.Fatalf("expected parse error for broken sitemap, got %+v", dones[2])
+	}
+	if got := strings.Join(pending, ","); got != "1,0,1,2,1,0" {
+		t.Fatalf("unexpected queue changes: %s", got)
+	}
+}
+
+func TestSitemapFetcher_CaptureExtensions(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="` + ImageNamespace + `">
+  <url>
+    <loc>/page</loc>
+    <image:image><image:loc>/photo.jpg</image:loc></image:image>
+    <PageMap xmlns="http://www.google.com/schemas/sitemap-pagemap/1.0">
+      <DataObject type="document"><Attribute name="author">Jane</Attribute></DataObject>
+    </PageMap>
+  </url>
+</urlset>`))
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	if err != nil || len(items) != 1 || items[0].Extensions != nil {
+		t.Fatalf("expected no extensions by default, got %+v (%v)", items, err)
+	}
+
+	items, err = collectItems(New(Options{IgnoreRobots: true, CaptureExtensions: true}), sitemapURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("walk failed: %d items (%v)", len(items), err)
+	}
+	pageMap := "http://www.google.com/schemas/sitemap-pagemap/1.0"
+	want := []ExtensionElement{
+		{
+			XMLName:  xml.Name{Space: ImageNamespace, Local: "image"},
+			Children: []ExtensionElement{{XMLName: xml.Name{Space: ImageNamespace, Local: "loc"}, Text: "/photo.jpg"}},
+		},
+		{
+			XMLName: xml.Name{Space: pageMap, Local: "PageMap"},
+			Attrs:   []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: pageMap}},
+			Children: []ExtensionElement{{
+				XMLName: xml.Name{Space: pageMap, Local: "DataObject"},
+				Attrs:   []xml.Attr{{Name: xml.Name{Local: "type"}, Value: "document"}},
+				Children: []ExtensionElement{{
+					XMLName: xml.Name{Space: pageMap, Local: "Attribute"},
+					Attrs:   []xml.Attr{{Name: xml.Name{Local: "name"}, Value: "author"}},
+					Text:    "Jane",
+				}},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(items[0].Extensions, want) {
+		t.Fatalf("unexpected extensions:\n got %+v\nwant %+v", items[0].Extensions, want)
+	}
+}
+
+func TestSitemapFetcher_RequireNamespace(t *testing.T) {
+	docs := map[string]string{
+		"/sitemap.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <other:url xmlns:other="urn:other"><loc>/b</loc></other:url>
+</urlset>`,
+		"/bare.xml": `<urlset><url><loc>/a</loc></url></urlset>`,
+		"/feed.xml": `<rss><channel><item><url><loc>/a</loc></url></item></channel></rss>`,
+	}
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(docs[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	for path := range docs {
+		items, err := collectItems(New(Options{IgnoreRobots: true}), mustParseURL(t, server.URL+path))
+		if err != nil || len(items) == 0 {
+			t.Fatalf("%s: expected local names to match by default, got %d items (%v)", path, len(items), err)
+		}
+	}
+
+	fetcher := New(Options{IgnoreRobots: true, RequireNamespace: true})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL+"/sitemap.xml"))
+	if err != nil || len(items) != 1 || items[0].Loc.Path != "/a" {
+		t.Fatalf("expected only the sitemaps.org entry, got %+v (%v)", items, err)
+	}
+	for _, path := range []string{"/bare.xml", "/feed.xml"} {
+		items, err := collectItems(fetcher, mustParseURL(t, server.URL+path))
+		var notSitemap *ErrNotSitemap
+		if !errors.As(err, &notSitemap) || notSitemap.URL.Path != path || len(items) != 0 {
+			t.Fatalf("%s: expected ErrNotSitemap, got %d items (%v)", path, len(items), err)
+		}
+	}
+}
+
+func TestSitemapFetcher_RequireExtensions(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+        xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+        xmlns:v="http://www.google.com/schemas/sitemap-video/1.1">
+  <url><loc>/plain</loc></url>
+  <url><loc>/photo</loc><image:image><image:loc>/photo.jpg</image:loc></image:image></url>
+  <url><loc>/clip</loc><v:video><v:title>Clip</v:title></v:video></url>
+  <url><loc>/story</loc><news:news><news:title>Undeclared prefix</news:title></news:news></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	cases := []struct {
+		require []Extension
+		want    string
+	}{
+		{nil, "/plain,/photo,/clip,/story"},
+		{[]Extension{ExtensionImage}, "/photo"},
+		{[]Extension{ExtensionVideo, ExtensionNews}, "/clip,/story"},
+	}
+	for _, tc := range cases {
+		items, err := collectItems(New(Options{IgnoreRobots: true, RequireExtensions: tc.require}), sitemapURL)
+		if err != nil {
+			t.Fatalf("walk failed for %v: %v", tc.require, err)
+		}
+		paths := make([]string, 0, len(items))
+		for _, item := range items {
+			paths = append(paths, item.Loc.Path)
+		}
+		if got := strings.Join(paths, ","); got != tc.want {
+			t.Fatalf("require %v: expected %s, got %s", tc.require, tc.want, got)
+		}
+	}
+}
+
+func TestSitemapParser_ExtensionNames(t *testing.T) {
+	const sitemap = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url><loc>/photo</loc><image:image><image:loc>/photo.jpg</image:loc></image:image></url>
+</urlset>`
+	image := xml.Name{Space: ImageNamespace, Local: "image"}
+
+	for _, tc := range []struct {
+		name   string
+		parser sitemapParser
+		want   []xml.Name
+	}{
+		{"default", sitemapParser{}, nil},
+		{"names", sitemapParser{extensionNames: true}, []xml.Name{image}},
+		{"captured", sitemapParser{captureExtensions: true}, []xml.Name{image}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []xml.Name
+			parser := tc.parser
+			parser.onURL = func(entry xmlURLEntry) error {
+				if entry.Loc != "/photo" {
+					t.Fatalf("expected /photo, got %q", entry.Loc)
+				}
+				for _, ext := range entry.Extensions {
+					got = append(got, ext.XMLName)
+				}
+				return nil
+			}
+			if err := parser.parse(context.Background(), strings.NewReader(sitemap)); err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected extensions %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSitemapFetcher_WalkWithResult(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-pages.xml</loc></sitemap>
+  <sitemap><loc>/private/sitemap.xml</loc></sitemap>
+</sitemapindex>`
+	const pages = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <url><loc>/b</loc></url>
+  <url><loc>/tmp/c</loc></url>
+  <url><loc>/private/d</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /private/\nSitemap: /sitemap_index.xml\n"))
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/sitemap-pages.xml":
+			_, _ = w.Write([]byte(pages))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	websiteURL, _ := url.Parse(server.URL)
+	fetcher := New(Options{Exclude: []*regexp.Regexp{regexp.MustCompile(`/tmp/`)}})
+	result, err := fetcher.WalkWithResult(context.Background(), websiteURL, func(Item) error { return nil })
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if result.SitemapsFetched != 2 || result.URLsYielded != 2 || result.URLsFiltered != 1 {
+		t.Fatalf("unexpected counts: %+v", result)
+	}
+	if result.RobotsBlockedURLs != 1 || result.RobotsBlockedSitemaps != 1 {
+		t.Fatalf("unexpected robots counts: %+v", result)
+	}
+	if result.BytesRead != int64(len(index)+len(pages)) || result.Duration <= 0 {
+		t.Fatalf("unexpected bytes or duration: %+v", result)
+	}
+	if len(result.Sitemaps) != 2 || result.Sitemaps[1].URL.Path != "/sitemap-pages.xml" || result.Sitemaps[1].URLs != 2 {
+		t.Fatalf("unexpected per-sitemap breakdown: %+v", result.Sitemaps)
+	}
+
+	result, err = New(Options{MaxURLs: 1}).WalkWithResult(context.Background(), websiteURL, func(Item) error { return nil })
+	var maxErr *ErrMaxURLs
+	if !errors.As(err, &maxErr) || result == nil || result.URLsYielded != 1 {
+		t.Fatalf("expected partial result with ErrMaxURLs, got %+v (%v)", result, err)
+	}
+	if last := result.Sitemaps[len(result.Sitemaps)-1]; !errors.As(last.Err, &maxErr) {
+		t.Fatalf("expected last sitemap to carry the error, got %+v", last)
+	}
+}
+
+func TestSitemapFetcher_Redirects(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			http.Redirect(w, r, "/old/sitemap.xml", http.StatusMovedPermanently)
+		case "/old/sitemap.xml":
+			http.Redirect(w, r, "/new/sitemap.xml", http.StatusFound)
+		case "/new/sitemap.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>page</loc></url></urlset>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var items []Item
+	result, err := New(Options{IgnoreRobots: true}).WalkWithResult(context.Background(), mustParseURL(t, server.URL+"/sitemap.xml"), func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Loc.Path != "/new/page" {
+		t.Fatalf("expected the entry to resolve against the final URL, got %+v", items)
+	}
+	stats := result.Sitemaps[0]
+	if stats.FinalURL == nil || stats.FinalURL.Path != "/new/sitemap.xml" {
+		t.Fatalf("unexpected final URL: %v", stats.FinalURL)
+	}
+	if len(stats.Redirects) != 2 || stats.Redirects[0].Path != "/sitemap.xml" || stats.Redirects[1].Path != "/old/sitemap.xml" {
+		t.Fatalf("unexpected redirect chain: %v", stats.Redirects)
+	}
+}
+
+func TestSitemapFetcher_SkipDuplicateSitemaps(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <url><loc>/b</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\nSitemap: /sitemap.xml\nSitemap: /sitemap_index.xml\n"))
+		case "/sitemap.xml", "/sitemap_index.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	websiteURL, _ := url.Parse(server.URL)
+	items, err := collectItems(New(Options{}), websiteURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("expected both copies to be parsed by default, got %d items", len(items))
+	}
+
+	var count int
+	result, err := New(Options{SkipDuplicateSitemaps: true}).WalkWithResult(context.Background(), websiteURL, func(Item) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if count != 2 || result.SitemapAliases != 1 {
+		t.Fatalf("expected 2 items and 1 alias, got %d items, %+v", count, result)
+	}
+	alias := result.Sitemaps[1]
+	if alias.URL.Path != "/sitemap_index.xml" || alias.AliasOf == nil || alias.AliasOf.Path != "/sitemap.xml" || alias.URLs != 0 {
+		t.Fatalf("unexpected alias stats: %+v", alias)
+	}
+	if result.Sitemaps[0].AliasOf != nil {
+		t.Fatalf("first sitemap should not be an alias: %+v", result.Sitemaps[0])
+	}
+}
+
+func TestSitemapFetcher_ModifiedWindow(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/old.xml</loc><lastmod>2020-01-01</lastmod></sitemap>
+  <sitemap><loc>/new.xml</loc><lastmod>2024-06-01</lastmod></sitemap>
+</sitemapindex>`
+	const old = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/archive</loc><lastmod>2020-01-01</lastmod></url>
+</urlset>`
+	const recent = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/january</loc><lastmod>2024-01-15</lastmod></url>
+  <url><loc>/june</loc><lastmod>2024-06-01T10:00:00Z</lastmod></url>
+  <url><loc>/undated</loc></url>
+</urlset>`
+
+	var oldRequests atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/old.xml":
+			oldRequests.Add(1)
+			_, _ = w.Write([]byte(old))
+		case "/new.xml":
+			_, _ = w.Write([]byte(recent))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	indexURL, _ := url.Parse(server.URL + "/sitemap_index.xml")
+	cutoff := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	var paths []string
+	result, err := New(Options{ModifiedAfter: cutoff}).WalkWithResult(context.Background(), indexURL, func(item Item) error {
+		paths = append(paths, item.Loc.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if strings.Join(paths, ",") != "/june" {
+		t.Fatalf("unexpected items after cutoff: %v", paths)
+	}
+	if oldRequests.Load() != 0 || result.SitemapsSkipped != 1 || result.URLsFiltered != 2 {
+		t.Fatalf("expected the old child to be skipped, got %d requests, %+v", oldRequests.Load(), result)
+	}
+
+	items, err := collectItems(New(Options{ModifiedBefore: cutoff}), indexURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	paths = paths[:0]
+	for _, item := range items {
+		paths = append(paths, item.Loc.Path)
+	}
+	if strings.Join(paths, ",") != "/archive,/january" || oldRequests.Load() != 1 {
+		t.Fatalf("unexpected items before cutoff: %v (%d old requests)", paths, oldRequests.Load())
+	}
+}
+
+func TestSitemapFetcher_Filter(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc><priority>0.8</priority></url>
+  <url><loc>/a/b/c/d</loc><priority>0.9</priority></url>
+  <url><loc>/low</loc><priority>0.1</priority></url>
+  <url><loc>/b</loc><priority>0.5</priority></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+
+	fetcher := New(Options{
+		// Limits count yielded items only, so filtered entries do not use them up.
+		MaxURLs: 2,
+		Filter: func(item Item) bool {
+			return item.Priority != nil && *item.Priority >= 0.5 && strings.Count(item.Loc.Path, "/") <= 3
+		},
+	})
+	var paths []string
+	result, err := fetcher.WalkWithResult(context.Background(), sitemapURL, func(item Item) error {
+		paths = append(paths, item.Loc.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if strings.Join(paths, ",") != "/a,/b" || result.URLsFiltered != 2 {
+		t.Fatalf("unexpected items %v, result %+v", paths, result)
+	}
+}
+
+func TestSitemapFetcher_YieldSitemaps(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/posts.xml</loc><lastmod>2024-05-01</lastmod></sitemap>
+  <sitemap><loc>/pages.xml</loc></sitemap>
+</sitemapindex>`
+	const urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/posts.xml", "/pages.xml":
+			_, _ = w.Write([]byte(urlset))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	indexURL := mustParseURL(t, server.URL+"/sitemap_index.xml")
+
+	var refs []SitemapRef
+	fetcher := New(Options{YieldSitemaps: func(ref SitemapRef) error {
+		refs = append(refs, ref)
+		return nil
+	}})
+	items, err := collectItems(fetcher, indexURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 2 || len(refs) != 2 {
+		t.Fatalf("expected 2 items and 2 refs, got %d and %+v", len(items), refs)
+	}
+	if refs[0].Loc.Path != "/posts.xml" || refs[0].LastMod == nil || refs[0].Index.Path != "/sitemap_index.xml" || refs[0].Depth != 1 {
+		t.Fatalf("unexpected first ref: %+v", refs[0])
+	}
+	if refs[1].Loc.Path != "/pages.xml" || refs[1].LastMod != nil {
+		t.Fatalf("unexpected second ref: %+v", refs[1])
+	}
+
+	stop := errors.New("stop")
+	fetcher = New(Options{YieldSitemaps: func(SitemapRef) error { return stop }})
+	if _, err := collectItems(fetcher, indexURL); !errors.Is(err, stop) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+}
+
+func TestSitemapFetcher_SitemapTypes(t *testing.T) {
+	docs := map[string]string{
+		"/sitemap.xml": `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/pages.xml</loc></sitemap>
+  <sitemap><loc>/feed.xml</loc></sitemap>
+  <sitemap><loc>/urls.txt</loc></sitemap>
+  <sitemap><loc>/garbage.txt</loc></sitemap>
+</sitemapindex>`,
+		"/pages.xml":   `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/a</loc></url><url><loc>/b</loc></url></urlset>`,
+		"/feed.xml":    `<rss version="2.0"><channel><item><link>/a</link></item></channel></rss>`,
+		"/urls.txt":    "https://example.com/a\r\n\r\nhttps://example.com/b\n",
+		"/garbage.txt": "Service unavailable, please try again later\n",
+	}
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(docs[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	var streamed []SitemapStats
+	fetcher := New(Options{
+		IgnoreRobots: true,
+		Exclude:      []*regexp.Regexp{regexp.MustCompile("/b$")},
+		Hooks:        Hooks{OnSitemapStats: func(stats SitemapStats) { streamed = append(streamed, stats) }},
+	})
+	result, err := fetcher.WalkWithResult(context.Background(), mustParseURL(t, server.URL+"/sitemap.xml"), func(Item) error { return nil })
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if !reflect.DeepEqual(streamed, result.Sitemaps) {
+		t.Fatalf("expected OnSitemapStats to report the WalkResult records")
+	}
+	want := map[string]struct {
+		typ     SitemapType
+		entries int
+		urls    int
+	}{
+		"/sitemap.xml": {SitemapTypeIndex, 4, 0},
+		"/pages.xml":   {SitemapTypeURLSet, 2, 1},
+		"/feed.xml":    {SitemapTypeFeed, 0, 0},
+		"/urls.txt":    {SitemapTypeText, 0, 0},
+		"/garbage.txt": {SitemapTypeUnknown, 0, 0},
+	}
+	if len(result.Sitemaps) != len(want) {
+		t.Fatalf("expected %d sitemaps, got %d", len(want), len(result.Sitemaps))
+	}
+	for _, stats := range result.Sitemaps {
+		w := want[stats.URL.Path]
+		if stats.Type != w.typ || stats.Entries != w.entries || stats.URLs != w.urls || stats.Bytes != int64(len(docs[stats.URL.Path])) {
+			t.Fatalf("%s: unexpected stats %+v", stats.URL.Path, stats)
+		}
+	}
+}
+
+func TestSitemapFetcher_ListSitemaps(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/posts.xml</loc><lastmod>2024-05-01</lastmod></sitemap>
+  <sitemap><loc>/pages.xml</loc></sitemap>
+</sitemapindex>`
+	const urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/a</loc></url><url><loc>/b</loc></url></urlset>`
+	large := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + strings.Repeat(`<url><loc>/page</loc></url>`, 10000) + `</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/posts.xml":
+			_, _ = w.Write([]byte(urlset))
+		case "/pages.xml":
+			_, _ = w.Write([]byte(large))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	indexURL := mustParseURL(t, server.URL+"/sitemap_index.xml")
+	list, err := New(Options{}).ListSitemaps(context.Background(), indexURL)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("expected 3 sitemaps, got %+v", list)
+	}
+	if list[0].Type != SitemapTypeIndex || list[0].Depth != 0 || list[0].Sitemaps != 2 || list[0].URLs != 0 || !list[0].Counted {
+		t.Fatalf("unexpected index entry: %+v", list[0])
+	}
+	posts := list[1]
+	if posts.URL.Path != "/posts.xml" || posts.Type != SitemapTypeURLSet || posts.Depth != 1 || posts.URLs != 0 || posts.Counted ||
+		posts.LastMod == nil || posts.Via != ViaIndex || posts.Ref.Path != "/sitemap_index.xml" {
+		t.Fatalf("unexpected child entry: %+v", posts)
+	}
+	pages := list[2]
+	if pages.URL.Path != "/pages.xml" || pages.LastMod != nil || pages.Type != SitemapTypeURLSet || pages.Bytes >= int64(len(large)/2) {
+		t.Fatalf("expected the large urlset to be skimmed, got %+v", pages)
+	}
+
+	list, err = New(Options{CountSitemapURLs: true, MaxURLs: 1}).ListSitemaps(context.Background(), indexURL)
+	if err != nil {
+		t.Fatalf("counting list failed: %v", err)
+	}
+	if len(list) != 3 || list[1].URLs != 2 || !list[1].Counted || list[2].URLs != 10000 || list[2].Bytes != int64(len(large)) {
+		t.Fatalf("expected CountSitemapURLs to count every urlset, got %+v", list)
+	}
+}
+
+func TestSitemapFetcher_MaxSitemapBytes(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?><urlset>` +
+		strings.Repeat(`<url><loc>/page</loc></url>`, 1000) + `</urlset>`
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte(sitemap))
+	_ = gzipWriter.Close()
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(gzipped.Bytes())
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml.gz")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	_, err = collectItems(New(Options{IgnoreRobots: true, MaxSitemapBytes: 1024}), sitemapURL)
+	var tooLarge *ErrSitemapTooLarge
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected ErrSitemapTooLarge, got %v", err)
+	}
+	if tooLarge.Limit != 1024 {
+		t.Fatalf("expected limit 1024, got %d", tooLarge.Limit)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, MaxSitemapBytes: int64(len(sitemap))}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk at exact limit failed: %v", err)
+	}
+	if len(items) != 1000 {
+		t.Fatalf("expected 1000 items, got %d", len(items))
+	}
+}
+
+func TestSitemapFetcher_Provenance(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/child.xml</loc><lastmod>2024-03-04</lastmod></sitemap>
+</sitemapindex>`
+	const child = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/page</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\nSitemap: /index.xml\n"))
+		case "/index.xml":
+			_, _ = w.Write([]byte(index))
+		case "/child.xml":
+			_, _ = w.Write([]byte(child))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse base URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{}), baseURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	chain := items[0].Provenance
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 hops, got %d", len(chain))
+	}
+	if chain[0].Via != ViaRobots || !strings.HasSuffix(chain[0].Ref.String(), "/robots.txt") || chain[0].Depth != 0 {
+		t.Fatalf("unexpected root hop: %+v", chain[0])
+	}
+	if chain[1].Via != ViaIndex || !strings.HasSuffix(chain[1].Ref.String(), "/index.xml") || chain[1].Depth != 1 {
+		t.Fatalf("unexpected child hop: %+v", chain[1])
+	}
+	if chain[1].LastMod == nil || chain[1].LastMod.Format("2006-01-02") != "2024-03-04" {
+		t.Fatalf("expected index lastmod on child hop, got %v", chain[1].LastMod)
+	}
+}
+
+func TestSitemapFetcher_SitemapLastMod(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/pages.xml</loc><lastmod>2024-05-01</lastmod></sitemap>
+</sitemapindex>`))
+		case "/pages.xml":
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		}
+	}))
+	defer server.Close()
+
+	var items []Item
+	result, err := New(Options{IgnoreRobots: true}).WalkWithResult(context.Background(), mustParseURL(t, server.URL+"/sitemap.xml"), func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil || len(items) != 1 || len(result.Sitemaps) != 2 {
+		t.Fatalf("walk failed: %d items, %d sitemaps (%v)", len(items), len(result.Sitemaps), err)
+	}
+	want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if items[0].SitemapLastMod == nil || !items[0].SitemapLastMod.Equal(want) {
+		t.Fatalf("expected the index lastmod on the item, got %v", items[0].SitemapLastMod)
+	}
+	if result.Sitemaps[0].LastMod != nil || result.Sitemaps[1].LastMod == nil || !result.Sitemaps[1].LastMod.Equal(want) {
+		t.Fatalf("expected the index lastmod on the child's stats only, got %v and %v", result.Sitemaps[0].LastMod, result.Sitemaps[1].LastMod)
+	}
+}
+
+func TestSitemapFetcher_CrossHostPolicy(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/local</loc></url>
+  <url><loc>https://foreign.example/page</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected 2 items with default policy, got %d (%v)", len(items), err)
+	}
+
+	items, err = collectItems(New(Options{IgnoreRobots: true, CrossHostPolicy: CrossHostSkip}), sitemapURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected 1 item with skip policy, got %d (%v)", len(items), err)
+	}
+
+	_, err = collectItems(New(Options{IgnoreRobots: true, CrossHostPolicy: CrossHostError}), sitemapURL)
+	var crossErr *ErrCrossHost
+	if !errors.As(err, &crossErr) {
+		t.Fatalf("expected ErrCrossHost, got %v", err)
+	}
+	if crossErr.URL.Host != "foreign.example" {
+		t.Fatalf("unexpected cross-host URL %s", crossErr.URL)
+	}
+
+	// After a redirect to another host, entries are compared with the host
+	// that served the sitemap.
+	cdn := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer cdn.Close()
+	origin := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, cdn.URL+"/sitemap.xml", http.StatusMovedPermanently)
+	}))
+	defer origin.Close()
+	items, err = collectItems(New(Options{IgnoreRobots: true, CrossHostPolicy: CrossHostSkip}), mustParseURL(t, origin.URL+"/sitemap.xml"))
+	if err != nil || len(items) != 1 || items[0].Loc.String() != cdn.URL+"/local" {
+		t.Fatalf("expected the redirected sitemap's own entry to be kept, got %v (%v)", items, err)
+	}
+}
+
+func TestSitemapFetcher_MaxElementBytes(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/small</loc></url>
+  <url><loc>/huge</loc><changefreq>` + strings.Repeat("x", 64*1024) + `</changefreq></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, MaxElementBytes: 4096}), sitemapURL)
+	var elementErr *ErrElementTooLarge
+	if !errors.As(err, &elementErr) {
+		t.Fatalf("expected ErrElementTooLarge, got %v", err)
+	}
+	if elementErr.URL == nil || elementErr.Limit != 4096 {
+		t.Fatalf("unexpected error details: %+v", elementErr)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected entries before the oversized element, got %d", len(items))
+	}
+
+	items, err = collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected default limit to allow 64KB element, got %d (%v)", len(items), err)
+	}
+}
+
+func TestSitemapFetcher_DecoderLimits(t *testing.T) {
+	deep := `<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>/ok</loc></url>` +
+		`<url><loc>/deep</loc>` + strings.Repeat("<x>", 50) + strings.Repeat("</x>", 50) + `</url></urlset>`
+	long := `<?xml version="1.0" encoding="UTF-8"?><urlset><url><loc>/` + strings.Repeat("a", 2048) + `</loc></url></urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deep.xml":
+			_, _ = w.Write([]byte(deep))
+		case "/long.xml":
+			_, _ = w.Write([]byte(long))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	deepURL, _ := url.Parse(server.URL + "/deep.xml")
+	longURL, _ := url.Parse(server.URL + "/long.xml")
+	fetcher := New(Options{IgnoreRobots: true, Decoder: DecoderOptions{MaxNesting: 10, MaxTokenBytes: 1024}})
+
+	items, err := collectItems(fetcher, deepURL)
+	var limitErr *ErrXMLLimit
+	if !errors.As(err, &limitErr) || limitErr.Limit != XMLLimitNesting {
+		t.Fatalf("expected nesting limit error, got %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item before the deep element, got %d", len(items))
+	}
+
+	_, err = collectItems(fetcher, longURL)
+	if !errors.As(err, &limitErr) || limitErr.Limit != XMLLimitTokenBytes {
+		t.Fatalf("expected token size limit error, got %v", err)
+	}
+
+	items, err = collectItems(New(Options{IgnoreRobots: true}), deepURL)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected the default limits to allow 50 levels, got %d (%v)", len(items), err)
+	}
+
+	items, err = collectItems(New(Options{IgnoreRobots: true, Decoder: DecoderOptions{MaxNesting: -1, MaxTokenBytes: -1}}), longURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected negative limits to disable them, got %d (%v)", len(items), err)
+	}
+}
+
+func TestSitemapFetcher_LenientXML(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<urlset><url><loc>/a?x=1&y=2</loc></url><url><loc>/b\x01c</loc></url><url><loc>/trun"))
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	var parseErr *ErrSitemapParse
+	if _, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL); !errors.As(err, &parseErr) {
+		t.Fatalf("expected malformed XML to fail by default, got %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, LenientXML: true}), sitemapURL)
+	if err != nil {
+		t.Fatalf("expected lenient parsing to succeed, got %v", err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Loc.RequestURI())
+	}
+	if !reflect.DeepEqual(got, []string{"/a?x=1&y=2", "/bc"}) {
+		t.Fatalf("unexpected items: %v", got)
+	}
+}
+
+func TestSitemapFetcher_HostileXML(t *testing.T) {
+	var entityRequests atomic.Int32
+	var server *httptest.Server
+	server = newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/laughs.xml":
+			doc := `<?xml version="1.0"?><!DOCTYPE urlset [<!ENTITY lol "lol">`
+			for i := 1; i <= 9; i++ {
+				doc += fmt.Sprintf(`<!ENTITY lol%d "%s">`, i, strings.Repeat(fmt.Sprintf("&lol%d;", i-1), 10))
+			}
+			doc += `]><urlset><url><loc>/&lol9;</loc></url></urlset>`
+			_, _ = w.Write([]byte(doc))
+		case "/xxe.xml":
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><!DOCTYPE urlset [<!ENTITY xxe SYSTEM "` + server.URL + `/secret">]>` +
+				`<urlset><url><loc>/&xxe;</loc></url></urlset>`))
+		case "/nested.xml":
+			_, _ = w.Write([]byte(`<urlset>` + strings.Repeat("<x>", 10000)))
+		case "/comment.xml":
+			_, _ = w.Write([]byte(`<urlset><!--` + strings.Repeat("a", 2<<20) + `--></urlset>`))
+		case "/secret":
+			entityRequests.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/laughs.xml", "/xxe.xml"} {
+		items, err := collectItems(New(Options{IgnoreRobots: true}), mustParseURL(t, server.URL+path))
+		if err != nil || len(items) != 1 || len(items[0].Loc.Path) > 16 {
+			t.Fatalf("%s: expected the entity to stay unexpanded, got %+v (%v)", path, items, err)
+		}
+	}
+	if entityRequests.Load() != 0 {
+		t.Fatalf("expected external entities not to be fetched")
+	}
+	for path, limit := range map[string]string{"/nested.xml": XMLLimitNesting, "/comment.xml": XMLLimitTokenBytes} {
+		_, err := collectItems(New(Options{IgnoreRobots: true}), mustParseURL(t, server.URL+path))
+		var limitErr *ErrXMLLimit
+		if !errors.As(err, &limitErr) || limitErr.Limit != limit {
+			t.Fatalf("%s: expected the default %s limit, got %v", path, limit, err)
+		}
+	}
+}
+
+func TestSitemapFetcher_LastModLocation(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/east</loc><lastmod>2024-01-02T10:00:00+03:00</lastmod></url>
+  <url><loc>/date</loc><lastmod>2024-01-02</lastmod></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, LastModLocation: time.UTC}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if got := items[0].LastMod.Format(time.RFC3339); got != "2024-01-02T07:00:00Z" {
+		t.Fatalf("expected lastmod normalized to UTC, got %s", got)
+	}
+	if items[0].LastModOffset == nil || *items[0].LastModOffset != 3*60*60 {
+		t.Fatalf("expected original offset +03:00, got %v", items[0].LastModOffset)
+	}
+	if items[1].LastModOffset != nil {
+		t.Fatalf("expected no offset for date-only lastmod, got %d", *items[1].LastModOffset)
+	}
+}
+
+func TestSitemapFetcher_LastModFormats(t *testing.T) {
+	f := New(Options{ExtraTimeLayouts: []string{"02/01/2006"}})
+	for _, tc := range []struct {
+		value  string
+		want   string
+		offset *int
+	}{
+		{"2024-01-02T15:04:05+03:00", "2024-01-02T15:04:05+03:00", intPtr(3 * 3600)},
+		{"2024-01-02T15:04+03:00", "2024-01-02T15:04:00+03:00", intPtr(3 * 3600)},
+		{"2024-01", "2024-01-01T00:00:00Z", nil},
+		{"2024", "2024-01-01T00:00:00Z", nil},
+		{"2024-01-02 15:04:05", "2024-01-02T15:04:05Z", nil},
+		{"2024-01-02 15:04:05+01:00", "2024-01-02T15:04:05+01:00", intPtr(3600)},
+		{"2024-01-02T15:04:05+0100", "2024-01-02T15:04:05+01:00", intPtr(3600)},
+		{"2024-01-02T15:04:05.5-05", "2024-01-02T15:04:05.5-05:00", intPtr(-5 * 3600)},
+		{"1704207845", "2024-01-02T15:04:05Z", nil},
+		{"123456789", "", nil},
+		{"9999999999", "", nil},
+		{"02/01/2024", "2024-01-02T00:00:00Z", nil},
+		{"yesterday", "", nil},
+	} {
+		lastMod, offset := f.lastMod(tc.value)
+		got := ""
+		if lastMod != nil {
+			got = lastMod.Format(time.RFC3339Nano)
+		}
+		if got != tc.want || !reflect.DeepEqual(offset, tc.offset) {
+			t.Fatalf("%q: expected %q (offset %v), got %q (offset %v)", tc.value, tc.want, tc.offset, got, offset)
+		}
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,198 @@
+// Package sitemaptest provides helpers for testing code built on
+// gositemapfetcher: an in-memory sitemap Server and a scripted Walker fake.
+package sitemaptest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Entry is a <url> entry of a URLSet or a <sitemap> entry of an Index. Loc
+// values starting with "/" are resolved against the server URL; the other
+// fields are written as given and omitted when empty.
+type Entry struct {
+	Loc        string
+	LastMod    string
+	ChangeFreq string // URLSet only
+	Priority   string // URLSet only
+}
+
+// Server is an HTTP test server serving sitemaps, sitemap indexes, and
+// robots.txt registered on it. Paths ending in ".gz" are served
+// gzip-compressed; unregistered paths, including robots.txt until Robots is
+// called, answer 404. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	files    map[string]*file
+	requests []string
+}
+
+type file struct {
+	contentType string
+	body        []byte
+	status      int
+	limited     int // 429 answers left before body is served
+	retryAfter  time.Duration
+}
+
+// NewServer starts a Server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{files: map[string]*file{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// URLSet serves a <urlset> sitemap listing entries at path.
+func (s *Server) URLSet(path string, entries ...Entry) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, entry := range entries {
+		buf.WriteString("  <url>")
+		s.writeElement(&buf, "loc", s.resolve(entry.Loc))
+		s.writeElement(&buf, "lastmod", entry.LastMod)
+		s.writeElement(&buf, "changefreq", entry.ChangeFreq)
+		s.writeElement(&buf, "priority", entry.Priority)
+		buf.WriteString("</url>\n")
+	}
+	buf.WriteString("</urlset>\n")
+	s.File(path, "application/xml", buf.String())
+}
+
+// Index serves a <sitemapindex> listing the child sitemaps at path.
+func (s *Server) Index(path string, children ...Entry) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header + `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	for _, child := range children {
+		buf.WriteString("  <sitemap>")
+		s.writeElement(&buf, "loc", s.resolve(child.Loc))
+		s.writeElement(&buf, "lastmod", child.LastMod)
+		buf.WriteString("</sitemap>\n")
+	}
+	buf.WriteString("</sitemapindex>\n")
+	s.File(path, "application/xml", buf.String())
+}
+
+// Robots serves lines, joined with newlines, as /robots.txt, e.g.
+// "User-agent: *", "Disallow: /private/", "Sitemap: /sitemap.xml".
+// Relative Sitemap directives are resolved against the server URL.
+func (s *Server) Robots(lines ...string) {
+	resolved := make([]string, len(lines))
+	for i, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "sitemap") {
+			line = name + ": " + s.resolve(strings.TrimSpace(value))
+		}
+		resolved[i] = line
+	}
+	s.File("/robots.txt", "text/plain; charset=utf-8", strings.Join(resolved, "\n")+"\n")
+}
+
+// File serves body at path with the given Content-Type, replacing anything
+// registered there before.
+func (s *Server) File(path, contentType, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = &file{contentType: contentType, body: []byte(body), status: http.StatusOK}
+}
+
+// Status makes path answer with the given HTTP status and an empty body.
+func (s *Server) Status(path string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = &file{status: code}
+}
+
+// RateLimit makes the next times requests for path answer 429 Too Many
+// Requests, with a Retry-After header when retryAfter is positive, before
+// its registered response is served again.
+func (s *Server) RateLimit(path string, times int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[path]
+	if !ok {
+		f = &file{status: http.StatusNotFound}
+		s.files[path] = f
+	}
+	f.limited = times
+	f.retryAfter = retryAfter
+}
+
+// Requests returns the paths requested so far, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	f, ok := s.files[r.URL.Path]
+	limited := ok && f.limited > 0
+	if limited {
+		f.limited--
+	}
+	var served file
+	if ok {
+		served = *f
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !ok:
+		http.NotFound(w, r)
+	case limited:
+		if served.retryAfter > 0 {
+			seconds := int((served.retryAfter + time.Second - 1) / time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	case served.status != http.StatusOK:
+		w.WriteHeader(served.status)
+	default:
+		body := served.body
+		if strings.HasSuffix(r.URL.Path, ".gz") {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write(body)
+			_ = zw.Close()
+			body = buf.Bytes()
+			w.Header().Set("Content-Type", "application/gzip")
+		} else {
+			w.Header().Set("Content-Type", served.contentType)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method != http.MethodHead {
+			_, _ = w.Write(body)
+		}
+	}
+}
+
+// resolve turns a root-relative path into an absolute URL on the server.
+func (s *Server) resolve(loc string) string {
+	if strings.HasPrefix(loc, "/") && !strings.HasPrefix(loc, "//") {
+		return s.URL + loc
+	}
+	return loc
+}
+
+func (s *Server) writeElement(buf *bytes.Buffer, name, value string) {
+	if value == "" {
+		return
+	}
+	buf.WriteString("<" + name + ">")
+	_ = xml.EscapeText(buf, []byte(value))
+	buf.WriteString("</" + name + ">")
+}
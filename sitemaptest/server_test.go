@@ -0,0 +1,48 @@
+package sitemaptest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func TestServer_Walk(t *testing.T) {
+	server := NewServer(t)
+	server.Robots("User-agent: *", "Disallow: /private/", "Sitemap: /index.xml")
+	server.Index("/index.xml", Entry{Loc: "/pages.xml.gz", LastMod: "2024-05-01"}, Entry{Loc: "/missing.xml"})
+	server.URLSet("/pages.xml.gz",
+		Entry{Loc: "/one?a=1&b=2", Priority: "0.8"},
+		Entry{Loc: "/private/two"},
+	)
+	server.Status("/missing.xml", http.StatusGone)
+	server.RateLimit("/pages.xml.gz", 1, 0)
+
+	site, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	fetcher := gositemapfetcher.New(gositemapfetcher.Options{
+		AllowNon200: true,
+		Retry:       gositemapfetcher.RetryPolicy{BaseDelay: time.Millisecond},
+	})
+	var locs []string
+	err = fetcher.Walk(context.Background(), site, func(item gositemapfetcher.Item) error {
+		locs = append(locs, item.Loc.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if want := []string{server.URL + "/one?a=1&b=2"}; !reflect.DeepEqual(locs, want) {
+		t.Fatalf("expected %v, got %v", want, locs)
+	}
+	want := []string{"/robots.txt", "/index.xml", "/pages.xml.gz", "/pages.xml.gz", "/missing.xml"}
+	if got := server.Requests(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected requests %v, got %v", want, got)
+	}
+}
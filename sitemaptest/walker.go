@@ -0,0 +1,73 @@
+package sitemaptest
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+var _ gositemapfetcher.SitemapWalker = (*Walker)(nil)
+
+// Walker is a scripted gositemapfetcher.SitemapWalker. Each Walk call yields
+// Items in order and then returns Err, or returns the first error from yield
+// or the context. It is safe for concurrent use once set up.
+type Walker struct {
+	Items []gositemapfetcher.Item
+	Err   error
+	// Sites, when it has an entry for the walked URL's string form, replaces
+	// Items and Err for that URL.
+	Sites map[string]Script
+
+	mu    sync.Mutex
+	calls []*url.URL
+}
+
+// Script is the scripted outcome of walking one site, see Walker.Sites.
+type Script struct {
+	Items []gositemapfetcher.Item
+	Err   error
+}
+
+// Walk implements gositemapfetcher.SitemapWalker.
+func (w *Walker) Walk(ctx context.Context, website *url.URL, yield func(gositemapfetcher.Item) error) error {
+	w.mu.Lock()
+	w.calls = append(w.calls, website)
+	w.mu.Unlock()
+
+	script := Script{Items: w.Items, Err: w.Err}
+	if site, ok := w.Sites[website.String()]; ok {
+		script = site
+	}
+	for _, item := range script.Items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := yield(item); err != nil {
+			return err
+		}
+	}
+	return script.Err
+}
+
+// Calls returns the URLs passed to Walk so far, in order.
+func (w *Walker) Calls() []*url.URL {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]*url.URL(nil), w.calls...)
+}
+
+// Items returns items for the given locations, e.g. to script a Walker.
+// It panics on a location that is not a valid URL.
+func Items(locs ...string) []gositemapfetcher.Item {
+	items := make([]gositemapfetcher.Item, len(locs))
+	for i, loc := range locs {
+		parsed, err := url.Parse(loc)
+		if err != nil {
+			panic("sitemaptest: invalid location " + loc + ": " + err.Error())
+		}
+		items[i] = gositemapfetcher.Item{Loc: parsed}
+	}
+	return items
+}
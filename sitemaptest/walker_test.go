@@ -0,0 +1,46 @@
+package sitemaptest
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	gositemapfetcher "github.com/enot-style/go-sitemap-fetcher"
+)
+
+func TestWalker_Script(t *testing.T) {
+	errBroken := errors.New("broken")
+	walker := &Walker{
+		Items: Items("https://a.example/1", "https://a.example/2"),
+		Sites: map[string]Script{"https://b.example": {Err: errBroken}},
+	}
+
+	var walked int
+	err := walker.Walk(context.Background(), &url.URL{Scheme: "https", Host: "a.example"}, func(gositemapfetcher.Item) error {
+		walked++
+		return nil
+	})
+	if err != nil || walked != 2 {
+		t.Fatalf("expected 2 scripted items, got %d (%v)", walked, err)
+	}
+
+	errStop := errors.New("stop")
+	err = walker.Walk(context.Background(), &url.URL{Scheme: "https", Host: "a.example"}, func(gositemapfetcher.Item) error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected the yield error, got %v", err)
+	}
+
+	err = walker.Walk(context.Background(), &url.URL{Scheme: "https", Host: "b.example"}, func(gositemapfetcher.Item) error {
+		t.Fatalf("expected no items for b.example")
+		return nil
+	})
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("expected the scripted error, got %v", err)
+	}
+	if calls := walker.Calls(); len(calls) != 3 || calls[2].Host != "b.example" {
+		t.Fatalf("expected 3 recorded calls, got %v", calls)
+	}
+}
@@ -0,0 +1,261 @@
+package gositemapfetcher
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SitemapSource opens sitemap documents by URL. When Options.SitemapSource is
+// nil, sitemaps are fetched over HTTP.
+type SitemapSource interface {
+	// Open returns the raw (possibly gzip-compressed) sitemap body. A missing
+	// document should be reported as *ErrHTTPStatus with StatusCode 404.
+	Open(ctx context.Context, loc *url.URL) (io.ReadCloser, error)
+}
+
+// openSitemap opens a sitemap through the configured source, applying the
+// same missing/non-200 rules as HTTP fetching.
+func (f *SitemapFetcher) openSitemap(ctx context.Context, loc *url.URL, allowMissing bool) (io.ReadCloser, error) {
+	if f.opts.SitemapSource == nil && !isFileURL(loc) {
+		acceptEncoding := "gzip"
+		if f.opts.DisableCompression {
+			acceptEncoding = "identity"
+		}
+		return f.fetchSitemap(ctx, loc, allowMissing, acceptEncoding)
+	}
+	budget := byteBudgetFrom(ctx)
+	if budget != nil && budget.exhausted() {
+		return nil, budget.err(loc)
+	}
+	var body io.ReadCloser
+	var err error
+	if f.opts.SitemapSource == nil {
+		body, err = os.Open(filepath.FromSlash(loc.Path))
+	} else {
+		body, err = f.opts.SitemapSource.Open(ctx, loc)
+	}
+	if err != nil {
+		var statusErr *ErrHTTPStatus
+		if errors.As(err, &statusErr) {
+			if f.opts.AllowNon200 {
+				f.debug(ctx, "non-200 status", urlAttr("url", loc), slog.Int("status", statusErr.StatusCode))
+				return nil, nil
+			}
+			if allowMissing && statusErr.StatusCode == http.StatusNotFound {
+				f.debug(ctx, "sitemap not found (probe)", urlAttr("url", loc))
+				return nil, nil
+			}
+		}
+		return nil, err
+	}
+	if budget != nil {
+		body = &budgetReader{ReadCloser: body, budget: budget, loc: loc}
+	}
+	reader, err := wrapReader(body, loc, "", nil)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// openWithMirrors opens loc and, when loc is on the walked host and fails at
+// the network level, tries the same path on each of Options.Mirrors in order.
+// It returns the URL that actually served the sitemap.
+func (f *SitemapFetcher) openWithMirrors(ctx context.Context, loc *url.URL, allowMissing bool, primaryHost string) (io.ReadCloser, *url.URL, error) {
+	reader, err := f.openSitemap(ctx, loc, allowMissing)
+	if err == nil || len(f.opts.Mirrors) == 0 || !strings.EqualFold(loc.Host, primaryHost) || !isNetworkError(ctx, err) {
+		return reader, loc, err
+	}
+	for _, mirror := range f.opts.Mirrors {
+		candidate := mirrorURL(mirror, loc)
+		f.debug(ctx, "fetch failed, trying mirror", urlAttr("url", loc), urlAttr("mirror", candidate), slog.Any("error", err))
+		mirrorReader, mirrorErr := f.openSitemap(ctx, candidate, allowMissing)
+		if mirrorErr == nil {
+			return mirrorReader, candidate, nil
+		}
+		if ctx.Err() != nil {
+			return nil, loc, ctx.Err()
+		}
+		f.debug(ctx, "mirror failed", urlAttr("mirror", candidate), slog.Any("error", mirrorErr))
+	}
+	return nil, loc, err
+}
+
+// mirrorURL maps loc onto a mirror base URL, keeping its path and query.
+func mirrorURL(mirror, loc *url.URL) *url.URL {
+	mapped := *loc
+	mapped.Scheme = mirror.Scheme
+	mapped.Host = mirror.Host
+	mapped.User = mirror.User
+	if prefix := strings.TrimSuffix(mirror.Path, "/"); prefix != "" {
+		mapped.Path = prefix + loc.Path
+		mapped.RawPath = ""
+	}
+	return &mapped
+}
+
+// isNetworkError reports whether err came from the transport rather than
+// from an HTTP status or the sitemap body.
+func isNetworkError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// ParseFile walks a sitemap or sitemap index stored on the local filesystem,
+// e.g. one generated in CI before deployment. Relative entries in an index
+// resolve to sibling files; absolute http(s) entries are fetched as usual.
+func (f *SitemapFetcher) ParseFile(ctx context.Context, path string, yield func(Item) error) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return &ErrInvalidURL{URL: path, Err: err}
+	}
+	return f.Walk(ctx, FileURL(abs), yield)
+}
+
+// FileURL returns the file:// URL for an absolute filesystem path.
+func FileURL(path string) *url.URL {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return &url.URL{Scheme: "file", Path: path}
+}
+
+func isFileURL(u *url.URL) bool {
+	return u != nil && strings.EqualFold(u.Scheme, "file")
+}
+
+// ArchiveSource is a SitemapSource replaying sitemap bodies recorded by
+// TarArchive, so a walk can be re-run offline.
+type ArchiveSource struct {
+	bodies map[string]archivedBody
+}
+
+type archivedBody struct {
+	data     []byte // gzip-compressed by TarArchive
+	encoding string // Content-Encoding the body was served with
+}
+
+// OpenTarArchive loads an archive written by TarArchive. Bodies are kept
+// compressed in memory and decompressed on demand.
+func OpenTarArchive(r io.Reader) (*ArchiveSource, error) {
+	reader := tar.NewReader(r)
+	files := map[string][]byte{}
+	var metas []ArchiveMeta
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(header.Name, ".meta.json") {
+			var meta ArchiveMeta
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return nil, fmt.Errorf("invalid archive metadata %s: %w", header.Name, err)
+			}
+			metas = append(metas, meta)
+			continue
+		}
+		files[header.Name] = data
+	}
+
+	source := &ArchiveSource{bodies: make(map[string]archivedBody, len(metas))}
+	for _, meta := range metas {
+		body, ok := files[meta.Body]
+		if !ok {
+			return nil, fmt.Errorf("archive entry %s missing for %s", meta.Body, meta.URL)
+		}
+		loc, err := url.Parse(meta.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid archived URL %q: %w", meta.URL, err)
+		}
+		// Later captures of the same URL win.
+		source.bodies[canonicalURLKey(loc)] = archivedBody{data: body, encoding: meta.Header.Get("Content-Encoding")}
+	}
+	return source, nil
+}
+
+// Open implements SitemapSource.
+func (s *ArchiveSource) Open(_ context.Context, loc *url.URL) (io.ReadCloser, error) {
+	body, ok := s.bodies[canonicalURLKey(loc)]
+	if !ok {
+		return nil, &ErrHTTPStatus{URL: loc, StatusCode: http.StatusNotFound, Status: http.StatusText(http.StatusNotFound)}
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body.data))
+	if err != nil {
+		return nil, err
+	}
+	// The archived bytes are as received, so a codec applied by the
+	// Content-Encoding header alone is applied here.
+	codec := headerCodec(body.encoding)
+	if codec == nil {
+		return reader, nil
+	}
+	decoded, err := codec.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &multiCloser{reader: decoded, closers: []io.Closer{decoded, reader}}, nil
+}
+
+// SourceFunc adapts a function to SitemapSource, e.g. a thin wrapper around
+// an object storage client.
+type SourceFunc func(ctx context.Context, loc *url.URL) (io.ReadCloser, error)
+
+// Open implements SitemapSource.
+func (fn SourceFunc) Open(ctx context.Context, loc *url.URL) (io.ReadCloser, error) {
+	return fn(ctx, loc)
+}
+
+// DirSource is a SitemapSource reading sitemaps mirrored into a directory
+// laid out as <root>/<host>/<path>, e.g. a bucket synced to local disk.
+type DirSource struct {
+	root string
+}
+
+// NewDirSource returns a DirSource serving files below root.
+func NewDirSource(root string) *DirSource {
+	return &DirSource{root: root}
+}
+
+// Open implements SitemapSource. Missing files are reported as HTTP 404.
+func (s *DirSource) Open(_ context.Context, loc *url.URL) (io.ReadCloser, error) {
+	notFound := &ErrHTTPStatus{URL: loc, StatusCode: http.StatusNotFound, Status: http.StatusText(http.StatusNotFound)}
+	host := loc.Host
+	if host == "" || host == "." || host == ".." || strings.ContainsAny(host, `/\`) {
+		return nil, notFound
+	}
+	// Cleaning the rooted path keeps ".." segments inside root.
+	name := filepath.Join(s.root, host, filepath.FromSlash(path.Clean("/"+loc.Path)))
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,71 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSitemapFetcher_DirSource(t *testing.T) {
+	root := t.TempDir()
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemaps/pages.xml</loc></sitemap>
+  <sitemap><loc>/sitemaps/missing.xml</loc></sitemap>
+</sitemapindex>`
+	const pages = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+</urlset>`
+
+	if err := os.MkdirAll(filepath.Join(root, "example.com", "sitemaps"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "example.com", "sitemap.xml"), []byte(index), 0o644); err != nil {
+		t.Fatalf("write index failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "example.com", "sitemaps", "pages.xml"), []byte(pages), 0o644); err != nil {
+		t.Fatalf("write sitemap failed: %v", err)
+	}
+
+	sitemapURL, _ := url.Parse("https://example.com/sitemap.xml")
+	source := NewDirSource(root)
+
+	_, err := collectItems(New(Options{IgnoreRobots: true, SitemapSource: source}), sitemapURL)
+	var statusErr *ErrHTTPStatus
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for the missing child, got %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true, AllowNon200: true, SitemapSource: source}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Loc.String() != "https://example.com/a" {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	escape, _ := url.Parse("https://example.com/../../etc/passwd")
+	if _, err := source.Open(context.Background(), escape); !errors.As(err, &statusErr) {
+		t.Fatalf("expected path outside root to be not found, got %v", err)
+	}
+}
+
+func TestSitemapFetcher_SourceSkipsHTMLDiscovery(t *testing.T) {
+	var requests []string
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		_, _ = w.Write([]byte(`<html><head><link rel="sitemap" href="/found.xml"></head></html>`))
+	}))
+	defer server.Close()
+
+	fetcher := New(Options{IgnoreRobots: true, DiscoverFromHTML: true, SitemapSource: NewDirSource(t.TempDir())})
+	_, _ = collectItems(fetcher, mustParseURL(t, server.URL))
+	if len(requests) != 0 {
+		t.Fatalf("expected no live requests with a SitemapSource, got %v", requests)
+	}
+}
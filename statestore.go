@@ -0,0 +1,89 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// StateStore remembers the child urlsets of earlier walks, keyed by
+// sitemap URL, so Options.StateStore can skip the ones whose index lastmod
+// has not advanced since. Implementations must be safe for concurrent use
+// when the fetcher walks from several goroutines.
+type StateStore interface {
+	// Get returns the stored state for key, or nil when nothing is stored.
+	Get(ctx context.Context, key string) (*SitemapState, error)
+	// Put stores state for key.
+	Put(ctx context.Context, key string, state SitemapState) error
+}
+
+// SitemapState is what a walk recorded about a child urlset it read
+// completely.
+type SitemapState struct {
+	// LastMod is the lastmod the parent index gave the sitemap.
+	LastMod time.Time
+	// ETag is the ETag the sitemap was served with, empty when there was
+	// none.
+	ETag string
+	// WalkedAt is when the sitemap was read.
+	WalkedAt time.Time
+}
+
+// MemoryStateStore is a concurrency-safe in-memory StateStore, e.g. for a
+// long-running service walking the same sites daily.
+type MemoryStateStore struct {
+	mu      sync.Mutex
+	entries map[string]SitemapState
+}
+
+// NewMemoryStateStore returns an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{entries: map[string]SitemapState{}}
+}
+
+// Get implements StateStore.
+func (s *MemoryStateStore) Get(_ context.Context, key string) (*SitemapState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Put implements StateStore.
+func (s *MemoryStateStore) Put(_ context.Context, key string, state SitemapState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = state
+	return nil
+}
+
+// unchangedSince reports whether the StateStore saw loc with a lastmod at
+// or after lastMod, so it need not be read again.
+func (f *SitemapFetcher) unchangedSince(ctx context.Context, loc *url.URL, lastMod *time.Time) bool {
+	if f.opts.StateStore == nil || lastMod == nil {
+		return false
+	}
+	state, err := f.opts.StateStore.Get(ctx, canonicalURLKey(loc))
+	if err != nil {
+		f.debug(ctx, "state lookup failed", urlAttr("url", loc), slog.Any("error", err))
+		return false
+	}
+	return state != nil && !lastMod.After(state.LastMod)
+}
+
+// recordState stores the index lastmod and ETag of a child urlset read
+// completely.
+func (f *SitemapFetcher) recordState(ctx context.Context, loc *url.URL, lastMod *time.Time, etag string) {
+	if f.opts.StateStore == nil || lastMod == nil {
+		return
+	}
+	state := SitemapState{LastMod: *lastMod, ETag: etag, WalkedAt: time.Now()}
+	if err := f.opts.StateStore.Put(ctx, canonicalURLKey(loc), state); err != nil {
+		f.debug(ctx, "state store failed", urlAttr("url", loc), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,113 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSitemapFetcher_StateStore(t *testing.T) {
+	var mu sync.Mutex
+	newsLastMod, deepLastMod := "2024-05-01", "2024-05-01"
+	var fetched []string
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched = append(fetched, r.URL.Path)
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/archive.xml</loc><lastmod>2020-01-01</lastmod></sitemap>
+  <sitemap><loc>/news.xml</loc><lastmod>` + newsLastMod + `</lastmod></sitemap>
+  <sitemap><loc>/undated.xml</loc></sitemap>
+  <sitemap><loc>/nested.xml</loc><lastmod>2020-01-01</lastmod></sitemap>
+</sitemapindex>`))
+		case "/nested.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/deep.xml</loc><lastmod>` + deepLastMod + `</lastmod></sitemap>
+</sitemapindex>`))
+		case "/archive.xml":
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		default:
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		}
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	var skipped []string
+	store := NewMemoryStateStore()
+	fetcher := New(Options{
+		IgnoreRobots: true,
+		StateStore:   store,
+		Hooks: Hooks{OnSitemapSkipped: func(loc *url.URL, reason string) {
+			skipped = append(skipped, loc.Path+" "+reason)
+		}},
+	})
+	walk := func() *WalkResult {
+		t.Helper()
+		fetched = nil
+		result, err := fetcher.WalkWithResult(context.Background(), sitemapURL, func(Item) error { return nil })
+		if err != nil {
+			t.Fatalf("walk failed: %v", err)
+		}
+		return result
+	}
+
+	walk()
+	if want := []string{"/sitemap.xml", "/archive.xml", "/news.xml", "/undated.xml", "/nested.xml", "/deep.xml"}; !reflect.DeepEqual(fetched, want) {
+		t.Fatalf("first walk: expected %v, got %v", want, fetched)
+	}
+	state, _ := store.Get(context.Background(), canonicalURLKey(mustParseURL(t, server.URL+"/archive.xml")))
+	if state == nil || state.ETag != `"v1"` {
+		t.Fatalf("expected the archive's ETag to be recorded, got %+v", state)
+	}
+	if state, _ := store.Get(context.Background(), canonicalURLKey(mustParseURL(t, server.URL+"/nested.xml"))); state != nil {
+		t.Fatalf("expected the nested index not to be recorded, got %+v", state)
+	}
+
+	// The nested index keeps its lastmod while a child of it changes.
+	newsLastMod, deepLastMod = "2024-05-02", "2024-05-02"
+	result := walk()
+	if want := []string{"/sitemap.xml", "/news.xml", "/undated.xml", "/nested.xml", "/deep.xml"}; !reflect.DeepEqual(fetched, want) {
+		t.Fatalf("second walk: expected %v, got %v", want, fetched)
+	}
+	if result.SitemapsSkipped != 1 || !reflect.DeepEqual(skipped, []string{"/archive.xml " + SkipUnchanged}) {
+		t.Fatalf("expected the unchanged archive to be skipped, got %d skipped (%v)", result.SitemapsSkipped, skipped)
+	}
+}
+
+func TestSitemapFetcher_StateStoreListSitemaps(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/pages.xml</loc><lastmod>2024-05-01</lastmod></sitemap>
+</sitemapindex>`))
+		default:
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+		}
+	}))
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	// Listing, counted or not, yields no URLs, so a later walk sharing the
+	// store must still read the urlset.
+	store := NewMemoryStateStore()
+	for _, count := range []bool{false, true} {
+		if _, err := New(Options{IgnoreRobots: true, StateStore: store, CountSitemapURLs: count}).ListSitemaps(context.Background(), sitemapURL); err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+	}
+	if state, _ := store.Get(context.Background(), canonicalURLKey(mustParseURL(t, server.URL+"/pages.xml"))); state != nil {
+		t.Fatalf("expected ListSitemaps not to record the urlset, got %+v", state)
+	}
+	items, err := collectItems(New(Options{IgnoreRobots: true, StateStore: store}), sitemapURL)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected the walk to read the listed urlset, got %v (%v)", items, err)
+	}
+}
@@ -0,0 +1,61 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+)
+
+// Span names and attribute keys recorded when Options.Tracer is set.
+const (
+	SpanSitemapFetch = "sitemap.fetch"
+	SpanRobotsFetch  = "robots.fetch"
+
+	AttrSitemapURLs   = "sitemap.urls"   // items yielded from the sitemap
+	AttrSitemapBytes  = "sitemap.bytes"  // decompressed bytes parsed
+	AttrSitemapVia    = "sitemap.via"    // how the sitemap was discovered
+	AttrSitemapDepth  = "sitemap.depth"  // sitemap index depth
+	AttrSitemapSource = "sitemap.source" // mirror that served the sitemap
+	AttrRobotsFound   = "robots.found"   // whether robots.txt was usable
+)
+
+// Tracer starts the spans of Options.Tracer. The oteltracer module adapts
+// an OpenTelemetry TracerProvider, so the library itself does not depend on
+// OpenTelemetry.
+type Tracer interface {
+	// Start starts a client span named name under ctx and returns a context
+	// carrying it, so an instrumented HTTP transport nests beneath it.
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	// End ends the span, recording err as its failure when it is not nil.
+	End(err error)
+}
+
+type spanKey struct{}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) End(error)                  {}
+
+// startSpan starts a span under ctx, or returns a no-op span when tracing is
+// disabled.
+func (f *SitemapFetcher) startSpan(ctx context.Context, name string, loc *url.URL, attrs ...slog.Attr) (context.Context, Span) {
+	if f.opts.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	attrs = append(attrs, slog.String("url.full", loc.String()))
+	ctx, span := f.opts.Tracer.Start(ctx, name, attrs...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// recordStatus attaches an HTTP status to the span carried by ctx.
+func (f *SitemapFetcher) recordStatus(ctx context.Context, statusCode int) {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		span.SetAttributes(slog.Int("http.response.status_code", statusCode))
+	}
+}
@@ -0,0 +1,98 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type recordingSpan struct {
+	name   string
+	parent *recordingSpan
+	attrs  map[string]slog.Value
+	err    error
+	ended  bool
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordingSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+type recordingSpanKey struct{}
+
+type recordingTracer struct {
+	spans *[]*recordingSpan
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	parent, _ := ctx.Value(recordingSpanKey{}).(*recordingSpan)
+	span := &recordingSpan{name: name, parent: parent, attrs: map[string]slog.Value{}}
+	span.SetAttributes(attrs...)
+	*t.spans = append(*t.spans, span)
+	return context.WithValue(ctx, recordingSpanKey{}, span), span
+}
+
+func TestSitemapFetcher_Tracing(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/a</loc></url>
+  <url><loc>/b</loc></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\n"))
+		case "/sitemap.xml":
+			_, _ = w.Write([]byte(sitemap))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var spans []*recordingSpan
+	parent := &recordingSpan{name: "crawl", attrs: map[string]slog.Value{}}
+	ctx := context.WithValue(context.Background(), recordingSpanKey{}, parent)
+	fetcher := New(Options{Tracer: recordingTracer{spans: &spans}})
+
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+	if err := fetcher.Walk(ctx, sitemapURL, func(Item) error { return nil }); err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+
+	byName := map[string]*recordingSpan{}
+	for _, span := range spans {
+		byName[span.name] = span
+	}
+	sitemapSpan, robotsSpan := byName[SpanSitemapFetch], byName[SpanRobotsFetch]
+	if sitemapSpan == nil || robotsSpan == nil {
+		t.Fatalf("expected sitemap and robots spans, got %d spans", len(spans))
+	}
+	if sitemapSpan.parent != parent || !sitemapSpan.ended || sitemapSpan.err != nil {
+		t.Fatalf("unexpected sitemap span: %+v", sitemapSpan)
+	}
+	if got := sitemapSpan.attrs["url.full"].String(); got != sitemapURL.String() {
+		t.Fatalf("expected url.full %s, got %s", sitemapURL, got)
+	}
+	if sitemapSpan.attrs["http.response.status_code"].Int64() != http.StatusOK ||
+		sitemapSpan.attrs[AttrSitemapURLs].Int64() != 2 ||
+		sitemapSpan.attrs[AttrSitemapBytes].Int64() != int64(len(sitemap)) {
+		t.Fatalf("unexpected sitemap span attributes: %v", sitemapSpan.attrs)
+	}
+	if !robotsSpan.ended || !robotsSpan.attrs[AttrRobotsFound].Bool() {
+		t.Fatalf("unexpected robots span: %+v", robotsSpan)
+	}
+	if len(parent.attrs) != 0 {
+		t.Fatalf("expected the caller's span to be left untouched, got %v", parent.attrs)
+	}
+}
@@ -0,0 +1,48 @@
+package gositemapfetcher
+
+import (
+	"net/http"
+)
+
+// configureClient returns client with the transport settings from opts
+// applied to a copy of its transport, leaving the caller's client (often
+// http.DefaultClient) untouched. ProxyURL and TLS are skipped for transports
+// other than *http.Transport; WrapTransport applies to any transport.
+func configureClient(client *http.Client, opts Options) *http.Client {
+	if opts.ProxyURL == nil && opts.TLS == nil && opts.WrapTransport == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	changed := false
+	if opts.ProxyURL != nil || opts.TLS != nil {
+		if transport, ok := base.(*http.Transport); ok {
+			transport = transport.Clone()
+			if opts.ProxyURL != nil {
+				transport.Proxy = http.ProxyURL(cloneURL(opts.ProxyURL))
+			}
+			if opts.TLS != nil {
+				transport.TLSClientConfig = opts.TLS.Clone()
+			}
+			base = transport
+			changed = true
+		} else {
+			opts.Logger.Debug("ProxyURL and TLS ignored: HTTPClient.Transport is not an *http.Transport")
+		}
+	}
+	if opts.WrapTransport != nil {
+		if wrapped := opts.WrapTransport(base); wrapped != nil {
+			base = wrapped
+			changed = true
+		}
+	}
+	if !changed {
+		return client
+	}
+
+	configured := *client
+	configured.Transport = base
+	return &configured
+}
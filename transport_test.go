@@ -0,0 +1,117 @@
+package gositemapfetcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSitemapFetcher_ProxyURL(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute target URL.
+		if r.URL.Host != "sitemaps.example" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		proxied.Add(1)
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+	}))
+	defer proxy.Close()
+
+	client := &http.Client{}
+	fetcher := New(Options{HTTPClient: client, ProxyURL: mustParseURL(t, proxy.URL), IgnoreRobots: true})
+	items, err := collectItems(fetcher, mustParseURL(t, "http://sitemaps.example/sitemap.xml"))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 || proxied.Load() != 1 {
+		t.Fatalf("expected the sitemap to come through the proxy, got %d items, %d proxied requests", len(items), proxied.Load())
+	}
+	if client.Transport != nil {
+		t.Fatalf("expected the caller's client to be left untouched")
+	}
+}
+
+func TestSitemapFetcher_TLS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("skipping test that requires network listener: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+	}))
+	server.Listener = listener
+	server.StartTLS()
+	defer server.Close()
+	sitemapURL := mustParseURL(t, server.URL+"/sitemap.xml")
+
+	if _, err := collectItems(New(Options{HTTPClient: &http.Client{}, IgnoreRobots: true}), sitemapURL); err == nil {
+		t.Fatalf("expected the self-signed certificate to be rejected")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	for _, config := range []*tls.Config{{RootCAs: roots}, {InsecureSkipVerify: true}} {
+		items, err := collectItems(New(Options{HTTPClient: &http.Client{}, TLS: config, IgnoreRobots: true}), sitemapURL)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("expected the TLS config to be used, got %d items (%v)", len(items), err)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestSitemapFetcher_WrapTransport(t *testing.T) {
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			_, _ = w.Write([]byte("User-agent: *\nAllow: /\n"))
+			return
+		}
+		if r.Header.Get("X-Signature") != "signed" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	var paths []string
+	client := &http.Client{}
+	fetcher := New(Options{
+		HTTPClient: client,
+		UserAgent:  "TestBot",
+		WrapTransport: func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Header.Get("User-Agent") != "TestBot" {
+					t.Errorf("expected the User-Agent to be set before the middleware, got %q", req.Header.Get("User-Agent"))
+				}
+				paths = append(paths, req.URL.Path)
+				req = req.Clone(req.Context())
+				req.Header.Set("X-Signature", "signed")
+				return next.RoundTrip(req)
+			})
+		},
+	})
+	items, err := collectItems(fetcher, mustParseURL(t, server.URL+"/sitemap.xml"))
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item through the wrapped transport, got %d", len(items))
+	}
+	if len(paths) != 2 || paths[0] != "/robots.txt" || paths[1] != "/sitemap.xml" {
+		t.Fatalf("expected robots and sitemap requests to pass through the middleware, got %v", paths)
+	}
+	if client.Transport != nil {
+		t.Fatalf("expected the caller's client to be left untouched")
+	}
+}
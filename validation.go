@@ -0,0 +1,181 @@
+package gositemapfetcher
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// SitemapNamespace is the XML namespace required by the sitemaps.org protocol.
+	SitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	// MaxEntriesPerSitemap is the protocol limit of URLs (or child sitemaps) per file.
+	MaxEntriesPerSitemap = 50000
+
+	maxStoredViolations = 1000
+)
+
+// Spec rules reported in SpecViolation.Rule.
+const (
+	RuleNamespace  = "namespace"
+	RuleMaxEntries = "max-entries"
+	RuleScope      = "scope"
+	RulePriority   = "priority"
+	RuleChangeFreq = "changefreq"
+	RuleLastMod    = "lastmod"
+	// RuleCDATA is a warning: CDATA is well-formed XML, but the protocol
+	// expects entity-escaped values and some consumers mishandle it.
+	RuleCDATA = "cdata"
+)
+
+// SpecViolation describes one sitemaps.org protocol violation.
+type SpecViolation struct {
+	Sitemap *url.URL
+	// Line is the line of the offending entry in the decompressed document,
+	// or 0 for violations of the whole document such as its namespace.
+	Line   int
+	Loc    string
+	Rule   string
+	Detail string
+}
+
+func (v SpecViolation) String() string {
+	where := v.Sitemap.String()
+	if v.Line > 0 {
+		where = fmt.Sprintf("%s:%d", v.Sitemap, v.Line)
+	}
+	if v.Loc == "" {
+		return fmt.Sprintf("%s: %s: %s", where, v.Rule, v.Detail)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", where, v.Rule, v.Detail, v.Loc)
+}
+
+// specValidator collects protocol violations and warnings across a walk.
+type specValidator struct {
+	violations []SpecViolation
+	total      int
+	warnings   []SpecViolation
+}
+
+func (v *specValidator) add(violation SpecViolation) {
+	v.total++
+	if len(v.violations) < maxStoredViolations {
+		v.violations = append(v.violations, violation)
+	}
+}
+
+func (v *specValidator) warn(warning SpecViolation) {
+	if len(v.warnings) < maxStoredViolations {
+		v.warnings = append(v.warnings, warning)
+	}
+}
+
+func (v *specValidator) checkCDATA(sitemap *url.URL, line int, loc string, cdata bool) {
+	if cdata {
+		v.warn(SpecViolation{Sitemap: cloneURL(sitemap), Line: line, Loc: loc, Rule: RuleCDATA, Detail: "entry uses CDATA instead of escaped text"})
+	}
+}
+
+func (v *specValidator) err() error {
+	if v.total == 0 {
+		return nil
+	}
+	return &ErrSpecViolations{Violations: v.violations, Total: v.total}
+}
+
+func (v *specValidator) checkRoot(sitemap *url.URL, root xml.StartElement) {
+	if root.Name.Space != SitemapNamespace {
+		v.add(SpecViolation{
+			Sitemap: cloneURL(sitemap),
+			Rule:    RuleNamespace,
+			Detail:  fmt.Sprintf("<%s> has namespace %q, want %q", root.Name.Local, root.Name.Space, SitemapNamespace),
+		})
+	}
+}
+
+// checkURLEntry reports violations for a <url> entry and returns whether the
+// entry may be yielded. The scope rule applies to base, the URL that served
+// the sitemap after redirects.
+func (v *specValidator) checkURLEntry(sitemap, base, loc *url.URL, entry xmlURLEntry, index int) bool {
+	ok := true
+	report := func(rule, detail string) {
+		v.add(SpecViolation{Sitemap: cloneURL(sitemap), Line: entry.line, Loc: loc.String(), Rule: rule, Detail: detail})
+		ok = false
+	}
+	if index > MaxEntriesPerSitemap {
+		if index == MaxEntriesPerSitemap+1 {
+			report(RuleMaxEntries, fmt.Sprintf("more than %d URLs in one sitemap", MaxEntriesPerSitemap))
+		}
+		return false
+	}
+	v.checkCDATA(sitemap, entry.line, loc.String(), entry.cdata)
+	if !inSitemapScope(base, loc) {
+		report(RuleScope, "URL is outside the sitemap's location")
+	}
+	if raw := strings.TrimSpace(entry.Priority); raw != "" {
+		priority := parsePriority(raw)
+		if priority == nil || !priorityInRange(*priority) {
+			report(RulePriority, fmt.Sprintf("priority %q is not in [0.0, 1.0]", raw))
+		}
+	}
+	if raw := strings.TrimSpace(entry.ChangeFreq); raw != "" {
+		if !ParseChangeFreq(raw).Valid() {
+			report(RuleChangeFreq, fmt.Sprintf("invalid changefreq %q", raw))
+		}
+	}
+	if raw := strings.TrimSpace(entry.LastMod); raw != "" && parseTimeValue(raw) == nil {
+		report(RuleLastMod, fmt.Sprintf("invalid lastmod %q", raw))
+	}
+	return ok
+}
+
+// checkSitemapEntry reports violations for a <sitemap> index entry and
+// returns whether the child sitemap may be followed.
+func (v *specValidator) checkSitemapEntry(sitemap *url.URL, entry xmlSitemapEntry, index int) bool {
+	if index > MaxEntriesPerSitemap {
+		if index == MaxEntriesPerSitemap+1 {
+			v.add(SpecViolation{
+				Sitemap: cloneURL(sitemap),
+				Line:    entry.line,
+				Rule:    RuleMaxEntries,
+				Detail:  fmt.Sprintf("more than %d sitemaps in one index", MaxEntriesPerSitemap),
+			})
+		}
+		return false
+	}
+	v.checkCDATA(sitemap, entry.line, strings.TrimSpace(entry.Loc), entry.cdata)
+	if raw := strings.TrimSpace(entry.LastMod); raw != "" && parseTimeValue(raw) == nil {
+		v.add(SpecViolation{
+			Sitemap: cloneURL(sitemap),
+			Line:    entry.line,
+			Loc:     strings.TrimSpace(entry.Loc),
+			Rule:    RuleLastMod,
+			Detail:  fmt.Sprintf("invalid lastmod %q", raw),
+		})
+	}
+	return true
+}
+
+// inSitemapScope reports whether loc lives on the sitemap's host and under
+// the directory containing the sitemap, as required by the protocol. A local
+// file has no published location yet, so every entry is in scope.
+func inSitemapScope(sitemap, loc *url.URL) bool {
+	if isFileURL(sitemap) {
+		return true
+	}
+	if !strings.EqualFold(sitemap.Scheme, loc.Scheme) || !strings.EqualFold(sitemap.Host, loc.Host) {
+		return false
+	}
+	dir := sitemap.Path
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		dir = dir[:i+1]
+	} else {
+		dir = "/"
+	}
+	path := loc.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.HasPrefix(path, dir)
+}
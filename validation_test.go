@@ -0,0 +1,118 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSitemapFetcher_StrictSpec(t *testing.T) {
+	const sitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://example.com/not-sitemaps">
+  <url><loc>/blog/ok</loc><priority>0.5</priority><changefreq>Daily</changefreq></url>
+  <url><loc>/blog/loud</loc><priority>10</priority></url>
+  <url><loc>/blog/nan</loc><priority>NaN</priority></url>
+  <url><loc>/blog/often</loc><changefreq>sometimes</changefreq></url>
+  <url><loc>/other/page</loc></url>
+  <url><loc>https://elsewhere.example/blog/page</loc></url>
+  <url><loc>/blog/when</loc><lastmod>yesterday</lastmod></url>
+  <url><loc><![CDATA[/blog/cdata]]></loc><lastmod><![CDATA[2024-01-02]]></lastmod></url>
+</urlset>`
+
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sitemap))
+	}))
+	defer server.Close()
+
+	sitemapURL, err := url.Parse(server.URL + "/blog/sitemap.xml")
+	if err != nil {
+		t.Fatalf("failed to parse sitemap URL: %v", err)
+	}
+
+	items, err := collectItems(New(Options{IgnoreRobots: true}), sitemapURL)
+	if err != nil {
+		t.Fatalf("walk failed: %v", err)
+	}
+	if len(items) != 8 {
+		t.Fatalf("expected 8 items without strict mode, got %d", len(items))
+	}
+
+	items = nil
+	result, err := New(Options{IgnoreRobots: true, StrictSpec: true}).WalkWithResult(context.Background(), sitemapURL, func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	var violations *ErrSpecViolations
+	if !errors.As(err, &violations) {
+		t.Fatalf("expected ErrSpecViolations, got %v", err)
+	}
+	if len(items) != 2 || items[1].Loc.String() != server.URL+"/blog/cdata" {
+		t.Fatalf("expected only the valid entries, CDATA included, to be yielded, got %v", items)
+	}
+	if len(result.SpecWarnings) != 1 || result.SpecWarnings[0].Rule != RuleCDATA || result.SpecWarnings[0].Line != 10 || result.SpecWarnings[0].Loc != server.URL+"/blog/cdata" {
+		t.Fatalf("expected one CDATA warning on line 10, got %v", result.SpecWarnings)
+	}
+
+	rules := map[string]int{}
+	for _, v := range violations.Violations {
+		rules[v.Rule]++
+	}
+	want := map[string]int{
+		RuleNamespace:  1,
+		RulePriority:   2,
+		RuleChangeFreq: 1,
+		RuleScope:      2,
+		RuleLastMod:    1,
+	}
+	for rule, count := range want {
+		if rules[rule] != count {
+			t.Fatalf("expected %d %s violations, got %d (%v)", count, rule, rules[rule], violations.Violations)
+		}
+	}
+	if violations.Total != 7 {
+		t.Fatalf("expected 7 violations, got %d", violations.Total)
+	}
+
+	lines := map[string]int{}
+	for _, v := range violations.Violations {
+		if v.Rule == RuleNamespace {
+			if v.Line != 0 {
+				t.Fatalf("expected no line for the namespace violation, got %d", v.Line)
+			}
+			continue
+		}
+		lines[v.Loc] = v.Line
+	}
+	wantLines := map[string]int{
+		server.URL + "/blog/loud":  4,
+		server.URL + "/blog/nan":   5,
+		server.URL + "/blog/often": 6,
+		server.URL + "/blog/when":  9,
+		server.URL + "/other/page": 7,
+	}
+	for loc, line := range wantLines {
+		if lines[loc] != line {
+			t.Fatalf("expected %s on line %d, got %d", loc, line, lines[loc])
+		}
+	}
+}
+
+func TestSitemapFetcher_StrictSpecRedirect(t *testing.T) {
+	cdn := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/blog/page</loc></url><url><loc>page</loc></url></urlset>`))
+	}))
+	defer cdn.Close()
+	origin := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, cdn.URL+"/blog/sitemap.xml", http.StatusMovedPermanently)
+	}))
+	defer origin.Close()
+
+	// Relative entries resolve against the host that served the sitemap, so
+	// they are in its scope.
+	items, err := collectItems(New(Options{IgnoreRobots: true, StrictSpec: true}), mustParseURL(t, origin.URL+"/sitemap.xml"))
+	if err != nil || len(items) != 2 {
+		t.Fatalf("expected both relative entries in scope, got %v (%v)", items, err)
+	}
+}
@@ -0,0 +1,169 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the re-walk interval used when WatchOptions.Interval is zero.
+const DefaultWatchInterval = time.Hour
+
+// ChangeKind classifies a Change reported by a Watcher.
+type ChangeKind int
+
+const (
+	// ChangeNewURL is a URL that appeared since the previous walk.
+	ChangeNewURL ChangeKind = iota + 1
+	// ChangeRemovedURL is a URL that disappeared since the previous walk.
+	ChangeRemovedURL
+	// ChangeUpdatedLastMod is a URL whose lastmod changed.
+	ChangeUpdatedLastMod
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeNewURL:
+		return "new"
+	case ChangeRemovedURL:
+		return "removed"
+	case ChangeUpdatedLastMod:
+		return "updated"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is one difference between two walks of a watched site.
+type Change struct {
+	Kind ChangeKind
+	// Item is the current item, or the last seen one for ChangeRemovedURL.
+	Item Item
+	// PreviousLastMod is the lastmod before a ChangeUpdatedLastMod.
+	PreviousLastMod *time.Time
+}
+
+// WatchOptions configures a Watcher.
+type WatchOptions struct {
+	Interval time.Duration // time between walks (0 => 1h)
+	// OnError is called when a walk fails; the previous snapshot is kept and
+	// the next walk runs on schedule. Nil makes Run return the error.
+	OnError func(err error)
+}
+
+// Watcher periodically re-walks a site and reports URLs that were added,
+// removed, or got a new lastmod. Give the walked SitemapFetcher a Cache (e.g.
+// NewMemoryCache) so unchanged sitemaps are revalidated with their ETag or
+// Last-Modified instead of being downloaded on every walk.
+type Watcher struct {
+	walker  SitemapWalker
+	website *url.URL
+	opts    WatchOptions
+
+	mu       sync.Mutex
+	previous *ResultSet
+	err      error
+}
+
+// NewWatcher returns a Watcher for website. Nothing is fetched until Check,
+// Run, or Changes is called.
+func NewWatcher(walker SitemapWalker, website *url.URL, opts WatchOptions) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	return &Watcher{walker: walker, website: cloneURL(website), opts: opts}
+}
+
+// Check walks the site once and returns the changes since the previous
+// successful walk. The first walk only records a baseline and returns no
+// changes. A failed walk returns its error and leaves the baseline as is, so
+// a partial walk never shows up as removed URLs.
+func (w *Watcher) Check(ctx context.Context) ([]Change, error) {
+	current, err := CollectResultSet(ctx, w.walker, w.website)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	previous := w.previous
+	w.previous = current
+	w.mu.Unlock()
+	if previous == nil {
+		return nil, nil
+	}
+
+	diff := DiffResultSets(previous, current)
+	changes := make([]Change, 0, len(diff.Added)+len(diff.Removed)+len(diff.Changed))
+	for _, item := range diff.Added {
+		changes = append(changes, Change{Kind: ChangeNewURL, Item: item})
+	}
+	for _, item := range diff.Removed {
+		changes = append(changes, Change{Kind: ChangeRemovedURL, Item: item})
+	}
+	for _, change := range diff.Changed {
+		changes = append(changes, Change{Kind: ChangeUpdatedLastMod, Item: change.Current, PreviousLastMod: change.Previous.LastMod})
+	}
+	return changes, nil
+}
+
+// Snapshot returns the items of the last successful walk, or nil before the
+// first one.
+func (w *Watcher) Snapshot() *ResultSet {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.previous
+}
+
+// Run checks the site immediately and then every Interval, passing each
+// change to onChange, until ctx is done or a walk fails without OnError.
+func (w *Watcher) Run(ctx context.Context, onChange func(Change)) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		changes, err := w.Check(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if w.opts.OnError == nil {
+				return err
+			}
+			w.opts.OnError(err)
+		}
+		for _, change := range changes {
+			onChange(change)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Changes runs the watcher in a new goroutine and delivers changes on the
+// returned channel, which is closed once Run returns; Err reports why.
+func (w *Watcher) Changes(ctx context.Context) <-chan Change {
+	out := make(chan Change)
+	go func() {
+		defer close(out)
+		err := w.Run(ctx, func(change Change) {
+			select {
+			case out <- change:
+			case <-ctx.Done():
+			}
+		})
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}()
+	return out
+}
+
+// Err returns the error that stopped Changes, valid once its channel is closed.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
@@ -0,0 +1,116 @@
+package gositemapfetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Check(t *testing.T) {
+	versions := []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/kept</loc><lastmod>2024-01-01</lastmod></url>
+  <url><loc>/gone</loc></url>
+</urlset>`,
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>/kept</loc><lastmod>2024-02-01</lastmod></url>
+  <url><loc>/new</loc></url>
+</urlset>`,
+	}
+	var version, notModified, failing atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" || failing.Load() == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		etag := strconv.Quote(strconv.Itoa(int(version.Load())))
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(versions[version.Load()]))
+	}))
+	defer server.Close()
+
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+	watcher := NewWatcher(New(Options{Cache: NewMemoryCache()}), sitemapURL, WatchOptions{})
+	ctx := context.Background()
+
+	changes, err := watcher.Check(ctx)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("expected a silent baseline, got %v (%v)", changes, err)
+	}
+	changes, err = watcher.Check(ctx)
+	if err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v (%v)", changes, err)
+	}
+	if notModified.Load() != 1 {
+		t.Fatalf("expected the unchanged sitemap to be revalidated, got %d 304s", notModified.Load())
+	}
+
+	failing.Store(1)
+	if _, err := watcher.Check(ctx); err == nil {
+		t.Fatalf("expected the failed walk to be reported")
+	}
+	failing.Store(0)
+
+	version.Store(1)
+	changes, err = watcher.Check(ctx)
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	got := map[string]ChangeKind{}
+	for _, change := range changes {
+		got[change.Item.Loc.Path] = change.Kind
+		if change.Kind == ChangeUpdatedLastMod && (change.PreviousLastMod == nil || change.PreviousLastMod.Month() != time.January) {
+			t.Fatalf("expected previous lastmod in January, got %v", change.PreviousLastMod)
+		}
+	}
+	if len(got) != 3 || got["/new"] != ChangeNewURL || got["/gone"] != ChangeRemovedURL || got["/kept"] != ChangeUpdatedLastMod {
+		t.Fatalf("unexpected changes: %v", got)
+	}
+	if watcher.Snapshot().Len() != 2 {
+		t.Fatalf("expected the snapshot to hold the last walk")
+	}
+}
+
+func TestWatcher_Changes(t *testing.T) {
+	var version atomic.Int32
+	server := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := version.Add(1)
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>/page-` + strconv.Itoa(int(n)) + `</loc></url></urlset>`))
+	}))
+	defer server.Close()
+
+	sitemapURL, _ := url.Parse(server.URL + "/sitemap.xml")
+	watcher := NewWatcher(New(Options{}), sitemapURL, WatchOptions{Interval: 10 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := watcher.Changes(ctx)
+	first := <-events
+	second := <-events
+	if first.Kind != ChangeNewURL || first.Item.Loc.Path != "/page-2" {
+		t.Fatalf("unexpected first change: %+v", first)
+	}
+	if second.Kind != ChangeRemovedURL || second.Item.Loc.Path != "/page-1" {
+		t.Fatalf("unexpected second change: %+v", second)
+	}
+	cancel()
+	for range events {
+	}
+	if !errors.Is(watcher.Err(), context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", watcher.Err())
+	}
+}